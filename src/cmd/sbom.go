@@ -0,0 +1,259 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/defenseunicorns/uds-pk/src/compare"
+	"github.com/defenseunicorns/uds-pk/src/scan"
+	"github.com/spf13/cobra"
+)
+
+// SBOMDiffOptions holds flags for the sbom diff subcommand.
+type SBOMDiffOptions struct {
+	Format string
+}
+
+func sbomDiffCmd() *cobra.Command {
+	options := &SBOMDiffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff BASE_SBOM NEW_SBOM",
+		Short: "List the components added, removed and changed in version or license between two CycloneDX or Syft JSON SBOMs",
+		Args:  cobra.ExactArgs(2),
+		RunE:  options.run,
+	}
+	cmd.Flags().StringVar(&options.Format, "format", compare.FormatMarkdown, fmt.Sprintf("Output format of the differences (%s)", strings.Join(compare.InventoryFormats, ", ")))
+	return cmd
+}
+
+func (o *SBOMDiffOptions) run(cmd *cobra.Command, args []string) error {
+	renderer, err := compare.NewInventoryRenderer(o.Format)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+
+	diff, err := diffSBOMs(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	output, err := renderer.RenderInventory([]compare.InventoryDiff{diff})
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), output)
+	return nil
+}
+
+func diffSBOMs(baseSBOMPath string, newSBOMPath string) (compare.InventoryDiff, error) {
+	base, err := compare.LoadInventory(baseSBOMPath)
+	if err != nil {
+		return compare.InventoryDiff{}, err
+	}
+	current, err := compare.LoadInventory(newSBOMPath)
+	if err != nil {
+		return compare.InventoryDiff{}, err
+	}
+	return compare.DiffInventories(base, current), nil
+}
+
+// SBOMLicensesOptions holds flags for the sbom licenses subcommand.
+type SBOMLicensesOptions struct {
+	Scan          ScanReleasedOptions
+	Released      bool
+	PolicyFile    string
+	Allow         []string
+	Deny          []string
+	FailOnUnknown bool
+	Format        string
+}
+
+func sbomLicensesCmd() *cobra.Command {
+	options := &SBOMLicensesOptions{}
+	cmd := &cobra.Command{
+		Use:   "licenses [SBOM_OR_DIRECTORY...]",
+		Short: "Check the licenses of the components of SBOMs against an allow/deny policy",
+		Long: "Check the licenses of the components of CycloneDX or Syft JSON SBOMs against an allow/deny policy. " +
+			"The SBOMs are files or directories of SBOMs, the SBOMs of the last released package (--released) " +
+			"or SBOMs generated for the images of the zarf.yaml (--generate-sboms).",
+		RunE: options.run,
+	}
+	addCommonFlags(cmd, &options.Scan.Scan)
+	addSBOMFlags(cmd, &options.Scan.Scan)
+	addScanReleasedFlags(cmd, &options.Scan)
+	cmd.Flags().BoolVar(&options.Released, "released", false, "Check the SBOMs of the last released version of the package")
+	cmd.Flags().StringVar(&options.PolicyFile, "policy", "", "Path to a YAML license policy file with allow, deny, failOnUnknown and ignore entries")
+	cmd.Flags().StringArrayVar(&options.Allow, "allow", []string{}, "Allow an SPDX license identifier, e.g. Apache-2.0; a trailing * matches a prefix. Can be repeated.")
+	cmd.Flags().StringArrayVar(&options.Deny, "deny", []string{}, "Deny an SPDX license identifier, e.g. AGPL-*; a trailing * matches a prefix. Can be repeated.")
+	cmd.Flags().BoolVar(&options.FailOnUnknown, "fail-on-unknown", false, "Treat components without a license as violations")
+	cmd.Flags().StringVar(&options.Format, "format", compare.FormatMarkdown, fmt.Sprintf("Output format of the license check (%s)", strings.Join(compare.InventoryFormats, ", ")))
+	return cmd
+}
+
+// policy combines the license policy file with the policy flags
+func (o *SBOMLicensesOptions) policy() (compare.LicensePolicy, error) {
+	policy := compare.LicensePolicy{}
+	if o.PolicyFile != "" {
+		var err error
+		policy, err = compare.LoadLicensePolicy(o.PolicyFile)
+		if err != nil {
+			return policy, err
+		}
+	}
+	policy.Allow = append(policy.Allow, o.Allow...)
+	policy.Deny = append(policy.Deny, o.Deny...)
+	policy.FailOnUnknown = policy.FailOnUnknown || o.FailOnUnknown
+	return policy, policy.Validate()
+}
+
+func (o *SBOMLicensesOptions) run(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	log := Logger(&ctx)
+	verbose := Verbose(&ctx)
+	policy, err := o.policy()
+	if err != nil {
+		return err
+	}
+	renderer, err := compare.NewLicenseRenderer(o.Format)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 && !o.Released && !o.Scan.Scan.GenerateSBOMs {
+		return errors.New("no SBOMs to check: pass SBOM files or directories, --released or --generate-sboms")
+	}
+	cmd.SilenceUsage = true
+
+	outputDirectory := o.Scan.Scan.OutputDirectory
+	if outputDirectory == "" {
+		outputDirectory, err = os.MkdirTemp("", "licenses")
+		if err != nil {
+			return err
+		}
+		if !o.Scan.Scan.DevNoCleanUp {
+			defer os.RemoveAll(outputDirectory) //nolint:errcheck
+		}
+	}
+	sboms, err := o.sboms(args, outputDirectory, log, verbose)
+	if err != nil {
+		return err
+	}
+
+	var results []compare.LicenseResult
+	violations := 0
+	for _, sbom := range sboms {
+		inventory, err := compare.LoadInventory(sbom.path)
+		if err != nil {
+			return err
+		}
+		result := policy.Evaluate(inventory)
+		result.Flavor = sbom.flavor
+		if result.Image.Name == "" {
+			result.Image.Name = filepath.Base(sbom.path)
+		}
+		results = append(results, result)
+		violations += len(result.Violations)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Flavor != results[j].Flavor {
+			return results[i].Flavor < results[j].Flavor
+		}
+		return results[i].Image.Name < results[j].Image.Name
+	})
+
+	output, err := renderer.RenderLicenses(results)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), output)
+	if violations > 0 {
+		return fmt.Errorf("%w: %d components violate the license policy", compare.ErrLicensePolicyViolation, violations)
+	}
+	return nil
+}
+
+type flavorSBOM struct {
+	path   string
+	flavor string
+}
+
+// sboms collects the SBOM files to check, fetching or generating them in outputDirectory as requested
+func (o *SBOMLicensesOptions) sboms(args []string, outputDirectory string, log *slog.Logger, verbose bool) ([]flavorSBOM, error) {
+	var sboms []flavorSBOM
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			sboms = append(sboms, flavorSBOM{path: arg})
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(arg, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no SBOM json files in %s", arg)
+		}
+		for _, file := range files {
+			sboms = append(sboms, flavorSBOM{path: file})
+		}
+	}
+	if !o.Released && !o.Scan.Scan.GenerateSBOMs {
+		return sboms, nil
+	}
+
+	pkg, err := parseZarfYaml(&o.Scan.Scan)
+	if err != nil {
+		return nil, err
+	}
+	if o.Released {
+		releasedDir := filepath.Join(outputDirectory, "released")
+		if err := os.MkdirAll(releasedDir, 0755); err != nil {
+			return nil, err
+		}
+		flavorToSboms, err := fetchReleasedSboms(&pkg, &o.Scan, releasedDir, log)
+		if err != nil {
+			return nil, err
+		}
+		if len(flavorToSboms) == 0 {
+			log.Warn("No released package found", slog.String("package", pkg.Metadata.Name))
+		}
+		for flavor, files := range flavorToSboms {
+			for _, file := range files {
+				sboms = append(sboms, flavorSBOM{path: file, flavor: flavor})
+			}
+		}
+	}
+	if o.Scan.Scan.GenerateSBOMs {
+		sbomDir := o.Scan.Scan.sbomDir(filepath.Join(outputDirectory, "zarfYaml"))
+		for flavor, images := range getImages(&pkg) {
+			generated, err := scan.GenerateSBOMs(images, o.Scan.Scan.Arch, sbomDir, log, verbose, o.Scan.Scan.ExecCommand)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range generated {
+				sboms = append(sboms, flavorSBOM{path: file, flavor: flavor})
+			}
+		}
+	}
+	return sboms, nil
+}
+
+func init() {
+	sbomCmd := &cobra.Command{
+		Use:   "sbom",
+		Short: "Collection of commands for SBOMs",
+	}
+	sbomCmd.AddCommand(sbomDiffCmd())
+	sbomCmd.AddCommand(sbomLicensesCmd())
+	rootCmd.AddCommand(sbomCmd)
+}
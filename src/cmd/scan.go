@@ -5,20 +5,20 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
-	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/defenseunicorns/uds-pk/src/compare"
 	"github.com/defenseunicorns/uds-pk/src/scan"
 	"github.com/defenseunicorns/uds-pk/src/utils"
-	"github.com/google/go-github/v89/github"
 	"github.com/spf13/cobra"
 	"github.com/zarf-dev/zarf/src/api/v1alpha1"
 	"go.yaml.in/yaml/v4"
@@ -29,19 +29,37 @@ import (
 // command options
 type CompareOptions struct {
 	AllowDifferentImages bool
+	FailOn               []string
+	MaxNew               []string
+	PolicyFile           string
+	VEXFiles             []string
+	Format               string
+	RegistryMirrorsFile  string
 }
 
 type ImageFetchingOptions struct {
 	PublicPackagesPrefix  string
 	PrivatePackagesPrefix string
 	RepoOwner             string
+	Registry              string
+	PlainHTTP             bool
+	RegistryUsername      string
+	RegistryTokenVarName  string
+	ReleasedVersion       string
+	IncludePrereleases    bool
 }
 
 type CommonScanOptions struct {
 	OutputDirectory  string
 	DevNoCleanUp     bool
 	ZarfYamlLocation string
+	Arch             string
+	GenerateSBOMs    bool
+	SBOMCacheDir     string
+	GrypeDBArchive   string
 	ExecCommand      utils.RunProcess
+	// grypeDB is the database imported from GrypeDBArchive, once it was imported
+	grypeDB *scan.GrypeDB
 }
 
 type ScanReleasedOptions struct {
@@ -58,12 +76,14 @@ type ScanAndCompareOptions struct {
 	Compare                  CompareOptions
 	ScanAndCompareOutputFile string
 	ImageNameOverrides       []string
+	AllArchitectures         bool
+	SBOMDiff                 bool
 }
 
 // helper structs
-type PackageWithVersion struct {
-	encodedPackageUrl string
-	versions          []*github.PackageVersion
+type RepositoryWithTags struct {
+	repository string
+	tags       []string
 }
 
 func scanReleasedCmd() *cobra.Command {
@@ -74,6 +94,7 @@ func scanReleasedCmd() *cobra.Command {
 		RunE:  options.run,
 	}
 	addCommonFlags(cmd, &options.Scan)
+	addGrypeFlags(cmd, &options.Scan)
 	addScanReleasedFlags(cmd, options)
 
 	return cmd
@@ -103,6 +124,8 @@ func scanZarfYamlCmd() *cobra.Command {
 		RunE:  options.run,
 	}
 	addCommonFlags(cmd, &options.Scan)
+	addGrypeFlags(cmd, &options.Scan)
+	addSBOMFlags(cmd, &options.Scan)
 
 	return cmd
 }
@@ -138,40 +161,124 @@ func compareCmd() *cobra.Command {
 
 func addCompareFlags(cmd *cobra.Command, options *CompareOptions) {
 	cmd.Flags().BoolVarP(&options.AllowDifferentImages, "allow-different-images", "d", false, "Allow comparing scans for different images")
+	cmd.Flags().StringArrayVar(&options.FailOn, "fail-on", []string{}, "Exit with an error when new vulnerabilities at or above a severity are found (format: new-<severity>, e.g. new-critical). Can be repeated.")
+	cmd.Flags().StringArrayVar(&options.MaxNew, "max-new", []string{}, "Exit with an error when the number of new vulnerabilities of a severity exceeds a limit (format: <severity>=<count>, use 'any' for the total). Can be repeated.")
+	cmd.Flags().StringVar(&options.PolicyFile, "policy", "", "Path to a YAML policy file with failOn and maxNew rules")
+	cmd.Flags().StringVar(&options.Format, "format", compare.FormatMarkdown, fmt.Sprintf("Output format of the comparison (%s)", strings.Join(compare.Formats, ", ")))
+	cmd.Flags().StringArrayVar(&options.VEXFiles, "vex", []string{}, "Path to an OpenVEX document, CycloneDX VEX document or YAML ignore list with accepted vulnerabilities. Can be repeated.")
+	cmd.Flags().StringVar(&options.RegistryMirrorsFile, "registry-mirrors", "", "Path to a YAML file with registries or repository prefixes that mirror the same images")
 }
 
-func (options *CompareOptions) run(_ *cobra.Command, args []string) error {
+func (options *CompareOptions) run(cmd *cobra.Command, args []string) error {
 	baseScanPath := args[0]
 	newScanPath := args[1]
-	markdownTable, err := compareScans(baseScanPath, newScanPath, options)
+	policy, err := options.policy()
 	if err != nil {
 		return err
 	}
+	vex, err := options.vex()
+	if err != nil {
+		return err
+	}
+	mirrors, err := options.mirrors()
+	if err != nil {
+		return err
+	}
+	renderer, err := compare.NewRenderer(options.Format)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
 
-	fmt.Println(markdownTable)
-	return nil
+	comparison, newBySeverity, err := compareScans(baseScanPath, newScanPath, options, vex, mirrors)
+	if err != nil {
+		return err
+	}
+
+	violations := policy.Evaluate(newBySeverity)
+	output, err := renderer.Render(compare.Report{Comparisons: []compare.Comparison{comparison}, Violations: violations})
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return policyError(violations)
+}
+
+// policy combines the policy file with the policy flags
+func (options *CompareOptions) policy() (compare.Policy, error) {
+	policy := compare.Policy{}
+	if options.PolicyFile != "" {
+		var err error
+		policy, err = compare.LoadPolicy(options.PolicyFile)
+		if err != nil {
+			return policy, err
+		}
+	}
+	policy.FailOn = append(policy.FailOn, options.FailOn...)
+	if err := policy.AddMaxNew(options.MaxNew); err != nil {
+		return policy, err
+	}
+	return policy, policy.Validate()
+}
+
+// vex loads the suppressions from the VEX files and warns about expired ones
+func (options *CompareOptions) vex() (compare.VEX, error) {
+	vex, err := compare.LoadVEX(options.VEXFiles...)
+	if err != nil {
+		return vex, err
+	}
+	for _, expired := range vex.Expired(time.Now()) {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: ignore for %s in %s expired on %s and is no longer applied\n",
+			expired.ID, expired.Source, expired.Expires.Format(time.DateOnly))
+	}
+	return vex, nil
+}
+
+// mirrors loads the registry mirror rules used to match images across registries
+func (options *CompareOptions) mirrors() (compare.RegistryMirrors, error) {
+	if options.RegistryMirrorsFile == "" {
+		return compare.RegistryMirrors{}, nil
+	}
+	return compare.LoadRegistryMirrors(options.RegistryMirrorsFile)
+}
+
+func policyError(violations []string) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w:\n  %s", compare.ErrPolicyViolation, strings.Join(violations, "\n  "))
 }
 
-func compareScans(baseScanPath string, newScanPath string, options *CompareOptions) (string, error) {
-	baseScan, newScan, err := compare.LoadScans(baseScanPath, newScanPath)
+func compareScans(baseScanPath string, newScanPath string, options *CompareOptions, vex compare.VEX, mirrors compare.RegistryMirrors) (compare.Comparison, map[string]int, error) {
+	baseScan, newScan, warnings, err := compare.LoadScans(baseScanPath, newScanPath)
 	if err != nil {
-		return "", err
+		return compare.Comparison{}, nil, err
+	}
+	for _, warning := range warnings {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
 	}
 
+	sameImage := mirrors.SameRepository(baseScan.Metadata.Component.Name, newScan.Metadata.Component.Name)
 	baseScan.Metadata.Component.Name = compare.TrimDockerRegistryPrefixes(baseScan.Metadata.Component.Name)
 	newScan.Metadata.Component.Name = compare.TrimDockerRegistryPrefixes(newScan.Metadata.Component.Name)
 
-	if baseScan.Metadata.Component.Name != newScan.Metadata.Component.Name {
+	if !sameImage {
 		if !options.AllowDifferentImages {
-			return "", fmt.Errorf("these scans are not for the same image: %s != %s", baseScan.Metadata.Component.Name, newScan.Metadata.Component.Name)
+			return compare.Comparison{}, nil, fmt.Errorf("these scans are not for the same image: %s != %s", baseScan.Metadata.Component.Name, newScan.Metadata.Component.Name)
 		} else {
 			_, _ = fmt.Fprintf(os.Stderr, "Warning: these scans are not for the same image: %s != %s\n", baseScan.Metadata.Component.Name, newScan.Metadata.Component.Name)
 		}
 	}
 
+	now := time.Now()
+	suppressed := append(vex.Apply(&baseScan, now), vex.Apply(&newScan, now)...)
 	vulnStatus := compare.GenerateComparisonMap(baseScan, newScan)
 
-	return compare.GenerateComparisonMarkdown(baseScan, newScan, vulnStatus)
+	comparison, err := compare.NewComparison(baseScan, newScan, vulnStatus, suppressed)
+	if err != nil {
+		return compare.Comparison{}, nil, err
+	}
+	return comparison, compare.CountNewBySeverity(newScan, vulnStatus), nil
 }
 
 func scanAndCompareCmd() *cobra.Command {
@@ -184,9 +291,14 @@ func scanAndCompareCmd() *cobra.Command {
 		RunE: options.Run,
 	}
 	addCommonFlags(cmd, &options.Scan.Scan)
+	addGrypeFlags(cmd, &options.Scan.Scan)
+	addSBOMFlags(cmd, &options.Scan.Scan)
 	addScanReleasedFlags(cmd, &options.Scan)
 	addCompareFlags(cmd, &options.Compare)
+	cmd.Flags().StringVar(&options.ScanAndCompareOutputFile, "output-file", "", "Write the consolidated report for all images and flavors to this file instead of stdout")
 	cmd.Flags().StringArrayVar(&options.ImageNameOverrides, "image-name-override", []string{}, "Override image name mapping for comparison (format: old=new). Can be repeated.")
+	cmd.Flags().BoolVar(&options.SBOMDiff, "sbom-diff", false, "Also list the components added, removed and changed in version or license for each image. Implies --generate-sboms")
+	cmd.Flags().BoolVar(&options.AllArchitectures, "all-archs", false, "Scan and compare every architecture of the released package instead of only --arch")
 	return cmd
 }
 
@@ -194,6 +306,22 @@ func (options *ScanAndCompareOptions) Run(cmd *cobra.Command, _ []string) error
 	ctx := cmd.Context()
 	log := Logger(&ctx)
 	verbose := Verbose(&ctx)
+	policy, err := options.Compare.policy()
+	if err != nil {
+		return err
+	}
+	vex, err := options.Compare.vex()
+	if err != nil {
+		return err
+	}
+	mirrors, err := options.Compare.mirrors()
+	if err != nil {
+		return err
+	}
+	renderer, err := compare.NewRenderer(options.Compare.Format)
+	if err != nil {
+		return err
+	}
 	outputDirectory := options.Scan.Scan.OutputDirectory
 	if outputDirectory == "" {
 		var err error
@@ -203,22 +331,123 @@ func (options *ScanAndCompareOptions) Run(cmd *cobra.Command, _ []string) error
 		}
 		log.Info("Output directory", slog.String("dir", outputDirectory))
 	}
-	zarfYamlScanOutDir := path.Join(outputDirectory, "zarfYaml")
-	log.Debug("Scanning zarf.yaml images")
-	zarfYamlScanResults, err := ScanZarfYamlImages(zarfYamlScanOutDir, &options.Scan.Scan, log, verbose)
+	pkg, err := parseZarfYaml(&options.Scan.Scan)
 	if err != nil {
 		return err
 	}
+	// imported once, before the options are copied for each architecture
+	db, err := options.Scan.Scan.vulnerabilityDB(log, verbose)
+	if err != nil {
+		return err
+	}
+	architectures := []string{options.Scan.Scan.Arch}
+	if options.AllArchitectures {
+		architectures, err = ReleasedArchitectures(&options.Scan, log)
+		if err != nil {
+			return err
+		}
+	}
 
-	releasedScanOutDir := path.Join(outputDirectory, "released")
-	releasedScanResults, err := ScanReleased(&ctx, releasedScanOutDir, &options.Scan, log, verbose)
+	var comparisons []compare.Comparison
+	newBySeverity := map[string]int{}
+
+	for _, arch := range architectures {
+		scanOptions := options.Scan
+		scanOptions.Scan.Arch = arch
+		// the components of the current images are read from their generated SBOMs
+		scanOptions.Scan.GenerateSBOMs = scanOptions.Scan.GenerateSBOMs || options.SBOMDiff
+		archOutputDirectory := outputDirectory
+		if options.AllArchitectures {
+			archOutputDirectory = path.Join(outputDirectory, arch)
+		}
+		archComparisons, err := options.scanAndCompareArchitecture(&ctx, archOutputDirectory, &scanOptions, vex, mirrors, newBySeverity, log, verbose)
+		if err != nil {
+			return err
+		}
+		if options.AllArchitectures {
+			for i := range archComparisons {
+				archComparisons[i].Arch = arch
+			}
+		}
+		comparisons = append(comparisons, archComparisons...)
+	}
+	sort.SliceStable(comparisons, func(i, j int) bool {
+		if comparisons[i].Flavor != comparisons[j].Flavor {
+			return comparisons[i].Flavor < comparisons[j].Flavor
+		}
+		if comparisons[i].Arch != comparisons[j].Arch {
+			return comparisons[i].Arch < comparisons[j].Arch
+		}
+		return comparisons[i].NewImage.Name < comparisons[j].NewImage.Name
+	})
+	violations := policy.Evaluate(newBySeverity)
+	report := compare.Report{
+		Name:         pkg.Metadata.Name,
+		Comparisons:  comparisons,
+		Violations:   violations,
+		Consolidated: true,
+	}
+	if db != nil {
+		report.DatabaseBuilt = &db.Built
+	}
+	output, err := renderer.Render(report)
 	if err != nil {
 		return err
 	}
-	log.Debug("Comparing scans", slog.Any("current", zarfYamlScanResults), slog.Any("released", releasedScanResults))
+	if options.ScanAndCompareOutputFile == "" {
+		// an empty markdown report is left out, documents in the other formats are read by tools and always written
+		if _, markdown := renderer.(compare.MarkdownRenderer); len(comparisons) > 0 || !markdown {
+			fmt.Println(output)
+		}
+	} else {
+		if dir := filepath.Dir(options.ScanAndCompareOutputFile); dir != "." && dir != "" {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return err
+			}
+		}
+		if err := os.WriteFile(options.ScanAndCompareOutputFile, []byte(output), 0o644); err != nil {
+			return err
+		}
+	}
+	return policyError(violations)
+}
 
-	var builder strings.Builder
+// indexScansByRepository indexes scan files by the repository of the image recorded in their metadata
+func indexScansByRepository(scanFiles map[string]string, mirrors compare.RegistryMirrors, log *slog.Logger) (compare.RepositoryIndex, error) {
+	references := map[string]string{}
+	for _, scanFile := range scanFiles {
+		reference, err := compare.ScanReference(scanFile)
+		if err != nil {
+			return compare.RepositoryIndex{}, err
+		}
+		if reference == "" {
+			log.Warn("Scan does not record the scanned image, it cannot be matched", slog.String("scan", scanFile))
+			continue
+		}
+		references[reference] = scanFile
+	}
+	return compare.NewRepositoryIndex(mirrors, references), nil
+}
+
+// scanAndCompareArchitecture scans the zarf.yaml images and the last released package for a single architecture
+// and compares the scans per flavor. New vulnerabilities are added to newBySeverity.
+func (options *ScanAndCompareOptions) scanAndCompareArchitecture(ctx *context.Context, outputDirectory string, scanOptions *ScanReleasedOptions,
+	vex compare.VEX, mirrors compare.RegistryMirrors, newBySeverity map[string]int, log *slog.Logger, verbose bool) ([]compare.Comparison, error) {
+	zarfYamlScanOutDir := path.Join(outputDirectory, "zarfYaml")
+	log.Debug("Scanning zarf.yaml images", slog.String("arch", scanOptions.Scan.Arch))
+	zarfYamlScanResults, err := ScanZarfYamlImages(zarfYamlScanOutDir, &scanOptions.Scan, log, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	releasedScanOutDir := path.Join(outputDirectory, "released")
+	releasedScanResults, err := ScanReleased(ctx, releasedScanOutDir, scanOptions, log, verbose)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug("Comparing scans", slog.Any("current", zarfYamlScanResults), slog.Any("released", releasedScanResults))
 
+	var comparisons []compare.Comparison
 	for flavor, flavorResults := range zarfYamlScanResults {
 		log.Debug("Scanning flavor", slog.String("flavor", flavor))
 		releasedFlavorResults, found := releasedScanResults[flavor]
@@ -226,72 +455,85 @@ func (options *ScanAndCompareOptions) Run(cmd *cobra.Command, _ []string) error
 			log.Warn("No released scan results found for flavor", slog.String("flavor", flavor))
 			continue // TODO: present scanning results for the flavor that has been added?
 		}
+		releasedIndex, err := indexScansByRepository(releasedFlavorResults, mirrors, log)
+		if err != nil {
+			return nil, err
+		}
+		releasedSBOMs := map[string]string{}
+		for sbomFile, scanFile := range releasedFlavorResults {
+			releasedSBOMs[scanFile] = sbomFile
+		}
 		for key, scanFile := range flavorResults {
-			imageName := extractImageName(key)
-			for _, override := range options.ImageNameOverrides {
-				parts := strings.SplitN(override, "=", 2)
-				if len(parts) == 2 && parts[1] == imageName {
-					imageName = parts[0]
-					log.Debug("Found override image name for image", slog.String("image", key), slog.String("override", imageName))
-					break
-				}
+			imageRef, err := compare.ScanReference(scanFile)
+			if err != nil {
+				return nil, err
+			}
+			if imageRef == "" {
+				imageRef = key
 			}
-			releasedScanFile, found := findMatchingScan(imageName, releasedFlavorResults, log)
+			releasedScanFile, found, overridden := options.findReleasedScan(imageRef, releasedIndex, mirrors, log)
 			if !found {
-				fmt.Fprintf(&builder, "### %s: No released scan found for image\n", imageName)
-				builder.WriteString("This is likely a new image\n")
 				// aligning with how it worked in callable-scan, we print all the vulnerabilities as existing ones
 				// for images that are newly added
 				releasedScanFile = scanFile
 			}
 			log.Debug("Comparing files: ", slog.String("base", releasedScanFile), slog.String("new", scanFile))
-			markdownTable, err := compareScans(releasedScanFile, scanFile, &options.Compare)
+			compareOptions := options.Compare
+			// an image name override deliberately compares different repositories
+			compareOptions.AllowDifferentImages = compareOptions.AllowDifferentImages || overridden
+			comparison, imageNewBySeverity, err := compareScans(releasedScanFile, scanFile, &compareOptions, vex, mirrors)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			if options.ScanAndCompareOutputFile != "" {
-				if builder.Len() > 0 {
-					builder.WriteString("\n\n")
+			comparison.Flavor = flavor
+			comparison.NoBaseline = !found
+			if options.SBOMDiff && found {
+				baseSBOM := releasedSBOMs[releasedScanFile]
+				newSBOM := scan.SBOMFile(strings.TrimPrefix(key, "registry:"), scanOptions.Scan.Arch, scanOptions.Scan.sbomDir(zarfYamlScanOutDir))
+				diff, err := diffSBOMs(baseSBOM, newSBOM)
+				if err != nil {
+					return nil, err
 				}
-				builder.WriteString(markdownTable)
-			} else {
-				fmt.Println(markdownTable)
+				comparison.Components = &diff
 			}
-		}
-	}
-	if options.ScanAndCompareOutputFile != "" {
-		if dir := filepath.Dir(options.ScanAndCompareOutputFile); dir != "." && dir != "" {
-			if err := os.MkdirAll(dir, 0o755); err != nil {
-				return err
+			comparisons = append(comparisons, comparison)
+			for severity, count := range imageNewBySeverity {
+				newBySeverity[severity] += count
 			}
 		}
-		if err := os.WriteFile(options.ScanAndCompareOutputFile, []byte(builder.String()), 0o644); err != nil {
-			return err
-		}
 	}
-	return nil
+	return comparisons, nil
 }
 
-func extractImageName(imageURL string) string {
-	// Remove the scheme prefix if present
-	imageURL = strings.TrimPrefix(imageURL, "registry:")
-	imageURL = strings.TrimPrefix(imageURL, "docker:")
-	// Remove the tag (after the colon)
-	imagePath := imageURL
-	if idx := strings.LastIndex(imageURL, ":"); idx != -1 {
-		imagePath = imageURL[:idx]
+// findReleasedScan finds the released scan of an image, preferring the image an override maps it to.
+// It reports whether a released scan was found and whether it was found through an override.
+func (options *ScanAndCompareOptions) findReleasedScan(imageRef string, releasedIndex compare.RepositoryIndex,
+	mirrors compare.RegistryMirrors, log *slog.Logger) (string, bool, bool) {
+	repository := mirrors.Repository(imageRef)
+	for _, override := range options.ImageNameOverrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if parts[1] != path.Base(repository) && mirrors.Repository(parts[1]) != repository {
+			continue
+		}
+		log.Debug("Found override image name for image", slog.String("image", imageRef), slog.String("override", parts[0]))
+		if scanFile, found := releasedIndex.Find(parts[0]); found {
+			return scanFile, true, true
+		}
+		log.Warn("No released scan found for image name override", slog.String("override", override))
 	}
-	// Get the last segment (image name)
-	return filepath.Base(imagePath)
+	scanFile, found := releasedIndex.Find(imageRef)
+	return scanFile, found, false
 }
 
-func findMatchingScan(imageName string, releasedResults map[string]string, logger *slog.Logger) (string, bool) {
-	for _, scanFile := range releasedResults {
-		if strings.Contains(scanFile, "/"+imageName+"_") {
-			return scanFile, true
-		}
+// sbomDir is the directory generated SBOMs are kept in: the cache directory or, without one, the output directory
+func (options *CommonScanOptions) sbomDir(zarfYamlScanOutDir string) string {
+	if options.SBOMCacheDir != "" {
+		return options.SBOMCacheDir
 	}
-	return "", false
+	return path.Join(zarfYamlScanOutDir, "sboms")
 }
 
 func ScanZarfYamlImages(zarfYamlScanOutDir string, options *CommonScanOptions, log *slog.Logger, verbose bool) (map[string]map[string]string, error) {
@@ -312,14 +554,32 @@ func ScanZarfYamlImages(zarfYamlScanOutDir string, options *CommonScanOptions, l
 	}
 
 	log.Debug("Temporary directory", slog.String("dir", tempDir))
+	db, err := options.vulnerabilityDB(log, verbose)
+	if err != nil {
+		return scanImagesResult, err
+	}
+	tooling := scan.GrypeTooling(db, log, options.ExecCommand)
 	flavorToImages := getImages(&pkg)
 	for flavor, images := range flavorToImages {
 		targetFlavorDir := path.Join(zarfYamlScanOutDir, flavor)
-		// TODO: cache image fetching and scanning so that we don't redo this on duplicates
-		scanImagesResult[flavor], err = scan.Images(images, targetFlavorDir, log, verbose, options.ExecCommand)
+		scanned := time.Now().UTC()
+		var sboms, digests map[string]string
+		if options.GenerateSBOMs {
+			scanImagesResult[flavor], err = scan.ImagesFromSBOMs(images, options.Arch, options.sbomDir(zarfYamlScanOutDir), targetFlavorDir, db, log, verbose, options.ExecCommand)
+			sboms = map[string]string{}
+			for key := range scanImagesResult[flavor] {
+				sboms[key] = scan.SBOMFile(strings.TrimPrefix(key, "registry:"), options.Arch, options.sbomDir(zarfYamlScanOutDir))
+			}
+		} else {
+			// TODO: cache image fetching and scanning so that we don't redo this on duplicates
+			scanImagesResult[flavor], digests, err = scan.Images(images, options.Arch, targetFlavorDir, db, log, verbose, options.ExecCommand)
+		}
 		if err != nil {
 			return scanImagesResult, err
 		}
+		if err := recordProvenance(scanImagesResult[flavor], scanned, sboms, digests, "generated", tooling); err != nil {
+			return scanImagesResult, err
+		}
 	}
 
 	log.Info("Successfully scanned images used in the package.")
@@ -333,35 +593,6 @@ func ScanReleased(ctx *context.Context, outDirectory string, options *ScanReleas
 	if err1 != nil {
 		return sbomScanResults, err1
 	}
-	pkgName := pkg.Metadata.Name
-	log.Debug("Package name", slog.String("pkgName", pkgName))
-	publicRepoUrl, err3 := determineRepositoryUrl(pkgName, options.Fetch.RepoOwner, options.Fetch.PublicPackagesPrefix, "packages/uds", log)
-	if err3 != nil {
-		return sbomScanResults, err3
-	}
-	encodedPublicUrl := url.PathEscape(publicRepoUrl)
-
-	privateRepoUrl, err4 := determineRepositoryUrl(pkgName, options.Fetch.RepoOwner, options.Fetch.PrivatePackagesPrefix, "packages/private/uds", log)
-	if err4 != nil {
-		return sbomScanResults, err4
-	}
-	encodedPrivateUrl := url.PathEscape(privateRepoUrl)
-
-	client := NewGithubClient(ctx)
-
-	var packageUrls []string
-	if exists, err := checkPackageExistenceInRepo(client, ctx, options.Fetch.RepoOwner, encodedPublicUrl, log); err != nil {
-		return sbomScanResults, fmt.Errorf("failed to check package existence for URL: %s, %w", encodedPublicUrl, err)
-	} else if exists {
-		log.Debug("Package exists in public repo, adding it to fetch", slog.String("packageUrl", publicRepoUrl))
-		packageUrls = append(packageUrls, publicRepoUrl)
-	}
-	if exists, err := checkPackageExistenceInRepo(client, ctx, options.Fetch.RepoOwner, encodedPrivateUrl, log); err != nil {
-		return sbomScanResults, fmt.Errorf("failed to check package existence for URL: %s, %w", encodedPrivateUrl, err)
-	} else if exists {
-		log.Debug("Package exists in private repo, adding it to fetch", slog.String("packageUrl", privateRepoUrl))
-		packageUrls = append(packageUrls, privateRepoUrl)
-	}
 
 	// create a temporary directory dropped after the program finishes:
 	tempDir, err := os.MkdirTemp("", "sboms")
@@ -374,25 +605,28 @@ func ScanReleased(ctx *context.Context, outDirectory string, options *ScanReleas
 		defer os.RemoveAll(tempDir) //nolint:errcheck
 	}
 
-	flavors := determineFlavors(&pkg)
-	log.Debug("Flavors", slog.Any("flavors", flavors))
-
-	flavorToSboms, err := fetchSbomsForFlavors(ctx, client, packageUrls, flavors, options.Fetch.RepoOwner, tempDir, log)
+	db, err := options.Scan.vulnerabilityDB(log, verbose)
+	if err != nil {
+		return sbomScanResults, err
+	}
+	flavorToSboms, err := fetchReleasedSboms(&pkg, options, tempDir, log)
 	if err != nil {
 		return sbomScanResults, err
 	}
 
 	log.Debug("Would analyze SBOMs for vulnerabilities", slog.Any("sboms", flavorToSboms))
+	tooling := scan.GrypeTooling(db, log, options.Scan.ExecCommand)
 
-	targetSbomsDir := path.Join(tempDir, "targetSboms")
-	if err := os.Mkdir(targetSbomsDir, 0755); err != nil {
+	// the SBOMs are kept with the scans, so that the components of the released images can be compared
+	targetSbomsDir := path.Join(outDirectory, "sboms")
+	if err := os.MkdirAll(targetSbomsDir, 0755); err != nil {
 		return sbomScanResults, err
 	}
 
 	// move flavor jsons to a single directory:
 	for flavor, sboms := range flavorToSboms {
 		targetFlavorDir := path.Join(targetSbomsDir, flavor)
-		if err := os.Mkdir(targetFlavorDir, 0755); err != nil {
+		if err := os.MkdirAll(targetFlavorDir, 0755); err != nil {
 			return sbomScanResults, err
 		}
 		for _, sbom := range sboms {
@@ -408,10 +642,18 @@ func ScanReleased(ctx *context.Context, outDirectory string, options *ScanReleas
 			}
 		}
 		outputDir := path.Join(outDirectory, flavor) + string(os.PathSeparator)
-		resultFiles, err := scan.SBOMs(targetFlavorDir, outputDir, log, verbose, options.Scan.ExecCommand)
+		scanned := time.Now().UTC()
+		resultFiles, err := scan.SBOMs(targetFlavorDir, outputDir, db, log, verbose, options.Scan.ExecCommand)
 		if err != nil {
 			return sbomScanResults, err
 		}
+		sboms := map[string]string{}
+		for sbom := range resultFiles {
+			sboms[sbom] = sbom
+		}
+		if err := recordProvenance(resultFiles, scanned, sboms, nil, "released package", tooling); err != nil {
+			return sbomScanResults, err
+		}
 		sbomScanResults[flavor] = resultFiles
 	}
 
@@ -420,107 +662,202 @@ func ScanReleased(ctx *context.Context, outDirectory string, options *ScanReleas
 	return sbomScanResults, nil
 }
 
-// NewGithubClient exposed for testing purposes
-var NewGithubClient = createGithubClient
-var FetchSboms = utils.FetchSboms
-
-func getAuthToken() string {
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	if githubToken != "" {
-		return githubToken
+// recordProvenance writes what produced each scan, started at scanned, into its CycloneDX metadata. sboms
+// maps the keys of the scans made from an SBOM to the SBOM, described as coming from sbomSource; the other
+// scans are of images, and digests maps their keys to the digest grype resolved for the image.
+func recordProvenance(scanFiles map[string]string, scanned time.Time, sboms map[string]string, digests map[string]string,
+	sbomSource string, tooling scan.Tooling) error {
+	timestamp := scanned.UTC().Truncate(time.Second)
+	for key, scanFile := range scanFiles {
+		provenance := compare.Provenance{
+			ToolVersion:  CLIVersion,
+			GrypeVersion: tooling.GrypeVersion,
+			DBSchema:     tooling.DB.Schema,
+			ImageDigest:  digests[key],
+			Timestamp:    &timestamp,
+		}
+		if !tooling.DB.Built.IsZero() {
+			provenance.DBBuilt = &tooling.DB.Built
+		}
+		if sbom, found := sboms[key]; found {
+			generator, digest, err := compare.SBOMOrigin(sbom)
+			if err != nil {
+				return err
+			}
+			provenance.SBOMSource = sbomSource
+			if generator != "" {
+				provenance.SBOMSource += " (" + generator + ")"
+			}
+			provenance.ImageDigest = digest
+		}
+		if err := compare.WriteProvenance(scanFile, provenance); err != nil {
+			return err
+		}
 	}
-	return os.Getenv("GITLAB_RELEASE_TOKEN")
+	return nil
 }
 
-func createGithubClient(ctx *context.Context) *github.Client {
-	log := Logger(ctx)
-	// GitHub REST API requires raw token, not base64-encoded
-	token := getAuthToken()
-	if token == "" {
-		log.Warn("No GitHub token found in environment (GITHUB_TOKEN or GITLAB_RELEASE_TOKEN)")
-	} else {
-		log.Debug("GitHub token found for REST API", slog.Int("length", len(token)))
+// fetchReleasedSboms downloads the SBOMs of the last released package of every flavor to tempDir
+func fetchReleasedSboms(pkg *v1alpha1.ZarfPackage, options *ScanReleasedOptions, tempDir string, log *slog.Logger) (map[string][]string, error) {
+	client, repositories, err := releasedRepositories(pkg.Metadata.Name, &options.Fetch, log)
+	if err != nil {
+		return nil, err
 	}
-	c, _ := github.NewClient(github.WithAuthToken(token))
-	return c
+	flavors := determineFlavors(pkg)
+	log.Debug("Flavors", slog.Any("flavors", flavors))
+	return fetchSbomsForFlavors(client, repositories, flavors, options, tempDir, log)
 }
 
-func fetchSbomsForFlavors(ctx *context.Context, client *github.Client,
-	packageUrls []string, flavors []string, repoOwner string, tempDir string,
-	log *slog.Logger) (map[string][]string, error) {
-	flavorToSboms := map[string][]string{}
+// releasedRepositories lists the tags of the public and private repositories the package is released to.
+// Repositories missing from the registry are left out.
+func releasedRepositories(pkgName string, options *ImageFetchingOptions, log *slog.Logger) (*utils.RegistryClient, []RepositoryWithTags, error) {
+	log.Debug("Package name", slog.String("pkgName", pkgName))
+	publicRepoUrl, err := determineRepositoryUrl(pkgName, options.RepoOwner, options.PublicPackagesPrefix, "packages/uds", log)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateRepoUrl, err := determineRepositoryUrl(pkgName, options.RepoOwner, options.PrivatePackagesPrefix, "packages/private/uds", log)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	var packagesWithVersions []PackageWithVersion
-	for _, packageUrl := range packageUrls {
-		encodedPackageUrl := url.PathEscape(packageUrl)
-		log.Debug("Package url", slog.String("packageUrl", packageUrl), slog.String("encodedPackageUrl", encodedPackageUrl))
-		versions, _, err := client.Organizations.PackageGetAllVersions(
-			*ctx,
-			repoOwner,
-			"container",
-			packageUrl,
-			&github.PackageListOptions{},
-		)
+	client := utils.NewRegistryClient(options.Registry, options.PlainHTTP, options.RegistryUsername, options.RegistryTokenVarName)
+
+	var repositories []RepositoryWithTags
+	for i, packageUrl := range []string{publicRepoUrl, privateRepoUrl} {
+		repository := path.Join(options.RepoOwner, packageUrl)
+		private := i == 1
+		tags, err := client.ListTags(repository, log)
+		if errors.Is(err, utils.ErrNotFound) {
+			log.Debug("Package not found in registry", slog.String("registry", client.Registry), slog.String("repository", repository), slog.Any("error", err))
+			continue
+		}
+		// without credentials, registries like ghcr.io deny access to private repositories instead of reporting them
+		// as not found
+		if errors.Is(err, utils.ErrUnauthorized) && private && !client.HasCredentials() {
+			log.Warn("Access to the private package was denied, treating it as not released; configure the registry credentials to include it",
+				slog.String("registry", client.Registry), slog.String("repository", repository))
+			continue
+		}
 		if err != nil {
-			log.Debug("failed to get package versions: ", slog.Any("error", err))
+			return nil, nil, fmt.Errorf("failed to list tags of %s/%s: %w", client.Registry, repository, err)
 		}
-		packagesWithVersions = append(packagesWithVersions, PackageWithVersion{
-			encodedPackageUrl: encodedPackageUrl,
-			versions:          versions,
-		})
+		log.Debug("Package exists in registry, adding it to fetch", slog.String("repository", repository))
+		repositories = append(repositories, RepositoryWithTags{repository: repository, tags: tags})
 	}
+	return client, repositories, nil
+}
 
+// ReleasedArchitectures returns the architectures the last released package was published for, across all
+// flavors. It falls back to the architecture from the options when nothing has been released yet.
+func ReleasedArchitectures(options *ScanReleasedOptions, log *slog.Logger) ([]string, error) {
+	pkg, err := parseZarfYaml(&options.Scan)
+	if err != nil {
+		return nil, err
+	}
+	client, repositories, err := releasedRepositories(pkg.Metadata.Name, &options.Fetch, log)
+	if err != nil {
+		return nil, err
+	}
+	flavors := determineFlavors(&pkg)
+	var architectures []string
 	for _, flavor := range flavors {
-		tag, packageUrl, err := findNewestTagForFlavor(packagesWithVersions, flavor, log)
+		tag, repository := findReleasedTagForFlavor(repositories, flavor, &options.Fetch, log)
+		if tag == "" {
+			continue
+		}
+		flavorArchitectures, err := client.Architectures(repository, tag, log)
 		if err != nil {
-			return flavorToSboms, err
+			return nil, fmt.Errorf("failed to list architectures of %s:%s: %w", repository, tag, err)
 		}
+		for _, arch := range flavorArchitectures {
+			if !slices.Contains(architectures, arch) {
+				architectures = append(architectures, arch)
+			}
+		}
+	}
+	if len(architectures) == 0 {
+		return []string{options.Scan.Arch}, nil
+	}
+	sort.Strings(architectures)
+	log.Debug("Released architectures", slog.Any("architectures", architectures))
+	return architectures, nil
+}
+
+func fetchSbomsForFlavors(client *utils.RegistryClient, repositories []RepositoryWithTags, flavors []string,
+	options *ScanReleasedOptions, tempDir string, log *slog.Logger) (map[string][]string, error) {
+	flavorToSboms := map[string][]string{}
+
+	for _, flavor := range flavors {
+		tag, repository := findReleasedTagForFlavor(repositories, flavor, &options.Fetch, log)
 		if tag == "" {
 			continue
 		}
-		sboms, err := fetchSboms(tempDir, tag, repoOwner, packageUrl, log)
+		sboms, err := fetchSboms(client, tempDir, tag, repository, options.Scan.Arch, log)
+		if errors.Is(err, utils.ErrNotFound) {
+			log.Warn("Released package not found for the architecture", slog.String("tag", tag), slog.String("arch", options.Scan.Arch), slog.Any("error", err))
+			continue
+		}
 		if err != nil {
 			return flavorToSboms, err
 		}
 		flavorToSboms[flavor] = sboms
 	}
+	if options.Fetch.ReleasedVersion != "" && len(repositories) > 0 && len(flavorToSboms) == 0 {
+		return flavorToSboms, fmt.Errorf("released version %s not found for any flavor", options.Fetch.ReleasedVersion)
+	}
 
 	return flavorToSboms, nil
 }
 
-func findNewestTagForFlavor(versions []PackageWithVersion, flavor string, log *slog.Logger) (string, string, error) {
-	for _, packageWithVersion := range versions {
-		packageUrl := packageWithVersion.encodedPackageUrl
-		versions := packageWithVersion.versions
-		for _, version := range versions {
-			var metadataMap map[string]interface{}
-			if err := json.Unmarshal(version.Metadata, &metadataMap); err == nil {
-				if container, ok := metadataMap["container"].(map[string]interface{}); ok {
-					if tags, ok := container["tags"].([]interface{}); ok {
-						// select the newest tag:
-						for _, tRaw := range tags {
-							if tag, ok := tRaw.(string); ok {
-								if strings.HasSuffix(tag, flavor) {
-									log.Debug("Found tag", slog.String("tag", tag))
-									return tag, packageUrl, nil
-								}
-							}
-						}
-					}
+// findReleasedTagForFlavor selects the tag of the flavor to compare against: the pinned released version
+// if one is set, otherwise the tag with the highest semantic version. Pre-releases are skipped unless
+// requested, and tags of other flavors that end with this flavor's name (e.g. fips-upstream for upstream)
+// are not considered, even when that flavor is no longer built.
+func findReleasedTagForFlavor(repositories []RepositoryWithTags, flavor string, options *ImageFetchingOptions,
+	log *slog.Logger) (string, string) {
+	var newest utils.ReleaseTag
+	newestRepository := ""
+	for _, repository := range repositories {
+		for _, tag := range repository.tags {
+			releaseTag, ok := utils.ParseReleaseTag(tag, flavor)
+			if !ok {
+				continue
+			}
+			if options.ReleasedVersion != "" {
+				if strings.TrimPrefix(releaseTag.Version, "v") == strings.TrimPrefix(options.ReleasedVersion, "v") {
+					log.Debug("Found pinned released version", slog.String("tag", tag), slog.String("repository", repository.repository))
+					return tag, repository.repository
 				}
+				continue
+			}
+			if releaseTag.IsPrerelease() && !options.IncludePrereleases {
+				log.Debug("Skipping pre-release tag", slog.String("tag", tag))
+				continue
+			}
+			if newestRepository == "" || releaseTag.Compare(newest) > 0 {
+				newest, newestRepository = releaseTag, repository.repository
 			}
 		}
 	}
-	log.Warn("No tags found for flavor", slog.String("flavor", flavor))
-	return "", "", nil
+	if options.ReleasedVersion != "" {
+		log.Warn("Released version not found for flavor", slog.String("version", options.ReleasedVersion), slog.String("flavor", flavor))
+		return "", ""
+	}
+	if newestRepository == "" {
+		log.Warn("No tags found for flavor", slog.String("flavor", flavor))
+		return "", ""
+	}
+	log.Debug("Found tag", slog.String("tag", newest.Tag), slog.String("repository", newestRepository))
+	return newest.Tag, newestRepository
 }
 
-func fetchSboms(tempDir string, tag string, repoOwner string, packageUrl string, log *slog.Logger) ([]string, error) {
+func fetchSboms(client *utils.RegistryClient, tempDir string, tag string, repository string, arch string, log *slog.Logger) ([]string, error) {
 	subDir, dirCreationErr := os.MkdirTemp(tempDir, tag)
 	if dirCreationErr != nil {
 		return nil, dirCreationErr
 	}
-	if sboms, err := FetchSboms(repoOwner, packageUrl, tag, subDir, log); err != nil {
+	if sboms, err := client.FetchSboms(repository, tag, arch, subDir, log); err != nil {
 		log.Debug("Error inspecting sbom", slog.Any("error", err))
 		return nil, err
 	} else {
@@ -529,33 +866,6 @@ func fetchSboms(tempDir string, tag string, repoOwner string, packageUrl string,
 	}
 }
 
-func checkPackageExistenceInRepo(client *github.Client, ctx *context.Context, owner string, pkgUrl string, log *slog.Logger) (bool, error) {
-	log.Debug("Checking if package exists", slog.String("url", pkgUrl), slog.String("owner", owner))
-	apiPath := fmt.Sprintf("/orgs/%s/packages/container/%s", owner, pkgUrl)
-	req, err := client.NewRequest(*ctx, "GET", apiPath, nil)
-	if err != nil {
-		return false, err
-	}
-
-	resp, err := client.Do(req, nil)
-	if err != nil {
-		if resp != nil && resp.StatusCode == http.StatusNotFound {
-			log.Debug("Package not found", slog.String("url", pkgUrl))
-			return false, nil
-		}
-		return false, err
-	}
-	defer resp.Body.Close() //nolint:errcheck
-	switch resp.StatusCode {
-	case http.StatusOK:
-		return true, nil
-	case http.StatusNotFound:
-		return false, nil
-	default:
-		return false, fmt.Errorf("unexpected status: %d", resp.StatusCode)
-	}
-}
-
 func determineRepositoryUrl(pkgName string, repoOwner string, prefix string, path string, log *slog.Logger) (string, error) {
 	const defenseUnicorns = "defenseunicorns"
 	log.Debug("Determining repository URL", slog.String("pkgName", pkgName),
@@ -623,11 +933,44 @@ func addScanReleasedFlags(cmd *cobra.Command, options *ScanReleasedOptions) {
 	cmd.Flags().StringVarP(&options.Fetch.PublicPackagesPrefix, "public-packages-prefix", "c", "", "The prefix for public packages")
 	cmd.Flags().StringVarP(&options.Fetch.PrivatePackagesPrefix, "private-packages-prefix", "r", "private", "The prefix for private packages")
 	cmd.Flags().StringVarP(&options.Fetch.RepoOwner, "repo-owner", "w", "uds-packages", "Repository owner")
+	cmd.Flags().StringVar(&options.Fetch.Registry, "registry", utils.DefaultRegistry, "OCI registry the released packages are published to (e.g. ghcr.io, a Harbor or GitLab container registry host)")
+	cmd.Flags().BoolVar(&options.Fetch.PlainHTTP, "plain-http", false, "Use plain HTTP instead of HTTPS for the registry")
+	cmd.Flags().StringVar(&options.Fetch.RegistryUsername, "registry-username", "", "Username for the registry, used together with the token from --registry-token-var-name")
+	cmd.Flags().StringVar(&options.Fetch.ReleasedVersion, "released-version", "", "Compare against this released version of the package (e.g. 1.10.0-uds.0) instead of the newest one")
+	cmd.Flags().BoolVar(&options.Fetch.IncludePrereleases, "include-prereleases", false, "Consider pre-release versions when selecting the newest released version")
+	cmd.Flags().StringVar(&options.Fetch.RegistryTokenVarName, "registry-token-var-name", "", "Environment variable name for the registry password or token (default: GITHUB_TOKEN, then GITLAB_RELEASE_TOKEN)")
 }
 
 func addCommonFlags(cmd *cobra.Command, options *CommonScanOptions) {
 	cmd.Flags().StringVarP(&options.ZarfYamlLocation, "zarf-yaml-path", "p", "./zarf.yaml", "Path to the zarf.yaml file")
 	cmd.Flags().StringVarP(&options.OutputDirectory, "output-directory", "o", "", "Output directory")
 	cmd.Flags().BoolVar(&options.DevNoCleanUp, "dev-no-cleanup", false, "For development: do not clean up temporary files")
+	cmd.Flags().StringVarP(&options.Arch, "arch", "a", "amd64", "Architecture of the images and released package SBOMs to scan (e.g. amd64, arm64)")
 	options.ExecCommand = utils.OsRunProcess
 }
+
+func addGrypeFlags(cmd *cobra.Command, options *CommonScanOptions) {
+	cmd.Flags().StringVar(&options.GrypeDBArchive, "grype-db", "", "Scan offline with the grype vulnerability database archive at this path instead of grype's own, auto-updating database")
+}
+
+// vulnerabilityDB imports the --grype-db archive the first time it is needed. It is nil when grype uses its own database.
+func (options *CommonScanOptions) vulnerabilityDB(log *slog.Logger, verbose bool) (*scan.GrypeDB, error) {
+	if options.GrypeDBArchive == "" || options.grypeDB != nil {
+		return options.grypeDB, nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	db, err := scan.ImportGrypeDB(options.GrypeDBArchive, filepath.Join(cacheDir, "uds-pk", "grype-db"), log, verbose, options.ExecCommand)
+	if err != nil {
+		return nil, err
+	}
+	options.grypeDB = db
+	return db, nil
+}
+
+func addSBOMFlags(cmd *cobra.Command, options *CommonScanOptions) {
+	cmd.Flags().BoolVar(&options.GenerateSBOMs, "generate-sboms", false, "Generate SBOMs of the current images with syft and scan those, like the SBOMs of the released package")
+	cmd.Flags().StringVar(&options.SBOMCacheDir, "sbom-cache-dir", "", "Directory the generated SBOMs are cached in and reused from on later runs, keyed by image reference (default: no cache)")
+}
@@ -0,0 +1,45 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package cmd
+
+import (
+	"log/slog"
+	"testing"
+)
+
+func TestFindReleasedTagForFlavor(t *testing.T) {
+	repositories := []RepositoryWithTags{
+		{repository: "uds-packages/app", tags: []string{
+			"1.9.0-uds.0-upstream", "1.10.0-uds.0-upstream", "1.11.0-rc.1-uds.0-upstream", "1.12.0-uds.0-fips-upstream",
+			"1.10.0-uds.0-registry1", "latest",
+		}},
+		{repository: "uds-packages/private/app", tags: []string{"1.10.0-uds.1-unicorn", "1.10.0-uds.10-unicorn", "1.10.0-uds.9-unicorn"}},
+	}
+	tests := []struct {
+		name           string
+		flavor         string
+		options        ImageFetchingOptions
+		wantTag        string
+		wantRepository string
+	}{
+		{"newest release", "upstream", ImageFetchingOptions{}, "1.10.0-uds.0-upstream", "uds-packages/app"},
+		{"prereleases requested", "upstream", ImageFetchingOptions{IncludePrereleases: true}, "1.11.0-rc.1-uds.0-upstream", "uds-packages/app"},
+		{"longer flavor", "fips-upstream", ImageFetchingOptions{}, "1.12.0-uds.0-fips-upstream", "uds-packages/app"},
+		// fips-upstream is no longer among the flavors of the package, its tags still are not upstream tags
+		{"retired longer flavor", "upstream", ImageFetchingOptions{}, "1.10.0-uds.0-upstream", "uds-packages/app"},
+		{"retired longer flavor with prereleases", "upstream", ImageFetchingOptions{IncludePrereleases: true}, "1.11.0-rc.1-uds.0-upstream", "uds-packages/app"},
+		{"numeric revision", "unicorn", ImageFetchingOptions{}, "1.10.0-uds.10-unicorn", "uds-packages/private/app"},
+		{"pinned version", "upstream", ImageFetchingOptions{ReleasedVersion: "1.9.0-uds.0"}, "1.9.0-uds.0-upstream", "uds-packages/app"},
+		{"pinned version missing", "registry1", ImageFetchingOptions{ReleasedVersion: "1.9.0-uds.0"}, "", ""},
+		{"no tags", "fips", ImageFetchingOptions{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag, repository := findReleasedTagForFlavor(repositories, tt.flavor, &tt.options, slog.Default())
+			if tag != tt.wantTag || repository != tt.wantRepository {
+				t.Errorf("findReleasedTagForFlavor(%q) = %q, %q; want %q, %q", tt.flavor, tag, repository, tt.wantTag, tt.wantRepository)
+			}
+		})
+	}
+}
@@ -4,10 +4,12 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/defenseunicorns/uds-pk/src/stig"
 	"github.com/spf13/cobra"
@@ -15,90 +17,572 @@ import (
 
 // GenerateChecklistOptions holds flags for the generate-checklist subcommand.
 type GenerateChecklistOptions struct {
-	ProfilePath string
-	XCCDFPath   string
-	OutputPath  string
+	ProfilePath   string
+	XCCDFPaths    []string
+	OutputPath    string
+	STIG          string
+	All           bool
+	Split         bool
+	Format        string
+	MergePath     string
+	RulesPaths    []string
+	Explain       bool
+	ExplainReport string
+	CacheDir      string
+	Offline       bool
 }
 
 func generateChecklistCmd() *cobra.Command {
 	options := &GenerateChecklistOptions{}
 	cmd := &cobra.Command{
 		Use:   "generate-checklist",
-		Short: "Generate a STIG checklist (.cklb) from a STIG profile and XCCDF content",
-		RunE:  options.run,
+		Short: "Generate a STIG checklist (.cklb or .ckl) from a STIG profile and XCCDF content",
+		Long: "Generate a STIG checklist (.cklb or .ckl) from a STIG profile and XCCDF content. By default the checklist is " +
+			"generated for the first supported STIG of the profile; --stig selects another one and --all generates " +
+			"a checklist with every supported STIG of the profile.",
+		RunE: options.run,
 	}
 	cmd.Flags().StringVar(&options.ProfilePath, "profile", "stig-profile.yaml", "Path to stig-profile.yaml")
-	cmd.Flags().StringVar(&options.XCCDFPath, "xccdf", "", "Path to XCCDF XML file (optional when the profile identifies a supported DISA STIG)")
-	cmd.Flags().StringVar(&options.OutputPath, "output", "", "Output .cklb file path (default: <app_name>-<stig>-<revision>.cklb)")
+	cmd.Flags().StringArrayVar(&options.XCCDFPaths, "xccdf", []string{}, "Path to XCCDF XML file (optional when the profile identifies a supported DISA STIG). With several STIGs, use <stig id>=<path>. Can be repeated.")
+	cmd.Flags().StringVar(&options.OutputPath, "output", "", "Output checklist file path (default: <app_name>-<stig>-<revision>.<format>), or the output directory with --split")
+	cmd.Flags().StringVar(&options.STIG, "stig", "", "ID of the STIG of the profile to generate the checklist for, e.g. rhel9_v2r7")
+	cmd.Flags().BoolVar(&options.All, "all", false, "Generate a single checklist with every supported STIG of the profile")
+	cmd.Flags().BoolVar(&options.Split, "split", false, "With --all, write one checklist per STIG instead of a single checklist")
+	cmd.Flags().StringVar(&options.Format, "format", stig.FormatCKLB, fmt.Sprintf("Checklist format, one of: %s (STIG Viewer 3 JSON), %s (STIG Viewer 2 XML)", stig.FormatCKLB, stig.FormatCKL))
+	cmd.Flags().StringVar(&options.MergePath, "merge", "", "Path to a previous .cklb or .ckl checklist whose reviewed statuses, finding details and comments are carried over")
+	cmd.Flags().StringArrayVar(&options.RulesPaths, "rules", []string{}, "Path to a rules file evaluated before the default rules of its STIG. Can be repeated; earlier files take precedence.")
+	cmd.Flags().BoolVar(&options.Explain, "explain", false, "Print which evaluation rule and profile fields decided the automated status of each rule, and whether a profile override replaced it")
+	cmd.Flags().StringVar(&options.ExplainReport, "explain-report", "", "Path to write the evaluation trace of --explain to as JSON")
+	cmd.Flags().StringVar(&options.CacheDir, "cache-dir", defaultSTIGCacheDir(), "Directory the downloaded DISA STIG archives are cached in")
+	cmd.Flags().BoolVar(&options.Offline, "offline", false, "Only use STIG archives from the cache, never download them")
+	cmd.MarkFlagsMutuallyExclusive("stig", "all")
 	return cmd
 }
 
+// stigChecklist is the checklist generated for one STIG of the profile
+type stigChecklist struct {
+	definition stig.STIGDefinition
+	checklist  *stig.Checklist
+	stig       *stig.STIG
+}
+
 func (o *GenerateChecklistOptions) run(cmd *cobra.Command, _ []string) error {
 	ctx := cmd.Context()
 	log := Logger(&ctx)
 
+	if o.Split && !o.All {
+		return fmt.Errorf("--split requires --all")
+	}
+	if !slices.Contains(stig.Formats, o.Format) {
+		return fmt.Errorf("unsupported checklist format %q, expected one of: %s", o.Format, strings.Join(stig.Formats, ", "))
+	}
+	// without a cache directory, archives are downloaded into a temporary directory
+	var cache *stig.XCCDFCache
+	if o.CacheDir != "" {
+		cache = &stig.XCCDFCache{Dir: o.CacheDir, Offline: o.Offline}
+	} else if o.Offline {
+		return fmt.Errorf("--offline requires --cache-dir")
+	}
+
 	log.Info("Loading profile", slog.String("path", o.ProfilePath))
 	profile, err := stig.LoadProfile(o.ProfilePath)
 	if err != nil {
 		return fmt.Errorf("failed to load profile: %w", err)
 	}
+	if err := loadRuleSets(profile, o.RulesPaths); err != nil {
+		return err
+	}
+
+	ids, err := o.stigIDs(profile)
+	if err != nil {
+		return err
+	}
+	xccdfPaths, err := xccdfPathsByID(o.XCCDFPaths, ids)
+	if err != nil {
+		return err
+	}
+	var previous *stig.Checklist
+	if o.MergePath != "" {
+		if previous, err = stig.LoadChecklist(o.MergePath); err != nil {
+			return fmt.Errorf("failed to load checklist to merge: %w", err)
+		}
+	}
+
+	var generated []stigChecklist
+	explanation := stig.Explanation{STIGs: []stig.STIGExplanation{}}
+	// the profile each STIG was generated with, to tell automated dispositions from reviews when merging
+	profiles := map[string]*stig.Profile{}
+	for _, id := range ids {
+		if err := profile.UseSTIG(id); err != nil {
+			return fmt.Errorf("failed to select STIG: %w", err)
+		}
+		definition, err := stig.LookupSTIGDefinition(id)
+		if err != nil {
+			return fmt.Errorf("failed to select STIG: %w", err)
+		}
+
+		xccdfPath, cleanup, err := stig.ResolveXCCDFPath(ctx, profile, xccdfPaths[id], cache)
+		if err != nil {
+			return fmt.Errorf("failed to resolve XCCDF: %w", err)
+		}
+		log.Info("Parsing XCCDF", slog.String("path", xccdfPath))
+		s, err := stig.ParseXCCDF(xccdfPath, profile)
+		cleanup()
+		if err != nil {
+			return fmt.Errorf("failed to parse XCCDF: %w", err)
+		}
+		if o.Explain || o.ExplainReport != "" {
+			explained, err := stig.ExplainSTIG(profile, s)
+			if err != nil {
+				return fmt.Errorf("failed to explain evaluation: %w", err)
+			}
+			explanation.STIGs = append(explanation.STIGs, explained)
+		}
+		generated = append(generated, stigChecklist{definition: definition, checklist: stig.BuildChecklist(profile, s), stig: s})
+		selected := *profile
+		profiles[s.STIGID] = &selected
+	}
+
+	// checklists to write by output path, in the order they are written
+	var outputPaths []string
+	outputs := map[string]*stig.Checklist{}
+	if o.All && !o.Split {
+		var definitions []stig.STIGDefinition
+		var checklists []*stig.Checklist
+		for _, g := range generated {
+			definitions = append(definitions, g.definition)
+			checklists = append(checklists, g.checklist)
+		}
+		outputPath := o.OutputPath
+		if outputPath == "" {
+			outputPath = stig.ChecklistFilename(stig.CombinedChecklistTitle(profile.AppName, definitions), o.Format)
+		}
+		outputPaths = append(outputPaths, outputPath)
+		outputs[outputPath] = stig.CombineChecklists(stig.CombinedChecklistTitle(profile.AppName, definitions), checklists)
+	} else {
+		for _, g := range generated {
+			outputPath := o.OutputPath
+			if outputPath == "" || o.Split {
+				outputPath = filepath.Join(o.OutputPath, stig.ChecklistFilename(stig.ChecklistTitle(profile.AppName, g.definition), o.Format))
+			}
+			outputPaths = append(outputPaths, outputPath)
+			outputs[outputPath] = g.checklist
+		}
+	}
+
+	w := cmd.OutOrStdout()
+	for _, outputPath := range outputPaths {
+		checklist := outputs[outputPath]
+		var merged stig.MergeResult
+		if previous != nil {
+			merged = stig.MergeChecklist(checklist, previous, profiles)
+		}
+		if err := writeChecklist(outputPath, o.Format, checklist); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(w, "Generated %s\n", outputPath)
+		if previous != nil {
+			_, _ = fmt.Fprintf(w, "Merged %d reviewed rules from %s\n", merged.Carried, o.MergePath)
+			for _, conflict := range merged.Conflicts {
+				_, _ = fmt.Fprintf(w, "  conflict: %s\n", conflict)
+			}
+		}
+	}
+
+	// Print summary
+	for _, g := range generated {
+		if len(generated) > 1 {
+			_, _ = fmt.Fprintf(w, "%s (%s)\n", g.definition.DisplayName, g.definition.ID)
+		}
+		counts := map[string]int{}
+		for _, r := range g.stig.Rules {
+			counts[r.Status]++
+		}
+		_, _ = fmt.Fprintf(w, "Total rules: %d\n", len(g.stig.Rules))
+		for _, status := range []string{"not_a_finding", "not_applicable", "not_reviewed", "open"} {
+			if c, ok := counts[status]; ok {
+				_, _ = fmt.Fprintf(w, "  %s: %d\n", status, c)
+			}
+		}
+	}
 
-	outputPath := o.OutputPath
-	if outputPath == "" {
-		if profile.SelectedSTIG == nil {
-			return fmt.Errorf("failed to determine output path: no supported STIG found in profile")
+	if o.Explain {
+		trace, err := stig.RenderExplanation(explanation, stig.ExplainFormatText)
+		if err != nil {
+			return err
 		}
-		definition, err := stig.LookupSTIGDefinition(profile.SelectedSTIG.ID)
+		_, _ = fmt.Fprintf(w, "\n%s", trace)
+	}
+	if o.ExplainReport != "" {
+		report, err := stig.RenderExplanation(explanation, stig.ExplainFormatJSON)
 		if err != nil {
-			return fmt.Errorf("failed to determine output path: %w", err)
+			return err
+		}
+		if err := os.WriteFile(o.ExplainReport, []byte(report+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write evaluation trace: %w", err)
+		}
+		_, _ = fmt.Fprintf(w, "Wrote evaluation trace to %s\n", o.ExplainReport)
+	}
+	return nil
+}
+
+// stigIDs returns the STIGs of the profile to generate checklists for
+func (o *GenerateChecklistOptions) stigIDs(profile *stig.Profile) ([]string, error) {
+	switch {
+	case o.STIG != "":
+		if err := profile.UseSTIG(o.STIG); err != nil {
+			return nil, fmt.Errorf("failed to select STIG: %w", err)
+		}
+		return []string{o.STIG}, nil
+	case o.All:
+		var ids []string
+		for _, supported := range profile.SupportedSTIGs() {
+			ids = append(ids, supported.ID)
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("no supported STIG found in profile")
+		}
+		return ids, nil
+	case profile.SelectedSTIG == nil:
+		return nil, fmt.Errorf("no supported STIG found in profile")
+	default:
+		return []string{profile.SelectedSTIG.ID}, nil
+	}
+}
+
+// xccdfPathsByID maps the STIGs to the XCCDF files given for them with --xccdf. A path without a STIG id is used
+// when there is a single STIG.
+func xccdfPathsByID(values []string, ids []string) (map[string]string, error) {
+	paths := map[string]string{}
+	for _, value := range values {
+		id, path, found := strings.Cut(value, "=")
+		if !found || !slices.Contains(ids, id) {
+			if len(ids) != 1 {
+				return nil, fmt.Errorf("--xccdf %s must name the STIG it is for with <stig id>=<path> when generating checklists for %s", value, strings.Join(ids, ", "))
+			}
+			id, path = ids[0], value
+		}
+		if _, duplicate := paths[id]; duplicate {
+			return nil, fmt.Errorf("more than one XCCDF file given for STIG %s", id)
 		}
-		outputPath = stig.DefaultChecklistFilename(profile.AppName, definition)
+		paths[id] = path
 	}
+	return paths, nil
+}
 
-	xccdfPath, cleanup, err := stig.ResolveXCCDFPath(ctx, profile, o.XCCDFPath)
+func writeChecklist(outputPath string, format string, checklist *stig.Checklist) error {
+	data, err := stig.MarshalChecklist(checklist, format)
 	if err != nil {
-		return fmt.Errorf("failed to resolve XCCDF: %w", err)
+		return fmt.Errorf("failed to marshal checklist: %w", err)
+	}
+	if dir := filepath.Dir(outputPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// DiffOptions holds flags for the stig diff subcommand.
+type DiffOptions struct {
+	ProfilePath string
+	STIG        string
+	Format      string
+	RulesPaths  []string
+}
+
+func diffCmd() *cobra.Command {
+	options := &DiffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff OLD NEW",
+		Short: "List the rules added, removed and changed in status or text between two checklists or two XCCDF revisions",
+		Long: "List the rules added, removed and changed in status or text between two .cklb or .ckl checklists. " +
+			"XCCDF files (.xml) are evaluated against the profile first, so two revisions of a STIG published by DISA " +
+			"can be compared with the dispositions they lead to.",
+		Args: cobra.ExactArgs(2),
+		RunE: options.run,
+	}
+	cmd.Flags().StringVar(&options.ProfilePath, "profile", "stig-profile.yaml", "Path to stig-profile.yaml to evaluate XCCDF files against")
+	cmd.Flags().StringVar(&options.STIG, "stig", "", "ID of the STIG of the profile to evaluate XCCDF files for (default: the first supported STIG)")
+	cmd.Flags().StringVar(&options.Format, "format", stig.DiffFormatMarkdown, fmt.Sprintf("Output format of the differences (%s)", strings.Join(stig.DiffFormats, ", ")))
+	cmd.Flags().StringArrayVar(&options.RulesPaths, "rules", []string{}, "Path to a rules file evaluated before the default rules of its STIG when evaluating XCCDF files. Can be repeated.")
+	return cmd
+}
+
+func (o *DiffOptions) run(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(stig.DiffFormats, strings.ToLower(o.Format)) {
+		return fmt.Errorf("unsupported output format %q, expected one of: %s", o.Format, strings.Join(stig.DiffFormats, ", "))
 	}
-	defer cleanup()
+	cmd.SilenceUsage = true
+
+	var profile *stig.Profile
+	checklists := make([]*stig.Checklist, len(args))
+	for i, path := range args {
+		if !strings.EqualFold(filepath.Ext(path), ".xml") {
+			checklist, err := stig.LoadChecklist(path)
+			if err != nil {
+				return fmt.Errorf("failed to load checklist: %w", err)
+			}
+			checklists[i] = checklist
+			continue
+		}
 
-	log.Info("Parsing XCCDF", slog.String("path", xccdfPath))
-	s, err := stig.ParseXCCDF(xccdfPath, profile)
+		if profile == nil {
+			var err error
+			if profile, err = stig.LoadProfile(o.ProfilePath); err != nil {
+				return fmt.Errorf("failed to load profile: %w", err)
+			}
+			if err := loadRuleSets(profile, o.RulesPaths); err != nil {
+				return err
+			}
+			if o.STIG != "" {
+				if err := profile.UseSTIG(o.STIG); err != nil {
+					return fmt.Errorf("failed to select STIG: %w", err)
+				}
+			}
+		}
+		s, err := stig.ParseXCCDF(path, profile)
+		if err != nil {
+			return fmt.Errorf("failed to parse XCCDF: %w", err)
+		}
+		checklists[i] = stig.BuildChecklist(profile, s)
+	}
+
+	output, err := stig.RenderDiff(stig.DiffChecklists(checklists[0], checklists[1]), o.Format)
 	if err != nil {
-		return fmt.Errorf("failed to parse XCCDF: %w", err)
+		return err
+	}
+	_, _ = fmt.Fprintln(cmd.OutOrStdout(), output)
+	return nil
+}
+
+// loadRuleSets adds the rule sets of the rules files to the profile
+func loadRuleSets(profile *stig.Profile, paths []string) error {
+	for _, path := range paths {
+		ruleSet, err := stig.LoadRuleSet(path)
+		if err != nil {
+			return fmt.Errorf("failed to load rules: %w", err)
+		}
+		profile.RuleSets = append(profile.RuleSets, ruleSet)
+	}
+	return nil
+}
+
+// ValidateOptions holds flags for the stig validate subcommand.
+type ValidateOptions struct {
+	ProfilePath string
+	XCCDFPaths  []string
+	CacheDir    string
+	Offline     bool
+}
+
+func validateCmd() *cobra.Command {
+	options := &ValidateOptions{}
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check a STIG profile for unknown keys, invalid override statuses and overrides of rules the STIGs do not have",
+		Long: "Check a STIG profile strictly and list all problems: unknown keys such as misspelled characteristics, " +
+			"override statuses other than not_a_finding, not_applicable, not_reviewed and open, STIGs missing from the " +
+			"STIG library and overrides whose rule version is not a rule of the XCCDF of their STIG.",
+		Args: cobra.NoArgs,
+		RunE: options.run,
 	}
+	cmd.Flags().StringVar(&options.ProfilePath, "profile", "stig-profile.yaml", "Path to stig-profile.yaml")
+	cmd.Flags().StringArrayVar(&options.XCCDFPaths, "xccdf", []string{}, "Path to the XCCDF XML file to check the overrides against (default: the DISA XCCDF of the STIG). With several STIGs, use <stig id>=<path>. Can be repeated.")
+	cmd.Flags().StringVar(&options.CacheDir, "cache-dir", defaultSTIGCacheDir(), "Directory the downloaded DISA STIG archives are cached in")
+	cmd.Flags().BoolVar(&options.Offline, "offline", false, "Only use STIG archives from the cache, never download them")
+	return cmd
+}
 
-	checklist := stig.BuildChecklist(profile, s)
+func (o *ValidateOptions) run(cmd *cobra.Command, _ []string) error {
+	ctx := cmd.Context()
+	log := Logger(&ctx)
 
-	data, err := json.MarshalIndent(checklist, "", "  ")
+	// without a cache directory, archives are downloaded into a temporary directory
+	var cache *stig.XCCDFCache
+	if o.CacheDir != "" {
+		cache = &stig.XCCDFCache{Dir: o.CacheDir, Offline: o.Offline}
+	} else if o.Offline {
+		return fmt.Errorf("--offline requires --cache-dir")
+	}
+
+	profile, problems, err := stig.ValidateProfile(o.ProfilePath)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+	var ids []string
+	for _, supported := range profile.SupportedSTIGs() {
+		ids = append(ids, supported.ID)
+	}
+	xccdfPaths, err := xccdfPathsByID(o.XCCDFPaths, ids)
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
+		return err
 	}
+	cmd.SilenceUsage = true
 
-	if err := os.WriteFile(outputPath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write output: %w", err)
+	for _, id := range ids {
+		if err := profile.UseSTIG(id); err != nil {
+			return fmt.Errorf("failed to select STIG: %w", err)
+		}
+		if len(profile.Overrides) == 0 {
+			continue
+		}
+		xccdfPath, cleanup, err := stig.ResolveXCCDFPath(ctx, profile, xccdfPaths[id], cache)
+		if err != nil {
+			problems = append(problems, stig.ProfileProblem{Message: fmt.Sprintf("cannot check the overrides of %s: %v", id, err)})
+			continue
+		}
+		log.Debug("Checking overrides", slog.String("stig", id), slog.String("xccdf", xccdfPath))
+		s, err := stig.ParseXCCDF(xccdfPath, profile)
+		cleanup()
+		if err != nil {
+			problems = append(problems, stig.ProfileProblem{Message: fmt.Sprintf("cannot check the overrides of %s: %v", id, err)})
+			continue
+		}
+		problems = append(problems, stig.ValidateOverrides(profile, s)...)
 	}
+	slices.SortStableFunc(problems, func(a, b stig.ProfileProblem) int { return a.Line - b.Line })
 
-	// Print summary
-	counts := map[string]int{}
-	for _, r := range s.Rules {
-		counts[r.Status]++
+	w := cmd.OutOrStdout()
+	for _, problem := range problems {
+		_, _ = fmt.Fprintf(w, "%s: %s\n", o.ProfilePath, problem)
+	}
+	if len(problems) == 1 {
+		return fmt.Errorf("found 1 problem in %s", o.ProfilePath)
+	}
+	if len(problems) > 1 {
+		return fmt.Errorf("found %d problems in %s", len(problems), o.ProfilePath)
 	}
+	_, _ = fmt.Fprintf(w, "%s is valid\n", o.ProfilePath)
+	return nil
+}
+
+// FetchOptions holds flags for the stig fetch subcommand.
+type FetchOptions struct {
+	CacheDir string
+	Refresh  bool
+}
+
+func fetchCmd() *cobra.Command {
+	options := &FetchOptions{}
+	cmd := &cobra.Command{
+		Use:   "fetch [STIG ID...]",
+		Short: "Download DISA STIG archives into the cache for offline use",
+		Long: "Download the DISA zip archives of the given STIGs, or of every STIG of the STIG library, into the cache " +
+			"and verify their SHA256 checksums, so checklists can be generated with --offline.",
+		RunE: options.run,
+	}
+	cmd.Flags().StringVar(&options.CacheDir, "cache-dir", defaultSTIGCacheDir(), "Directory the downloaded DISA STIG archives are cached in")
+	cmd.Flags().BoolVar(&options.Refresh, "refresh", false, "Download the archives again even when they are cached")
+	return cmd
+}
+
+func (o *FetchOptions) run(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	log := Logger(&ctx)
+
+	var definitions []stig.STIGDefinition
+	if len(args) == 0 {
+		for _, definition := range stig.STIGDefinitions() {
+			if definition.ZipURL != "" {
+				definitions = append(definitions, definition)
+			}
+		}
+	}
+	for _, id := range args {
+		definition, err := stig.LookupSTIGDefinition(id)
+		if err != nil {
+			return err
+		}
+		definitions = append(definitions, definition)
+	}
+	if o.CacheDir == "" {
+		return fmt.Errorf("--cache-dir is required")
+	}
+	cmd.SilenceUsage = true
+
+	cache := &stig.XCCDFCache{Dir: o.CacheDir}
 	w := cmd.OutOrStdout()
-	_, _ = fmt.Fprintf(w, "Generated %s\n", outputPath)
-	_, _ = fmt.Fprintf(w, "Total rules: %d\n", len(s.Rules))
-	for _, status := range []string{"not_a_finding", "not_applicable", "not_reviewed", "open"} {
-		if c, ok := counts[status]; ok {
-			_, _ = fmt.Fprintf(w, "  %s: %d\n", status, c)
+	for _, definition := range definitions {
+		log.Info("Fetching STIG", slog.String("id", definition.ID), slog.String("url", definition.ZipURL))
+		archive, err := cache.Fetch(ctx, definition, o.Refresh)
+		if err != nil {
+			return fmt.Errorf("failed to fetch STIG %s: %w", definition.ID, err)
+		}
+		action := "Cached"
+		if archive.Downloaded {
+			action = "Downloaded"
 		}
+		_, _ = fmt.Fprintf(w, "%s %s to %s (sha256 %s)\n", action, definition.ID, archive.Path, archive.SHA256)
 	}
 	return nil
 }
 
+func listCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the STIGs of the STIG library checklists can be generated for",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			output, err := stig.RenderDefinitions(stig.STIGDefinitions())
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprint(cmd.OutOrStdout(), output)
+			return nil
+		},
+	}
+}
+
+// loadSTIGLibrary adds the STIG definitions of the --library directory to the built-in ones. The default
+// directory is optional.
+func loadSTIGLibrary(cmd *cobra.Command, dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) && !cmd.Flags().Changed("library") {
+		return nil
+	}
+	if err := stig.LoadLibrary(dir); err != nil {
+		return fmt.Errorf("failed to load STIG library: %w", err)
+	}
+	return nil
+}
+
+func defaultSTIGCacheDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "uds-pk", "stig")
+}
+
+func defaultSTIGLibraryDir() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "uds-pk", "stig-library")
+}
+
 func init() {
+	var libraryDir string
 	stigCmd := &cobra.Command{
 		Use:   "stig",
 		Short: "STIG checklist operations",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			initLogger(cmd, args)
+			return loadSTIGLibrary(cmd, libraryDir)
+		},
 	}
+	stigCmd.PersistentFlags().StringVar(&libraryDir, "library", defaultSTIGLibraryDir(), "Directory of STIG definition files adding to or replacing the built-in STIGs, e.g. for new DISA revisions")
 	stigCmd.AddCommand(generateChecklistCmd())
+	stigCmd.AddCommand(diffCmd())
+	stigCmd.AddCommand(listCmd())
+	stigCmd.AddCommand(fetchCmd())
+	stigCmd.AddCommand(validateCmd())
 	rootCmd.AddCommand(stigCmd)
 }
@@ -6,10 +6,10 @@ package compare
 import (
 	"encoding/json"
 	"fmt"
-	"net/url"
 	"os"
-	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/CycloneDX/cyclonedx-go"
 	"github.com/olekukonko/tablewriter"
@@ -17,29 +17,62 @@ import (
 	"github.com/olekukonko/tablewriter/tw"
 )
 
-func LoadScans(basePath string, newPath string) (cyclonedx.BOM, cyclonedx.BOM, error) {
+// LoadScans reads both scans and drops the vulnerabilities that cannot be compared. A warning is
+// returned for every skipped vulnerability.
+func LoadScans(basePath string, newPath string) (cyclonedx.BOM, cyclonedx.BOM, []string, error) {
 	baseScan, err := loadScanJson(basePath)
 	if err != nil {
-		return cyclonedx.BOM{}, cyclonedx.BOM{}, err
+		return cyclonedx.BOM{}, cyclonedx.BOM{}, nil, err
 	}
 
 	newScan, err := loadScanJson(newPath)
 	if err != nil {
-		return cyclonedx.BOM{}, cyclonedx.BOM{}, err
+		return cyclonedx.BOM{}, cyclonedx.BOM{}, nil, err
 	}
 
-	return baseScan, newScan, nil
+	var warnings []string
+	for _, warning := range RemoveMalformedVulnerabilities(&baseScan) {
+		warnings = append(warnings, fmt.Sprintf("%s: %s", basePath, warning))
+	}
+	for _, warning := range RemoveMalformedVulnerabilities(&newScan) {
+		warnings = append(warnings, fmt.Sprintf("%s: %s", newPath, warning))
+	}
+
+	return baseScan, newScan, warnings, nil
+}
+
+// RemoveMalformedVulnerabilities drops vulnerabilities without an ID or an affected package, as they
+// cannot be matched between scans, and returns a warning for each of them.
+func RemoveMalformedVulnerabilities(scan *cyclonedx.BOM) []string {
+	if scan.Vulnerabilities == nil {
+		scan.Vulnerabilities = &[]cyclonedx.Vulnerability{}
+		return nil
+	}
+	var warnings []string
+	kept := []cyclonedx.Vulnerability{}
+	for i, vuln := range *scan.Vulnerabilities {
+		switch {
+		case vuln.ID == "":
+			warnings = append(warnings, fmt.Sprintf("skipping vulnerability %d: it has no ID", i))
+		case affectedRef(vuln) == "":
+			warnings = append(warnings, fmt.Sprintf("skipping vulnerability %s: it does not reference an affected package", vuln.ID))
+		default:
+			kept = append(kept, vuln)
+		}
+	}
+	scan.Vulnerabilities = &kept
+	return warnings
 }
 
 func GenerateComparisonMap(baseScan cyclonedx.BOM, newScan cyclonedx.BOM) map[string]int {
 	// matchStatus : 0 = new, 1 = existing, 2 = fixed
 	vulnStatus := make(map[string]int)
 
-	for _, baseVuln := range *baseScan.Vulnerabilities {
+	for _, baseVuln := range vulnerabilities(baseScan) {
 		vulnStatus[getUniqueVulnId(baseVuln)] = 2
 	}
 
-	for _, newVuln := range *newScan.Vulnerabilities {
+	for _, newVuln := range vulnerabilities(newScan) {
 		vulnUID := getUniqueVulnId(newVuln)
 		if _, ok := vulnStatus[vulnUID]; ok {
 			vulnStatus[vulnUID] = 1
@@ -51,44 +84,82 @@ func GenerateComparisonMap(baseScan cyclonedx.BOM, newScan cyclonedx.BOM) map[st
 	return vulnStatus
 }
 
-func GenerateComparisonMarkdown(baseScan cyclonedx.BOM, newScan cyclonedx.BOM, vulnStatus map[string]int) (string, error) {
-	newCount := 0
-	existingCount := 0
-	fixedCount := 0
-
-	for _, status := range vulnStatus {
-		switch status {
-		case 0:
-			newCount++
-		case 1:
-			existingCount++
-		case 2:
-			fixedCount++
+func GenerateComparisonMarkdown(baseScan cyclonedx.BOM, newScan cyclonedx.BOM, vulnStatus map[string]int, suppressed []SuppressedVulnerability) (string, error) {
+	comparison, err := NewComparison(baseScan, newScan, vulnStatus, suppressed)
+	if err != nil {
+		return "", err
+	}
+	return renderComparisonMarkdown(comparison)
+}
+
+// MarkdownRenderer renders comparisons as markdown tables for pull request comments.
+type MarkdownRenderer struct{}
+
+func (MarkdownRenderer) Render(report Report) (string, error) {
+	var outputBuilder strings.Builder
+	if report.Consolidated && len(report.Comparisons) > 0 {
+		summary, err := renderSummaryMarkdown(report)
+		if err != nil {
+			return "", err
 		}
+		outputBuilder.WriteString(summary)
 	}
+	for _, comparison := range report.Comparisons {
+		markdown, err := renderComparisonMarkdown(comparison)
+		if err != nil {
+			return "", err
+		}
+		if outputBuilder.Len() > 0 {
+			outputBuilder.WriteString("\n\n")
+		}
+		outputBuilder.WriteString(markdown)
+	}
+	if outputBuilder.Len() > 0 {
+		footer, err := renderProvenanceMarkdown(report)
+		if err != nil {
+			return "", err
+		}
+		outputBuilder.WriteString(footer)
+	}
+	return outputBuilder.String(), nil
+}
 
+func renderComparisonMarkdown(comparison Comparison) (string, error) {
 	var outputBuilder strings.Builder
 
-	if newScan.Metadata.Component.Name == baseScan.Metadata.Component.Name {
+	if comparison.NoBaseline {
+		fmt.Fprintf(&outputBuilder, "### %s: No released scan found for image\n", comparison.NewImage.Name)
+		outputBuilder.WriteString("This is likely a new image\n\n\n")
+	}
+
+	if comparison.NewImage.Name == comparison.BaseImage.Name {
 		fmt.Fprintf(&outputBuilder,
 			"### %s `%s` -> `%s`\n\n",
-			baseScan.Metadata.Component.Name,
-			baseScan.Metadata.Component.Version,
-			newScan.Metadata.Component.Version,
+			comparison.BaseImage.Name,
+			comparison.BaseImage.Version,
+			comparison.NewImage.Version,
 		)
 	} else {
 		fmt.Fprintf(&outputBuilder,
 			"### `%s:%s` -> `%s:%s`\n\n",
-			baseScan.Metadata.Component.Name,
-			baseScan.Metadata.Component.Version,
-			newScan.Metadata.Component.Name,
-			newScan.Metadata.Component.Version,
+			comparison.BaseImage.Name,
+			comparison.BaseImage.Version,
+			comparison.NewImage.Name,
+			comparison.NewImage.Version,
 		)
 	}
 
-	fmt.Fprintf(&outputBuilder, "New vulnerabilities: %d\n", newCount)
-	fmt.Fprintf(&outputBuilder, "Fixed vulnerabilities: %d\n", fixedCount)
-	fmt.Fprintf(&outputBuilder, "Existing vulnerabilities: %d\n\n", existingCount)
+	if comparison.Arch != "" {
+		fmt.Fprintf(&outputBuilder, "Architecture: %s\n", comparison.Arch)
+	}
+	fmt.Fprintf(&outputBuilder, "New vulnerabilities: %d\n", len(comparison.New))
+	fmt.Fprintf(&outputBuilder, "Fixed vulnerabilities: %d\n", len(comparison.Fixed))
+	if len(comparison.Suppressed) > 0 {
+		fmt.Fprintf(&outputBuilder, "Existing vulnerabilities: %d\n", len(comparison.Existing))
+		fmt.Fprintf(&outputBuilder, "Suppressed vulnerabilities: %d\n\n", len(comparison.Suppressed))
+	} else {
+		fmt.Fprintf(&outputBuilder, "Existing vulnerabilities: %d\n\n", len(comparison.Existing))
+	}
 
 	newVulnTableString := &strings.Builder{}
 	fixedVulnTableString := &strings.Builder{}
@@ -96,22 +167,13 @@ func GenerateComparisonMarkdown(baseScan cyclonedx.BOM, newScan cyclonedx.BOM, v
 
 	newVulnTable, fixedVulnTable, existingVulnTable := setupTables(newVulnTableString, fixedVulnTableString, existingVulnTableString)
 
-	newVulnRows, fixedVulnRows, existingVulnRows, err := generateTableRows(*baseScan.Vulnerabilities, *newScan.Vulnerabilities, vulnStatus)
-	if err != nil {
-		return "", err
-	}
-
-	newVulnRows = sortRows(newVulnRows)
-	fixedVulnRows = sortRows(fixedVulnRows)
-	existingVulnRows = sortRows(existingVulnRows)
-
-	if err := newVulnTable.Bulk(newVulnRows); err != nil {
+	if err := newVulnTable.Bulk(tableRows(comparison.New)); err != nil {
 		return "", err
 	}
-	if err := fixedVulnTable.Bulk(fixedVulnRows); err != nil {
+	if err := fixedVulnTable.Bulk(tableRows(comparison.Fixed)); err != nil {
 		return "", err
 	}
-	if err := existingVulnTable.Bulk(existingVulnRows); err != nil {
+	if err := existingVulnTable.Bulk(tableRows(comparison.Existing)); err != nil {
 		return "", err
 	}
 
@@ -145,6 +207,26 @@ func GenerateComparisonMarkdown(baseScan cyclonedx.BOM, newScan cyclonedx.BOM, v
 	outputBuilder.WriteString(existingVulnTableString.String())
 	outputBuilder.WriteString("\n</details>\n")
 
+	if len(comparison.Suppressed) > 0 {
+		suppressedTable, err := generateSuppressedTable(comparison.Suppressed)
+		if err != nil {
+			return "", err
+		}
+		outputBuilder.WriteString("<details>\n")
+		outputBuilder.WriteString("<summary>Suppressed vulnerabilities</summary>\n\n")
+		outputBuilder.WriteString(suppressedTable)
+		outputBuilder.WriteString("\n</details>\n")
+	}
+
+	if comparison.Components != nil {
+		markdown, err := renderInventoryMarkdown(*comparison.Components)
+		if err != nil {
+			return "", err
+		}
+		outputBuilder.WriteString("\n#### Component changes\n\n")
+		outputBuilder.WriteString(markdown)
+	}
+
 	outputBuilder.WriteString("\n---\n")
 
 	return outputBuilder.String(), nil
@@ -161,89 +243,99 @@ func TrimDockerRegistryPrefixes(name string) string {
 	return name
 }
 
-func sortRows(rows [][]string) [][]string {
-	orderMap := map[string]int{
-		"critical": 0,
-		"high":     1,
-		"medium":   2,
-		"low":      3,
-		"none":     4,
-		"unknown":  5,
-	}
-
-	sortFunc := func(i, j int, rowSlice [][]string) bool {
-		s1 := strings.ToLower(rowSlice[i][1])
-		s2 := strings.ToLower(rowSlice[j][1])
-		rank1, ok1 := orderMap[s1]
-		if !ok1 {
-			rank1 = 100
-		}
-		rank2, ok2 := orderMap[s2]
-		if !ok2 {
-			rank2 = 100
-		}
-		return rank1 < rank2
-	}
-
-	sort.Slice(rows, func(i, j int) bool {
-		return sortFunc(i, j, rows)
-	})
+var severityOrder = map[string]int{
+	"critical": 0,
+	"high":     1,
+	"medium":   2,
+	"low":      3,
+	"none":     4,
+	"unknown":  5,
+}
 
-	return rows
+func severityRank(severity string) int {
+	rank, ok := severityOrder[strings.ToLower(severity)]
+	if !ok {
+		return 100
+	}
+	return rank
 }
 
-func setupTables(newVulnTableString *strings.Builder, fixedVulnTableString *strings.Builder, existingVulnTableString *strings.Builder) (newVulnTable *tablewriter.Table, fixedVulnTable *tablewriter.Table, existingVulnTable *tablewriter.Table) {
+func newMarkdownTable(tableString *strings.Builder) *tablewriter.Table {
 	renderConfig :=
 		tablewriter.WithConfig(tablewriter.Config{Header: tw.CellConfig{
 			Alignment:    tw.CellAlignment{Global: tw.AlignLeft},
 			ColMaxWidths: tw.CellWidth{Global: 10000},
 		}})
 
-	newVulnTable = tablewriter.NewTable(newVulnTableString, tablewriter.WithRenderer(renderer.NewMarkdown()), renderConfig)
-	fixedVulnTable = tablewriter.NewTable(fixedVulnTableString, tablewriter.WithRenderer(renderer.NewMarkdown()), renderConfig)
-	existingVulnTable = tablewriter.NewTable(existingVulnTableString, tablewriter.WithRenderer(renderer.NewMarkdown()), renderConfig)
+	return tablewriter.NewTable(tableString, tablewriter.WithRenderer(renderer.NewMarkdown()), renderConfig)
+}
+
+func setupTables(newVulnTableString *strings.Builder, fixedVulnTableString *strings.Builder, existingVulnTableString *strings.Builder) (newVulnTable *tablewriter.Table, fixedVulnTable *tablewriter.Table, existingVulnTable *tablewriter.Table) {
+	newVulnTable = newMarkdownTable(newVulnTableString)
+	fixedVulnTable = newMarkdownTable(fixedVulnTableString)
+	existingVulnTable = newMarkdownTable(existingVulnTableString)
 
 	tables := []*tablewriter.Table{newVulnTable, fixedVulnTable, existingVulnTable}
 
 	for _, table := range tables {
-		table.Header([]string{"ID", "Severity", "URL"})
+		table.Header(tableHeaders)
 	}
 
 	return newVulnTable, fixedVulnTable, existingVulnTable
 }
 
-func generateTableRows(baseVulns []cyclonedx.Vulnerability, newVulns []cyclonedx.Vulnerability, vulnStatus map[string]int) (newVulnRows [][]string, fixedVulnRows [][]string, existingVulnRows [][]string, err error) {
-	allVulns := []cyclonedx.Vulnerability{}
-	allVulns = append(allVulns, baseVulns...)
-	allVulns = append(allVulns, newVulns...)
+var tableHeaders = []string{"ID", "Severity", "Score", "Package", "Installed", "Fixed In", "URL"}
+
+func tableRows(entries []VulnerabilityEntry) [][]string {
+	rows := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, []string{
+			entry.ID,
+			entry.Severity,
+			formatScore(entry.Score),
+			entry.Package,
+			entry.InstalledVersion,
+			entry.FixedVersion,
+			entry.URL,
+		})
+	}
+	return rows
+}
 
-	for vulnUID, status := range vulnStatus {
-		vuln, err := getVulnByUID(vulnUID, allVulns)
-		if err != nil {
-			return newVulnRows, fixedVulnRows, existingVulnRows, err
-		}
-		var vulnUrl string
-		parsedUrl, err := url.Parse(vuln.Source.URL)
-		if err != nil || parsedUrl.Scheme == "" {
-			vulnUrl = (*vuln.Advisories)[0].URL
-		} else {
-			vulnUrl = vuln.Source.URL
-		}
-		row := []string{
-			vuln.ID,
-			string((*vuln.Ratings)[0].Severity),
-			vulnUrl,
-		}
-		switch status {
-		case 0:
-			newVulnRows = append(newVulnRows, row)
-		case 1:
-			existingVulnRows = append(existingVulnRows, row)
-		case 2:
-			fixedVulnRows = append(fixedVulnRows, row)
+func formatScore(score float64) string {
+	if score == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(score, 'f', 1, 64)
+}
+
+func generateSuppressedTable(suppressed []SuppressedEntry) (string, error) {
+	tableString := &strings.Builder{}
+	table := newMarkdownTable(tableString)
+	table.Header([]string{"ID", "Severity", "Package", "Justification", "Expires"})
+
+	var rows [][]string
+	for _, s := range suppressed {
+		expires := ""
+		if s.Expires != nil {
+			expires = s.Expires.Format(time.DateOnly)
 		}
+		rows = append(rows, []string{
+			s.ID,
+			s.Severity,
+			packageWithoutVersion(s.PURL),
+			s.Justification,
+			expires,
+		})
+	}
+
+	if err := table.Bulk(rows); err != nil {
+		return "", err
+	}
+	if err := table.Render(); err != nil {
+		return "", err
 	}
-	return newVulnRows, fixedVulnRows, existingVulnRows, nil
+	return tableString.String(), nil
 }
 
 func loadScanJson(filename string) (cyclonedx.BOM, error) {
@@ -251,6 +343,10 @@ func loadScanJson(filename string) (cyclonedx.BOM, error) {
 	if err != nil {
 		return cyclonedx.BOM{}, err
 	}
+	return parseScanJson(data)
+}
+
+func parseScanJson(data []byte) (cyclonedx.BOM, error) {
 	var scan cyclonedx.BOM
 	if err := json.Unmarshal(data, &scan); err != nil {
 		return cyclonedx.BOM{}, err
@@ -258,11 +354,32 @@ func loadScanJson(filename string) (cyclonedx.BOM, error) {
 	if scan.Vulnerabilities == nil {
 		scan.Vulnerabilities = &[]cyclonedx.Vulnerability{}
 	}
+	if scan.Metadata == nil {
+		scan.Metadata = &cyclonedx.Metadata{}
+	}
+	if scan.Metadata.Component == nil {
+		scan.Metadata.Component = &cyclonedx.Component{}
+	}
 	return scan, nil
 }
 
+func vulnerabilities(scan cyclonedx.BOM) []cyclonedx.Vulnerability {
+	if scan.Vulnerabilities == nil {
+		return nil
+	}
+	return *scan.Vulnerabilities
+}
+
+// affectedRef returns the reference to the first package affected by the vulnerability.
+func affectedRef(vuln cyclonedx.Vulnerability) string {
+	if vuln.Affects == nil || len(*vuln.Affects) == 0 {
+		return ""
+	}
+	return (*vuln.Affects)[0].Ref
+}
+
 func getUniqueVulnId(vuln cyclonedx.Vulnerability) string {
-	pkgPath := strings.Split((*vuln.Affects)[0].Ref, "@")[0]
+	pkgPath := strings.Split(affectedRef(vuln), "@")[0]
 	return fmt.Sprintf("%s|%s", vuln.ID, pkgPath)
 }
 
@@ -274,3 +391,21 @@ func getVulnByUID(uid string, vulns []cyclonedx.Vulnerability) (cyclonedx.Vulner
 	}
 	return cyclonedx.Vulnerability{}, fmt.Errorf("vulnerability not found: %s", uid)
 }
+
+// vulnSeverity returns the highest severity among the ratings of the vulnerability, as the sources
+// grype reports can disagree. Vulnerabilities without a rated severity are "unknown".
+func vulnSeverity(vuln cyclonedx.Vulnerability) string {
+	severity := ""
+	if vuln.Ratings != nil {
+		for _, rating := range *vuln.Ratings {
+			ratingSeverity := strings.ToLower(strings.TrimSpace(string(rating.Severity)))
+			if ratingSeverity != "" && (severity == "" || severityRank(ratingSeverity) < severityRank(severity)) {
+				severity = ratingSeverity
+			}
+		}
+	}
+	if severity == "" {
+		return "unknown"
+	}
+	return severity
+}
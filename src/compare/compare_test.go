@@ -4,6 +4,8 @@
 package compare
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -99,8 +101,8 @@ func TestSetupTables(t *testing.T) {
 		t.Fatal("Expected all returned tables to be non-nil")
 	}
 
-	dummyRow := []string{"VULN-123", "high", "http://example.com"}
-	expectedHeaders := []string{"ID", "Severity", "URL"}
+	dummyRow := []string{"VULN-123", "high", "7.5", "libexample", "1.0.0", "1.0.1", "http://example.com"}
+	expectedHeaders := []string{"ID", "Severity", "Score", "Package", "Installed", "Fixed In", "URL"}
 
 	if err := newTable.Append(dummyRow); err != nil {
 		t.Errorf("Failed to append to newTable: %v", err)
@@ -145,64 +147,46 @@ func TestSetupTables(t *testing.T) {
 	}
 }
 
-func TestGenerateTableRows(t *testing.T) {
-	// Use the common vulnerabilities.
-	vulnStatus := map[string]int{
-		getUniqueVulnId(commonFixedVuln): 2, // fixed
-		getUniqueVulnId(commonExistVuln): 1, // existing
-		getUniqueVulnId(commonNewVuln):   0, // new
-	}
-
-	baseVulns := []cyclonedx.Vulnerability{commonFixedVuln, commonExistVuln}
-	newVulns := []cyclonedx.Vulnerability{commonExistVuln, commonNewVuln}
+func TestNewComparison(t *testing.T) {
+	vulnStatus := GenerateComparisonMap(commonBaseScan, commonNewBaseScan)
 
-	newRows, fixedRows, existRows, err := generateTableRows(baseVulns, newVulns, vulnStatus)
+	comparison, err := NewComparison(commonBaseScan, commonNewBaseScan, vulnStatus, nil)
 	if err != nil {
 		t.Fatalf("Expected no error but got: %v", err)
 	}
 
-	if len(newRows) != 1 {
-		t.Errorf("Expected 1 new vulnerability row, got %d", len(newRows))
-	} else {
-		row := newRows[0]
-		if row[0] != commonNewVuln.ID {
-			t.Errorf("Expected new row ID %q, got %q", commonNewVuln.ID, row[0])
-		}
-		if row[1] != "low" {
-			t.Errorf("Expected new row severity 'low', got %q", row[1])
-		}
-		if row[2] != commonNewVuln.Source.URL {
-			t.Errorf("Expected new row Source URL %q, got %q", commonNewVuln.Source.URL, row[2])
-		}
+	if comparison.BaseImage.Version != "1.0" || comparison.NewImage.Version != "2.0" {
+		t.Errorf("Unexpected images %+v -> %+v", comparison.BaseImage, comparison.NewImage)
 	}
 
-	if len(fixedRows) != 1 {
-		t.Errorf("Expected 1 fixed vulnerability row, got %d", len(fixedRows))
-	} else {
-		row := fixedRows[0]
-		if row[0] != commonFixedVuln.ID {
-			t.Errorf("Expected fixed row ID %q, got %q", commonFixedVuln.ID, row[0])
+	tests := []struct {
+		name     string
+		entries  []VulnerabilityEntry
+		vuln     cyclonedx.Vulnerability
+		severity string
+		pkg      string
+	}{
+		{"new", comparison.New, commonNewVuln, "low", "pkgNew"},
+		{"fixed", comparison.Fixed, commonFixedVuln, "medium", "pkgFix"},
+		{"existing", comparison.Existing, commonExistVuln, "high", "pkgExist"},
+	}
+	for _, tt := range tests {
+		if len(tt.entries) != 1 {
+			t.Errorf("Expected 1 %s vulnerability, got %d", tt.name, len(tt.entries))
+			continue
 		}
-		if row[1] != "medium" {
-			t.Errorf("Expected fixed row severity 'medium', got %q", row[1])
+		entry := tt.entries[0]
+		if entry.ID != tt.vuln.ID {
+			t.Errorf("Expected %s ID %q, got %q", tt.name, tt.vuln.ID, entry.ID)
 		}
-		if row[2] != commonFixedVuln.Source.URL {
-			t.Errorf("Expected fixed row Source URL %q, got %q", commonFixedVuln.Source.URL, row[2])
+		if entry.Severity != tt.severity {
+			t.Errorf("Expected %s severity %q, got %q", tt.name, tt.severity, entry.Severity)
 		}
-	}
-
-	if len(existRows) != 1 {
-		t.Errorf("Expected 1 existing vulnerability row, got %d", len(existRows))
-	} else {
-		row := existRows[0]
-		if row[0] != commonExistVuln.ID {
-			t.Errorf("Expected existing row ID %q, got %q", commonExistVuln.ID, row[0])
+		if entry.URL != tt.vuln.Source.URL {
+			t.Errorf("Expected %s Source URL %q, got %q", tt.name, tt.vuln.Source.URL, entry.URL)
 		}
-		if row[1] != "high" {
-			t.Errorf("Expected existing row severity 'high', got %q", row[1])
-		}
-		if row[2] != commonExistVuln.Source.URL {
-			t.Errorf("Expected existing row Source URL %q, got %q", commonExistVuln.Source.URL, row[2])
+		if entry.Package != tt.pkg || entry.InstalledVersion != "v1" {
+			t.Errorf("Expected %s package %s v1, got %s %s", tt.name, tt.pkg, entry.Package, entry.InstalledVersion)
 		}
 	}
 }
@@ -302,41 +286,110 @@ func TestGetVulnByUID_NotFound(t *testing.T) {
 	}
 }
 
-func TestSortRows(t *testing.T) {
-	rows := [][]string{
-		{"ID1", "medium", "http://example.com", "http://advise.com"},
-		{"ID2", "critical", "http://example.com", "http://advise.com"},
-		{"ID3", "high", "http://example.com", "http://advise.com"},
-		{"ID4", "unknown", "http://example.com", "http://advise.com"},
-		{"ID5", "low", "http://example.com", "http://advise.com"},
-		{"ID6", "none", "http://example.com", "http://advise.com"},
-		{"ID7", "notdefined", "http://example.com", "http://advise.com"},
+func TestSortEntries(t *testing.T) {
+	entries := []VulnerabilityEntry{
+		{ID: "ID1", Severity: "medium"},
+		{ID: "ID2", Severity: "critical"},
+		{ID: "ID3", Severity: "high"},
+		{ID: "ID4", Severity: "unknown"},
+		{ID: "ID5", Severity: "low"},
+		{ID: "ID6", Severity: "none"},
+		{ID: "ID7", Severity: "notdefined"},
 	}
 
 	expectedOrder := []string{"ID2", "ID3", "ID1", "ID5", "ID6", "ID4", "ID7"}
-	sortedRows := sortRows(rows)
-	if len(sortedRows) != len(expectedOrder) {
-		t.Fatalf("Expected %d rows after sorting, got %d", len(expectedOrder), len(sortedRows))
+	sortEntries(entries)
+	for i, entry := range entries {
+		if entry.ID != expectedOrder[i] {
+			t.Errorf("At index %d: expected entry with ID %q, got %q", i, expectedOrder[i], entry.ID)
+		}
+	}
+}
+
+func TestSortEntries_ScoreWithinSeverity(t *testing.T) {
+	entries := []VulnerabilityEntry{
+		{ID: "ID1", Severity: "high", Score: 7.1},
+		{ID: "ID2", Severity: "medium", Score: 6.5},
+		{ID: "ID3", Severity: "high", Score: 8.8},
+		{ID: "ID4", Severity: "high"},
+		{ID: "ID5", Severity: "critical", Score: 9.1},
 	}
-	for i, row := range sortedRows {
-		if row[0] != expectedOrder[i] {
-			t.Errorf("At index %d: expected row with ID %q, got %q", i, expectedOrder[i], row[0])
+
+	expectedOrder := []string{"ID5", "ID3", "ID1", "ID4", "ID2"}
+	sortEntries(entries)
+	for i, entry := range entries {
+		if entry.ID != expectedOrder[i] {
+			t.Errorf("At index %d: expected entry with ID %q, got %q", i, expectedOrder[i], entry.ID)
 		}
 	}
 }
 
-func TestSortRows_Empty(t *testing.T) {
-	var rows [][]string
-	sortedRows := sortRows(rows)
-	if len(sortedRows) != 0 {
-		t.Errorf("Expected empty slice after sorting, got %d elements", len(sortedRows))
+func TestNewComparison_ResolvesComponents(t *testing.T) {
+	lowScore, highScore := 5.3, 7.5
+	ref := "pkg:apk/alpine/libssl3@3.0.15-r0?arch=x86_64&package-id=f3f23da887ec4ed8"
+	vuln := cyclonedx.Vulnerability{
+		ID:      "CVE-2024-9143",
+		Affects: &[]cyclonedx.Affects{{Ref: ref}},
+		Ratings: &[]cyclonedx.VulnerabilityRating{
+			{Severity: "medium", Score: &lowScore},
+			{Severity: "high", Score: &highScore},
+		},
+		Properties: &[]cyclonedx.Property{{Name: "grype:fixed-versions", Value: "3.0.15-r1, 3.1.7-r0"}},
+	}
+	newScan := cyclonedx.BOM{
+		Metadata: &cyclonedx.Metadata{Component: &cyclonedx.Component{Name: "alpine", Version: "3.17"}},
+		Components: &[]cyclonedx.Component{{
+			BOMRef:     ref,
+			Name:       "libssl3",
+			Version:    "3.0.15-r0",
+			PackageURL: "pkg:apk/alpine/libssl3@3.0.15-r0?arch=x86_64",
+		}},
+		Vulnerabilities: &[]cyclonedx.Vulnerability{vuln},
+	}
+	baseScan := cyclonedx.BOM{
+		Metadata:        &cyclonedx.Metadata{Component: &cyclonedx.Component{Name: "alpine", Version: "3.16"}},
+		Vulnerabilities: &[]cyclonedx.Vulnerability{},
+	}
+
+	comparison, err := NewComparison(baseScan, newScan, GenerateComparisonMap(baseScan, newScan), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(comparison.New) != 1 {
+		t.Fatalf("Expected 1 new vulnerability, got %d", len(comparison.New))
+	}
+	entry := comparison.New[0]
+	if entry.Package != "libssl3" || entry.InstalledVersion != "3.0.15-r0" || entry.PURL != "pkg:apk/alpine/libssl3@3.0.15-r0?arch=x86_64" {
+		t.Errorf("Expected package details from the component, got %+v", entry)
+	}
+	if entry.FixedVersion != "3.0.15-r1" {
+		t.Errorf("Expected fixed version 3.0.15-r1, got %q", entry.FixedVersion)
+	}
+	if entry.Score != 7.5 {
+		t.Errorf("Expected the highest score 7.5, got %v", entry.Score)
+	}
+
+	markdown, err := MarkdownRenderer{}.Render(Report{Comparisons: []Comparison{comparison}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(markdown, "| CVE-2024-9143 | high     | 7.5   | libssl3 | 3.0.15-r0 | 3.0.15-r1 |") {
+		t.Errorf("Expected a row with package details, got:\n%s", markdown)
+	}
+}
+
+func TestSortEntries_Empty(t *testing.T) {
+	var entries []VulnerabilityEntry
+	sortEntries(entries)
+	if len(entries) != 0 {
+		t.Errorf("Expected empty slice after sorting, got %d elements", len(entries))
 	}
 }
 
 func TestGenerateComparisonMarkdown_Success(t *testing.T) {
 	// Use the common BOMs.
 	vulnStatus := GenerateComparisonMap(commonBaseScan, commonNewBaseScan)
-	markdown, err := GenerateComparisonMarkdown(commonBaseScan, commonNewBaseScan, vulnStatus)
+	markdown, err := GenerateComparisonMarkdown(commonBaseScan, commonNewBaseScan, vulnStatus, nil)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -384,7 +437,7 @@ func TestGenerateComparisonMarkdown_EmptyVulnerabilities(t *testing.T) {
 		Vulnerabilities: &[]cyclonedx.Vulnerability{},
 	}
 	vulnStatus := GenerateComparisonMap(emptyBase, emptyNew)
-	markdown, err := GenerateComparisonMarkdown(emptyBase, emptyNew, vulnStatus)
+	markdown, err := GenerateComparisonMarkdown(emptyBase, emptyNew, vulnStatus, nil)
 	if err != nil {
 		t.Fatalf("Expected no error, got: %v", err)
 	}
@@ -398,3 +451,94 @@ func TestGenerateComparisonMarkdown_EmptyVulnerabilities(t *testing.T) {
 		t.Errorf("Expected markdown to show 0 existing vulnerabilities")
 	}
 }
+
+func TestRemoveMalformedVulnerabilities(t *testing.T) {
+	scan := cyclonedx.BOM{Vulnerabilities: &[]cyclonedx.Vulnerability{
+		commonVuln1,
+		{Affects: &[]cyclonedx.Affects{{Ref: "pkgA@v1.0.0"}}},
+		{ID: "VULN-NO-AFFECTS"},
+		{ID: "VULN-EMPTY-REF", Affects: &[]cyclonedx.Affects{{}}},
+		{ID: "VULN-NO-RATINGS", Affects: &[]cyclonedx.Affects{{Ref: "pkgC@v1"}}},
+	}}
+
+	warnings := RemoveMalformedVulnerabilities(&scan)
+
+	if len(warnings) != 3 {
+		t.Fatalf("Expected 3 warnings, got %v", warnings)
+	}
+	if !strings.Contains(warnings[0], "vulnerability 1: it has no ID") || !strings.Contains(warnings[1], "VULN-NO-AFFECTS") || !strings.Contains(warnings[2], "VULN-EMPTY-REF") {
+		t.Errorf("Unexpected warnings %v", warnings)
+	}
+	if len(*scan.Vulnerabilities) != 2 {
+		t.Errorf("Expected 2 remaining vulnerabilities, got %d", len(*scan.Vulnerabilities))
+	}
+}
+
+func TestLoadScans_SkipsMalformedVulnerabilities(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.json")
+	newPath := filepath.Join(dir, "new.json")
+	if err := os.WriteFile(basePath, []byte(`{"bomFormat": "CycloneDX"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newPath, []byte(`{"vulnerabilities": [{"id": "CVE-1"}, {"id": "CVE-2", "affects": [{"ref": "pkg:apk/alpine/zlib@1.2.13-r0"}]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	baseScan, newScan, warnings, err := LoadScans(basePath, newPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], newPath+": skipping vulnerability CVE-1") {
+		t.Errorf("Unexpected warnings %v", warnings)
+	}
+
+	vulnStatus := GenerateComparisonMap(baseScan, newScan)
+	markdown, err := GenerateComparisonMarkdown(baseScan, newScan, vulnStatus, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(markdown, "New vulnerabilities: 1") || !strings.Contains(markdown, "| CVE-2 | unknown  |") {
+		t.Errorf("Expected the vulnerability without ratings to be listed as unknown, got:\n%s", markdown)
+	}
+}
+
+func TestGetUniqueVulnId_NoAffects(t *testing.T) {
+	if uid := getUniqueVulnId(cyclonedx.Vulnerability{ID: "VULN-1"}); uid != "VULN-1|" {
+		t.Errorf("Expected %q, got %q", "VULN-1|", uid)
+	}
+}
+
+func TestVulnSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		ratings  *[]cyclonedx.VulnerabilityRating
+		expected string
+	}{
+		{"no ratings", nil, "unknown"},
+		{"empty ratings", &[]cyclonedx.VulnerabilityRating{}, "unknown"},
+		{"empty severity", &[]cyclonedx.VulnerabilityRating{{Severity: ""}}, "unknown"},
+		{"single rating", &[]cyclonedx.VulnerabilityRating{{Severity: "High"}}, "high"},
+		{"highest rating wins", &[]cyclonedx.VulnerabilityRating{{Severity: "medium"}, {Severity: "critical"}, {Severity: "low"}}, "critical"},
+		{"known severity over unknown", &[]cyclonedx.VulnerabilityRating{{Severity: "unknown"}, {Severity: "low"}}, "low"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if severity := vulnSeverity(cyclonedx.Vulnerability{Ratings: tt.ratings}); severity != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, severity)
+			}
+		})
+	}
+}
+
+func TestNewVulnerabilityEntry_MissingFields(t *testing.T) {
+	vuln := cyclonedx.Vulnerability{
+		ID:      "VULN-1",
+		Affects: &[]cyclonedx.Affects{{Ref: "pkgA@v1.0.0"}},
+		Source:  &cyclonedx.Source{URL: "nvd"},
+	}
+	entry := newVulnerabilityEntry(vuln, nil)
+	if entry.URL != "" || entry.Severity != "unknown" || entry.Score != 0 {
+		t.Errorf("Unexpected entry %+v", entry)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"os"
+	"testing"
+)
+
+var fuzzSeeds = []string{
+	`{}`,
+	`{"metadata": {"component": {"name": "alpine", "version": "3.17"}}}`,
+	`{"vulnerabilities": [{"id": "CVE-1"}]}`,
+	`{"vulnerabilities": [{"id": "CVE-1", "affects": []}]}`,
+	`{"vulnerabilities": [{"id": "CVE-1", "affects": [{"ref": "pkg:apk/alpine/zlib@1.2.13-r0"}]}]}`,
+	`{"vulnerabilities": [{"id": "CVE-1", "affects": [{"ref": "pkg:apk/alpine/zlib@1.2.13-r0"}], "ratings": [], "advisories": []}]}`,
+	`{"vulnerabilities": [{"id": "CVE-1", "affects": [{"ref": "zlib"}], "ratings": [{"severity": "high", "score": 7.5}, {"severity": "critical"}], "source": {"url": "nvd"}}]}`,
+	`{"components": [{"bom-ref": "a", "name": "zlib", "components": [{"bom-ref": "b"}]}], "vulnerabilities": [{"id": "CVE-1", "affects": [{"ref": "b", "versions": [{"version": "1", "status": "unaffected"}]}], "recommendation": "Upgrade to"}]}`,
+	`{"vulnerabilities": null, "metadata": null}`,
+}
+
+func addFuzzSeeds(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+	for _, path := range []string{"../test/scans/alpine_3.16.json", "../test/scans/busybox.json"} {
+		if data, err := os.ReadFile(path); err == nil {
+			f.Add(data)
+		}
+	}
+}
+
+func FuzzCompareScan(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		scan, err := parseScanJson(data)
+		if err != nil {
+			return
+		}
+		RemoveMalformedVulnerabilities(&scan)
+		baseScan, err := parseScanJson([]byte(fuzzSeeds[6]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		vulnStatus := GenerateComparisonMap(baseScan, scan)
+		comparison, err := NewComparison(baseScan, scan, vulnStatus, nil)
+		if err != nil {
+			t.Fatalf("Expected no error comparing valid scans, got: %v", err)
+		}
+		CountNewBySeverity(scan, vulnStatus)
+		for _, format := range Formats {
+			renderer, err := NewRenderer(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := renderer.Render(Report{Comparisons: []Comparison{comparison}, Consolidated: true}); err != nil {
+				t.Fatalf("Expected no error rendering %s, got: %v", format, err)
+			}
+		}
+	})
+}
+
+func FuzzRemoveMalformedVulnerabilities(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		scan, err := parseScanJson(data)
+		if err != nil {
+			return
+		}
+		RemoveMalformedVulnerabilities(&scan)
+		for _, vuln := range *scan.Vulnerabilities {
+			if vuln.ID == "" || affectedRef(vuln) == "" {
+				t.Fatalf("Expected malformed vulnerability to be removed, got %+v", vuln)
+			}
+		}
+	})
+}
@@ -0,0 +1,203 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	goyaml "github.com/goccy/go-yaml"
+)
+
+const dockerHub = "docker.io"
+
+// dockerHubAliases are the host names under which Docker Hub images can be referenced
+var dockerHubAliases = map[string]bool{
+	"docker.io":               true,
+	"index.docker.io":         true,
+	"registry-1.docker.io":    true,
+	"registry.hub.docker.com": true,
+}
+
+// MirrorRule declares registries or repository prefixes that serve the same images as a canonical prefix,
+// e.g. registry1.dso.mil/ironbank/opensource as a mirror of docker.io.
+type MirrorRule struct {
+	Canonical string   `yaml:"canonical"`
+	Mirrors   []string `yaml:"mirrors"`
+}
+
+// RegistryMirrors holds the mirror rules used to decide whether two image references are the same repository.
+type RegistryMirrors struct {
+	Rules []MirrorRule `yaml:"mirrors"`
+}
+
+// LoadRegistryMirrors reads registry mirror rules in YAML format.
+func LoadRegistryMirrors(path string) (RegistryMirrors, error) {
+	var mirrors RegistryMirrors
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return mirrors, err
+	}
+	if err := goyaml.Unmarshal(data, &mirrors); err != nil {
+		return mirrors, fmt.Errorf("failed to parse registry mirrors file %s: %w", path, err)
+	}
+	return mirrors, mirrors.Validate()
+}
+
+// Validate checks that every rule has a canonical prefix and at least one mirror.
+func (m RegistryMirrors) Validate() error {
+	for i, rule := range m.Rules {
+		if normalizePrefix(rule.Canonical) == "" {
+			return fmt.Errorf("registry mirror rule %d has no canonical prefix", i+1)
+		}
+		if len(rule.Mirrors) == 0 {
+			return fmt.Errorf("registry mirror rule for %s has no mirrors", rule.Canonical)
+		}
+		for _, mirror := range rule.Mirrors {
+			if normalizePrefix(mirror) == "" {
+				return fmt.Errorf("registry mirror rule for %s has an empty mirror", rule.Canonical)
+			}
+		}
+	}
+	return nil
+}
+
+// Repository returns the identity of the repository an image reference points to: the fully qualified
+// repository without tag or digest, with Docker Hub aliases and library prefixes normalized and mirrors
+// rewritten to their canonical prefix. "alpine:3.17", "docker.io/alpine" and
+// "index.docker.io/library/alpine@sha256:..." all have the identity docker.io/library/alpine.
+func (m RegistryMirrors) Repository(reference string) string {
+	repository := normalizeRepository(reference)
+	if repository == "" {
+		return ""
+	}
+
+	// the longest matching mirror wins so that specific rules can override broader ones
+	bestMirror, bestCanonical := "", ""
+	for _, rule := range m.Rules {
+		for _, mirror := range rule.Mirrors {
+			mirror = normalizePrefix(mirror)
+			if hasPathPrefix(repository, mirror) && len(mirror) > len(bestMirror) {
+				bestMirror, bestCanonical = mirror, normalizePrefix(rule.Canonical)
+			}
+		}
+	}
+	if bestMirror != "" {
+		repository = normalizeRepository(bestCanonical + strings.TrimPrefix(repository, bestMirror))
+	}
+	return repository
+}
+
+// SameRepository reports whether two image references point to the same repository.
+func (m RegistryMirrors) SameRepository(a string, b string) bool {
+	return m.Repository(a) == m.Repository(b)
+}
+
+// ScanReference returns the reference of the scanned image as recorded in the metadata of a scan file.
+func ScanReference(scanPath string) (string, error) {
+	scan, err := loadScanJson(scanPath)
+	if err != nil {
+		return "", err
+	}
+	return scan.Metadata.Component.Name, nil
+}
+
+// RepositoryIndex finds the scan of an image among a set of scans by the repository of the scanned image.
+type RepositoryIndex struct {
+	mirrors      RegistryMirrors
+	byRepository map[string]string
+	byName       map[string][]string
+}
+
+// NewRepositoryIndex indexes the given values by the repository of their image reference.
+func NewRepositoryIndex(mirrors RegistryMirrors, references map[string]string) RepositoryIndex {
+	index := RepositoryIndex{mirrors: mirrors, byRepository: map[string]string{}, byName: map[string][]string{}}
+	keys := make([]string, 0, len(references))
+	for reference := range references {
+		keys = append(keys, reference)
+	}
+	sort.Strings(keys)
+	for _, reference := range keys {
+		repository := mirrors.Repository(reference)
+		if repository == "" {
+			continue
+		}
+		if _, ok := index.byRepository[repository]; ok {
+			continue
+		}
+		index.byRepository[repository] = references[reference]
+		name := path.Base(repository)
+		index.byName[name] = append(index.byName[name], references[reference])
+	}
+	return index
+}
+
+// Find returns the value for the repository of the reference. A bare image name, as used by image name
+// overrides, matches when exactly one indexed repository has that name.
+func (index RepositoryIndex) Find(reference string) (string, bool) {
+	if value, ok := index.byRepository[index.mirrors.Repository(reference)]; ok {
+		return value, true
+	}
+	if strings.Contains(reference, "/") {
+		return "", false
+	}
+	name, _, _ := strings.Cut(strings.TrimPrefix(reference, "registry:"), ":")
+	if values := index.byName[strings.ToLower(name)]; len(values) == 1 {
+		return values[0], true
+	}
+	return "", false
+}
+
+// normalizeRepository strips the scheme, tag and digest of an image reference and qualifies it with its registry.
+func normalizeRepository(reference string) string {
+	reference = strings.TrimSpace(reference)
+	for _, scheme := range []string{"registry:", "docker:", "podman:", "oci-registry:"} {
+		reference = strings.TrimPrefix(reference, scheme)
+	}
+	reference, _, _ = strings.Cut(reference, "@")
+	// a colon after the last slash separates the tag, one before it belongs to the registry port
+	if idx := strings.LastIndex(reference, ":"); idx > strings.LastIndex(reference, "/") {
+		reference = reference[:idx]
+	}
+	reference = strings.Trim(strings.ToLower(reference), "/")
+	if reference == "" {
+		return ""
+	}
+
+	registry, repository, found := strings.Cut(reference, "/")
+	if !found || !isRegistryHost(registry) {
+		registry, repository = dockerHub, reference
+	}
+	if dockerHubAliases[registry] {
+		registry = dockerHub
+		if !strings.Contains(repository, "/") {
+			repository = "library/" + repository
+		}
+	}
+	return registry + "/" + repository
+}
+
+// normalizePrefix normalizes the registry of a mirror rule prefix without qualifying it as a repository.
+func normalizePrefix(prefix string) string {
+	prefix = strings.Trim(strings.ToLower(strings.TrimSpace(prefix)), "/")
+	registry, rest, found := strings.Cut(prefix, "/")
+	if !dockerHubAliases[registry] {
+		return prefix
+	}
+	if !found {
+		return dockerHub
+	}
+	return dockerHub + "/" + rest
+}
+
+func isRegistryHost(segment string) bool {
+	return segment == "localhost" || strings.ContainsAny(segment, ".:")
+}
+
+func hasPathPrefix(repository string, prefix string) bool {
+	return repository == prefix || strings.HasPrefix(repository, prefix+"/")
+}
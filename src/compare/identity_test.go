@@ -0,0 +1,110 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepository(t *testing.T) {
+	tests := []struct {
+		reference string
+		expected  string
+	}{
+		{"alpine", "docker.io/library/alpine"},
+		{"alpine:3.17", "docker.io/library/alpine"},
+		{"docker.io/alpine", "docker.io/library/alpine"},
+		{"index.docker.io/library/alpine@sha256:abcd", "docker.io/library/alpine"},
+		{"registry.hub.docker.com/bitnami/elasticsearch:8.16.0", "docker.io/bitnami/elasticsearch"},
+		{"registry:ghcr.io/Foo/App:1.0", "ghcr.io/foo/app"},
+		{"localhost:5000/app:1.0", "localhost:5000/app"},
+		{"foo/app", "docker.io/foo/app"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if repository := (RegistryMirrors{}).Repository(tt.reference); repository != tt.expected {
+			t.Errorf("Repository(%q) = %q, expected %q", tt.reference, repository, tt.expected)
+		}
+	}
+}
+
+func TestRepository_Mirrors(t *testing.T) {
+	mirrors := RegistryMirrors{Rules: []MirrorRule{
+		{Canonical: "docker.io", Mirrors: []string{"registry1.dso.mil/ironbank/opensource"}},
+		{Canonical: "docker.io/library", Mirrors: []string{"registry1.dso.mil/ironbank/opensource/alpine"}},
+		{Canonical: "ghcr.io/example", Mirrors: []string{"mirror.example.com:5000/ghcr"}},
+	}}
+	tests := []struct {
+		a, b string
+	}{
+		{"registry1.dso.mil/ironbank/opensource/bitnami/elasticsearch:8.16.0", "bitnami/elasticsearch:8.17.0"},
+		{"registry1.dso.mil/ironbank/opensource/alpine/alpine:3.20", "alpine:3.21"},
+		{"mirror.example.com:5000/ghcr/app", "ghcr.io/example/app"},
+	}
+	for _, tt := range tests {
+		if !mirrors.SameRepository(tt.a, tt.b) {
+			t.Errorf("Expected %q and %q to be the same repository, got %q and %q", tt.a, tt.b, mirrors.Repository(tt.a), mirrors.Repository(tt.b))
+		}
+	}
+	if mirrors.SameRepository("registry1.dso.mil/ironbank/other/app", "app") {
+		t.Error("Expected repositories outside of a mirror prefix to stay distinct")
+	}
+}
+
+func TestRepositoryIndex_Find(t *testing.T) {
+	index := NewRepositoryIndex(RegistryMirrors{}, map[string]string{
+		"ghcr.io/foo/app":         "foo.json",
+		"ghcr.io/bar/app":         "bar.json",
+		"docker.io/library/redis": "redis.json",
+	})
+
+	if scan, found := index.Find("registry:ghcr.io/bar/app:2.0"); !found || scan != "bar.json" {
+		t.Errorf("Expected bar/app to match its own repository, got %q", scan)
+	}
+	if _, found := index.Find("ghcr.io/baz/app:2.0"); found {
+		t.Error("Expected no match for a repository that was not released")
+	}
+	if _, found := index.Find("app"); found {
+		t.Error("Expected an ambiguous image name not to match")
+	}
+	if scan, found := index.Find("redis"); !found || scan != "redis.json" {
+		t.Errorf("Expected a bare image name to match, got %q", scan)
+	}
+}
+
+func TestLoadRegistryMirrors(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "mirrors.yaml")
+	content := "mirrors:\n  - canonical: docker.io\n    mirrors:\n      - registry1.dso.mil/ironbank/opensource\n"
+	if err := os.WriteFile(valid, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mirrors, err := LoadRegistryMirrors(valid)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(mirrors.Rules) != 1 || mirrors.Rules[0].Mirrors[0] != "registry1.dso.mil/ironbank/opensource" {
+		t.Errorf("Unexpected rules %+v", mirrors.Rules)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("mirrors:\n  - canonical: docker.io\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRegistryMirrors(invalid); err == nil {
+		t.Error("Expected an error for a rule without mirrors, got nil")
+	}
+}
+
+func TestScanReference(t *testing.T) {
+	reference, err := ScanReference("../test/scans/docker.io_alpine_3.17.json")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if reference != "docker.io/alpine" {
+		t.Errorf("Expected docker.io/alpine, got %q", reference)
+	}
+}
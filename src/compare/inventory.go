@@ -0,0 +1,373 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// Component is a package found in an SBOM.
+type Component struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	PURL     string   `json:"purl,omitempty"`
+	Licenses []string `json:"licenses,omitempty"`
+}
+
+// Inventory holds the components of an SBOM, keyed by package identity without the version.
+type Inventory struct {
+	Image      Image
+	Components map[string]Component
+}
+
+// ComponentChange is a component present in both SBOMs with a different version or different licenses.
+type ComponentChange struct {
+	Name         string   `json:"name"`
+	PURL         string   `json:"purl,omitempty"`
+	BaseVersion  string   `json:"baseVersion,omitempty"`
+	NewVersion   string   `json:"newVersion,omitempty"`
+	BaseLicenses []string `json:"baseLicenses,omitempty"`
+	NewLicenses  []string `json:"newLicenses,omitempty"`
+}
+
+// VersionChanged reports whether the version of the component changed.
+func (c ComponentChange) VersionChanged() bool {
+	return c.BaseVersion != c.NewVersion
+}
+
+// LicensesChanged reports whether the licenses of the component changed.
+func (c ComponentChange) LicensesChanged() bool {
+	return !slices.Equal(c.BaseLicenses, c.NewLicenses)
+}
+
+// InventoryDiff holds the components added, removed and changed between a base and a new SBOM.
+type InventoryDiff struct {
+	BaseImage Image             `json:"baseImage"`
+	NewImage  Image             `json:"newImage"`
+	Added     []Component       `json:"added"`
+	Removed   []Component       `json:"removed"`
+	Changed   []ComponentChange `json:"changed"`
+}
+
+// Empty reports whether the SBOMs have the same components.
+func (d InventoryDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// LoadInventory reads the components of a CycloneDX JSON or Syft JSON SBOM.
+func LoadInventory(path string) (Inventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Inventory{}, err
+	}
+	var probe struct {
+		BOMFormat string          `json:"bomFormat"`
+		Artifacts json.RawMessage `json:"artifacts"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return Inventory{}, fmt.Errorf("failed to parse SBOM %s: %w", path, err)
+	}
+	switch {
+	case probe.BOMFormat == "CycloneDX":
+		return cycloneDXInventory(data, path)
+	case probe.Artifacts != nil:
+		return syftInventory(data, path)
+	default:
+		return Inventory{}, fmt.Errorf("%s is neither a CycloneDX nor a Syft JSON SBOM", path)
+	}
+}
+
+func cycloneDXInventory(data []byte, path string) (Inventory, error) {
+	var bom cyclonedx.BOM
+	if err := cyclonedx.NewBOMDecoder(bytes.NewReader(data), cyclonedx.BOMFileFormatJSON).Decode(&bom); err != nil {
+		return Inventory{}, fmt.Errorf("failed to parse CycloneDX SBOM %s: %w", path, err)
+	}
+	inventory := Inventory{Image: scanImage(bom), Components: map[string]Component{}}
+	var add func(components *[]cyclonedx.Component)
+	add = func(components *[]cyclonedx.Component) {
+		if components == nil {
+			return
+		}
+		for _, component := range *components {
+			var licenses []string
+			if component.Licenses != nil {
+				for _, choice := range *component.Licenses {
+					switch {
+					case choice.Expression != "":
+						licenses = append(licenses, choice.Expression)
+					case choice.License != nil && choice.License.ID != "":
+						licenses = append(licenses, choice.License.ID)
+					case choice.License != nil && choice.License.Name != "":
+						licenses = append(licenses, choice.License.Name)
+					}
+				}
+			}
+			inventory.add(Component{
+				Name:     component.Name,
+				Version:  component.Version,
+				Type:     string(component.Type),
+				PURL:     component.PackageURL,
+				Licenses: licenses,
+			})
+			add(component.Components)
+		}
+	}
+	add(bom.Components)
+	return inventory, nil
+}
+
+func syftInventory(data []byte, path string) (Inventory, error) {
+	var sbom struct {
+		Artifacts []struct {
+			Name     string            `json:"name"`
+			Version  string            `json:"version"`
+			Type     string            `json:"type"`
+			PURL     string            `json:"purl"`
+			Licenses []json.RawMessage `json:"licenses"`
+		} `json:"artifacts"`
+		Source struct {
+			Metadata struct {
+				UserInput string `json:"userInput"`
+			} `json:"metadata"`
+		} `json:"source"`
+	}
+	if err := json.Unmarshal(data, &sbom); err != nil {
+		return Inventory{}, fmt.Errorf("failed to parse Syft SBOM %s: %w", path, err)
+	}
+	inventory := Inventory{Image: referenceImage(sbom.Source.Metadata.UserInput), Components: map[string]Component{}}
+	for _, artifact := range sbom.Artifacts {
+		var licenses []string
+		for _, raw := range artifact.Licenses {
+			// older Syft versions list licenses as strings, newer ones as objects
+			var license struct {
+				Value          string `json:"value"`
+				SPDXExpression string `json:"spdxExpression"`
+			}
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				if err := json.Unmarshal(raw, &license); err != nil {
+					return Inventory{}, fmt.Errorf("failed to parse license of %s in %s: %w", artifact.Name, path, err)
+				}
+				value = license.SPDXExpression
+				if value == "" {
+					value = license.Value
+				}
+			}
+			if value != "" {
+				licenses = append(licenses, value)
+			}
+		}
+		inventory.add(Component{Name: artifact.Name, Version: artifact.Version, Type: artifact.Type, PURL: artifact.PURL, Licenses: licenses})
+	}
+	return inventory, nil
+}
+
+// referenceImage splits an image reference into the image name and tag
+func referenceImage(reference string) Image {
+	reference = strings.TrimPrefix(reference, "registry:")
+	if idx := strings.LastIndex(reference, ":"); idx > strings.LastIndex(reference, "/") {
+		return Image{Name: reference[:idx], Version: reference[idx+1:]}
+	}
+	return Image{Name: reference}
+}
+
+// add records a component. The same package installed in several places with different versions is
+// recorded once with all of its versions.
+func (inventory Inventory) add(component Component) {
+	if component.Name == "" {
+		return
+	}
+	key := component.Type + "/" + component.Name
+	if component.PURL != "" {
+		key = packageWithoutVersion(component.PURL)
+	}
+	sort.Strings(component.Licenses)
+	component.Licenses = slices.Compact(component.Licenses)
+	existing, found := inventory.Components[key]
+	if !found {
+		inventory.Components[key] = component
+		return
+	}
+	versions := strings.Split(existing.Version, ", ")
+	if !slices.Contains(versions, component.Version) {
+		versions = append(versions, component.Version)
+		sort.Strings(versions)
+		existing.Version = strings.Join(versions, ", ")
+	}
+	existing.Licenses = append(existing.Licenses, component.Licenses...)
+	sort.Strings(existing.Licenses)
+	existing.Licenses = slices.Compact(existing.Licenses)
+	inventory.Components[key] = existing
+}
+
+// DiffInventories lists the components added, removed, and changed in version or licenses, sorted by name.
+func DiffInventories(base Inventory, new Inventory) InventoryDiff {
+	diff := InventoryDiff{
+		BaseImage: base.Image,
+		NewImage:  new.Image,
+		Added:     []Component{},
+		Removed:   []Component{},
+		Changed:   []ComponentChange{},
+	}
+	for key, component := range new.Components {
+		baseComponent, found := base.Components[key]
+		if !found {
+			diff.Added = append(diff.Added, component)
+			continue
+		}
+		change := ComponentChange{
+			Name:         component.Name,
+			PURL:         packageWithoutVersion(component.PURL),
+			BaseVersion:  baseComponent.Version,
+			NewVersion:   component.Version,
+			BaseLicenses: baseComponent.Licenses,
+			NewLicenses:  component.Licenses,
+		}
+		if change.VersionChanged() || change.LicensesChanged() {
+			diff.Changed = append(diff.Changed, change)
+		}
+	}
+	for key, component := range base.Components {
+		if _, found := new.Components[key]; !found {
+			diff.Removed = append(diff.Removed, component)
+		}
+	}
+	sortComponents(diff.Added)
+	sortComponents(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		if diff.Changed[i].Name != diff.Changed[j].Name {
+			return diff.Changed[i].Name < diff.Changed[j].Name
+		}
+		return diff.Changed[i].PURL < diff.Changed[j].PURL
+	})
+	return diff
+}
+
+func sortComponents(components []Component) {
+	sort.Slice(components, func(i, j int) bool {
+		if components[i].Name != components[j].Name {
+			return components[i].Name < components[j].Name
+		}
+		return components[i].PURL < components[j].PURL
+	})
+}
+
+// InventoryRenderer turns the component differences between SBOMs into a specific output format.
+type InventoryRenderer interface {
+	RenderInventory(diffs []InventoryDiff) (string, error)
+}
+
+// InventoryFormats lists the output formats supported for component differences.
+var InventoryFormats = []string{FormatMarkdown, FormatJSON}
+
+// NewInventoryRenderer returns the renderer of component differences for the given output format.
+func NewInventoryRenderer(format string) (InventoryRenderer, error) {
+	switch strings.ToLower(format) {
+	case FormatMarkdown, "":
+		return MarkdownRenderer{}, nil
+	case FormatJSON:
+		return JSONRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, expected one of: %s", format, strings.Join(InventoryFormats, ", "))
+	}
+}
+
+func (JSONRenderer) RenderInventory(diffs []InventoryDiff) (string, error) {
+	if diffs == nil {
+		diffs = []InventoryDiff{}
+	}
+	return marshalJSON(struct {
+		Diffs []InventoryDiff `json:"diffs"`
+	}{diffs})
+}
+
+func (MarkdownRenderer) RenderInventory(diffs []InventoryDiff) (string, error) {
+	var outputBuilder strings.Builder
+	for _, diff := range diffs {
+		if outputBuilder.Len() > 0 {
+			outputBuilder.WriteString("\n\n")
+		}
+		fmt.Fprintf(&outputBuilder, "### `%s:%s` -> `%s:%s`\n\n", diff.BaseImage.Name, diff.BaseImage.Version, diff.NewImage.Name, diff.NewImage.Version)
+		markdown, err := renderInventoryMarkdown(diff)
+		if err != nil {
+			return "", err
+		}
+		outputBuilder.WriteString(markdown)
+		outputBuilder.WriteString("\n---\n")
+	}
+	return outputBuilder.String(), nil
+}
+
+// renderInventoryMarkdown renders the counts and tables of component changes, without a heading
+func renderInventoryMarkdown(diff InventoryDiff) (string, error) {
+	var outputBuilder strings.Builder
+	var versionChanges, licenseChanges int
+	for _, change := range diff.Changed {
+		if change.VersionChanged() {
+			versionChanges++
+		}
+		if change.LicensesChanged() {
+			licenseChanges++
+		}
+	}
+	fmt.Fprintf(&outputBuilder, "Added components: %d\n", len(diff.Added))
+	fmt.Fprintf(&outputBuilder, "Removed components: %d\n", len(diff.Removed))
+	fmt.Fprintf(&outputBuilder, "Version changes: %d\n", versionChanges)
+	fmt.Fprintf(&outputBuilder, "License changes: %d\n\n", licenseChanges)
+
+	componentRows := func(components []Component) [][]string {
+		rows := make([][]string, 0, len(components))
+		for _, component := range components {
+			rows = append(rows, []string{component.Name, component.Version, component.Type, strings.Join(component.Licenses, ", ")})
+		}
+		return rows
+	}
+	changeRows := make([][]string, 0, len(diff.Changed))
+	for _, change := range diff.Changed {
+		changeRows = append(changeRows, []string{
+			change.Name,
+			change.BaseVersion,
+			change.NewVersion,
+			strings.Join(change.BaseLicenses, ", "),
+			strings.Join(change.NewLicenses, ", "),
+		})
+	}
+	sections := []struct {
+		summary string
+		header  []string
+		rows    [][]string
+	}{
+		{"Added components", []string{"Name", "Version", "Type", "Licenses"}, componentRows(diff.Added)},
+		{"Removed components", []string{"Name", "Version", "Type", "Licenses"}, componentRows(diff.Removed)},
+		{"Changed components", []string{"Name", "Base Version", "New Version", "Base Licenses", "New Licenses"}, changeRows},
+	}
+	for _, section := range sections {
+		if len(section.rows) == 0 {
+			continue
+		}
+		tableString := &strings.Builder{}
+		table := newMarkdownTable(tableString)
+		table.Header(section.header)
+		if err := table.Bulk(section.rows); err != nil {
+			return "", err
+		}
+		if err := table.Render(); err != nil {
+			return "", err
+		}
+		outputBuilder.WriteString("<details>\n")
+		fmt.Fprintf(&outputBuilder, "<summary>%s</summary>\n\n", section.summary)
+		outputBuilder.WriteString(tableString.String())
+		outputBuilder.WriteString("\n</details>\n")
+	}
+	return outputBuilder.String(), nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const syftSBOM = `{
+  "artifacts": [
+    {"name": "busybox", "version": "1.36.1-r2", "type": "apk", "purl": "pkg:apk/alpine/busybox@1.36.1-r2?arch=x86_64", "licenses": ["GPL-2.0-only"]},
+    {"name": "zlib", "version": "1.3-r0", "type": "apk", "purl": "pkg:apk/alpine/zlib@1.3-r0", "licenses": [{"value": "Zlib", "spdxExpression": "Zlib"}]},
+    {"name": "openssl", "version": "3.1.4-r0", "type": "apk", "purl": "pkg:apk/alpine/openssl@3.1.4-r0", "licenses": [{"value": "Apache-2.0"}]}
+  ],
+  "source": {"metadata": {"userInput": "registry1.dso.mil/ironbank/app:1.0.0"}}
+}`
+
+const cycloneDXSBOM = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "metadata": {"component": {"name": "registry1.dso.mil/ironbank/app", "version": "1.1.0"}},
+  "components": [
+    {"name": "busybox", "version": "1.36.1-r5", "type": "library", "purl": "pkg:apk/alpine/busybox@1.36.1-r5?arch=x86_64", "licenses": [{"license": {"id": "GPL-2.0-only"}}]},
+    {"name": "zlib", "version": "1.3-r0", "type": "library", "purl": "pkg:apk/alpine/zlib@1.3-r0", "licenses": [{"expression": "Zlib OR MIT"}]},
+    {"name": "curl", "version": "8.5.0-r0", "type": "library", "purl": "pkg:apk/alpine/curl@8.5.0-r0",
+      "components": [{"name": "libcurl", "version": "8.5.0-r0", "type": "library", "purl": "pkg:apk/alpine/libcurl@8.5.0-r0"}]}
+  ]
+}`
+
+func writeSBOM(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sbom.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiffInventories(t *testing.T) {
+	base, err := LoadInventory(writeSBOM(t, syftSBOM))
+	if err != nil {
+		t.Fatalf("Expected no error loading the Syft SBOM, got: %v", err)
+	}
+	current, err := LoadInventory(writeSBOM(t, cycloneDXSBOM))
+	if err != nil {
+		t.Fatalf("Expected no error loading the CycloneDX SBOM, got: %v", err)
+	}
+	if base.Image != (Image{Name: "registry1.dso.mil/ironbank/app", Version: "1.0.0"}) {
+		t.Errorf("Unexpected base image %v", base.Image)
+	}
+
+	diff := DiffInventories(base, current)
+	names := func(components []Component) string {
+		var result []string
+		for _, component := range components {
+			result = append(result, component.Name)
+		}
+		return strings.Join(result, ",")
+	}
+	if names(diff.Added) != "curl,libcurl" {
+		t.Errorf("Expected curl and the nested libcurl to be added, got %v", diff.Added)
+	}
+	if names(diff.Removed) != "openssl" {
+		t.Errorf("Expected openssl to be removed, got %v", diff.Removed)
+	}
+	if len(diff.Changed) != 2 {
+		t.Fatalf("Expected 2 changed components, got %v", diff.Changed)
+	}
+	busybox, zlib := diff.Changed[0], diff.Changed[1]
+	if busybox.Name != "busybox" || !busybox.VersionChanged() || busybox.LicensesChanged() || busybox.NewVersion != "1.36.1-r5" {
+		t.Errorf("Expected a version change of busybox, got %+v", busybox)
+	}
+	if zlib.Name != "zlib" || zlib.VersionChanged() || !zlib.LicensesChanged() || strings.Join(zlib.NewLicenses, ",") != "Zlib OR MIT" {
+		t.Errorf("Expected a license change of zlib, got %+v", zlib)
+	}
+}
+
+func TestLoadInventory_UnknownFormat(t *testing.T) {
+	if _, err := LoadInventory(writeSBOM(t, `{"spdxVersion": "SPDX-2.3"}`)); err == nil {
+		t.Error("Expected an error for an unsupported SBOM format, got nil")
+	}
+}
+
+func TestRenderInventory(t *testing.T) {
+	base, _ := LoadInventory(writeSBOM(t, syftSBOM))
+	current, _ := LoadInventory(writeSBOM(t, cycloneDXSBOM))
+	diff := DiffInventories(base, current)
+
+	markdown, err := MarkdownRenderer{}.RenderInventory([]InventoryDiff{diff})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, expected := range []string{
+		"### `registry1.dso.mil/ironbank/app:1.0.0` -> `registry1.dso.mil/ironbank/app:1.1.0`",
+		"Added components: 2\n",
+		"Removed components: 1\n",
+		"Version changes: 1\n",
+		"License changes: 1\n",
+		"| busybox | 1.36.1-r2    | 1.36.1-r5",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected %q in the markdown, got:\n%s", expected, markdown)
+		}
+	}
+
+	output, err := JSONRenderer{}.RenderInventory([]InventoryDiff{diff})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	var report struct {
+		Diffs []InventoryDiff `json:"diffs"`
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Expected valid JSON, got: %v", err)
+	}
+	if len(report.Diffs) != 1 || len(report.Diffs[0].Added) != 2 || report.Diffs[0].Removed[0].Name != "openssl" {
+		t.Errorf("Unexpected JSON report %+v", report)
+	}
+
+	if _, err := NewInventoryRenderer("sarif"); err == nil {
+		t.Error("Expected an error for a format without component differences, got nil")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitRenderer renders comparisons as JUnit XML with a test suite per image and a failed test case
+// per new vulnerability. Images without new vulnerabilities get a single passing test case.
+type JUnitRenderer struct{}
+
+func (JUnitRenderer) Render(report Report) (string, error) {
+	suites := junitTestSuites{Name: toolName, Suites: []junitTestSuite{}}
+
+	for _, comparison := range report.Comparisons {
+		className := comparison.NewImage.Name
+		suite := junitTestSuite{Name: comparisonTitle(comparison)}
+		if variant := comparison.Variant(); variant != "" {
+			suite.Name = fmt.Sprintf("%s (%s)", suite.Name, variant)
+		}
+		for _, entry := range comparison.New {
+			details := fmt.Sprintf("Package: %s\nInstalled version: %s\nSeverity: %s", entry.Package, entry.InstalledVersion, entry.Severity)
+			if entry.FixedVersion != "" {
+				details += fmt.Sprintf("\nFixed version: %s", entry.FixedVersion)
+			}
+			if entry.URL != "" {
+				details += fmt.Sprintf("\nURL: %s", entry.URL)
+			}
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      fmt.Sprintf("%s in %s", entry.ID, entry.Package),
+				ClassName: className,
+				Failure: &junitFailure{
+					Message: fmt.Sprintf("new %s vulnerability %s in %s %s", entry.Severity, entry.ID, entry.Package, entry.InstalledVersion),
+					Type:    entry.Severity,
+					Text:    details,
+				},
+			})
+		}
+		suite.Failures = len(suite.TestCases)
+		if suite.Failures == 0 {
+			suite.TestCases = append(suite.TestCases, junitTestCase{Name: "no new vulnerabilities", ClassName: className})
+		}
+		suite.Tests = len(suite.TestCases)
+
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data), nil
+}
+
+func comparisonTitle(comparison Comparison) string {
+	if comparison.BaseImage.Name == comparison.NewImage.Name {
+		return fmt.Sprintf("%s %s -> %s", comparison.NewImage.Name, comparison.BaseImage.Version, comparison.NewImage.Version)
+	}
+	return fmt.Sprintf("%s:%s -> %s:%s", comparison.BaseImage.Name, comparison.BaseImage.Version, comparison.NewImage.Name, comparison.NewImage.Version)
+}
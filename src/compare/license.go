@@ -0,0 +1,457 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	goyaml "github.com/goccy/go-yaml"
+)
+
+// ErrLicensePolicyViolation is returned when the components of an SBOM do not satisfy the license policy.
+var ErrLicensePolicyViolation = errors.New("license policy check failed")
+
+// LicensePolicy describes the licenses components may be distributed under.
+//
+// Deny and Allow entries are SPDX license identifiers, compared case-insensitively; a trailing *
+// matches any identifier with that prefix, e.g. AGPL-*. Denied licenses always violate the policy.
+// When Allow is not empty, every license not in it violates the policy as well. Entries can name a
+// license with an exception, e.g. GPL-2.0-only WITH Classpath-exception-2.0, which an allow entry then
+// permits even when the license itself is denied. An OR expression
+// complies when one of its alternatives does, an AND expression when all of its parts do.
+type LicensePolicy struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+	// FailOnUnknown makes components without a license violate the policy. They are only reported otherwise.
+	FailOnUnknown bool `yaml:"failOnUnknown"`
+	// Ignore lists packages, by package URL or name, that are exempt from the policy.
+	Ignore []string `yaml:"ignore"`
+}
+
+// LicenseViolation is a component whose licenses do not satisfy the license policy.
+type LicenseViolation struct {
+	Component Component `json:"component"`
+	// License is the normalized license expression of the component
+	License string `json:"license,omitempty"`
+	// Reasons describe each license of the expression that violates the policy
+	Reasons []string `json:"reasons"`
+}
+
+// LicenseResult holds the outcome of checking the components of a single SBOM against the license policy.
+type LicenseResult struct {
+	Image      Image              `json:"image"`
+	Flavor     string             `json:"flavor,omitempty"`
+	Violations []LicenseViolation `json:"violations"`
+	Unknown    []Component        `json:"unknown"`
+}
+
+// LoadLicensePolicy reads a license policy file in YAML format.
+func LoadLicensePolicy(path string) (LicensePolicy, error) {
+	var policy LicensePolicy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, err
+	}
+	if err := goyaml.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse license policy file %s: %w", path, err)
+	}
+	return policy, policy.Validate()
+}
+
+// Validate checks that the policy has rules and that its entries are license identifiers.
+func (p LicensePolicy) Validate() error {
+	if len(p.Allow) == 0 && len(p.Deny) == 0 {
+		return errors.New("license policy needs allow or deny entries")
+	}
+	for _, entry := range append(append([]string{}, p.Allow...), p.Deny...) {
+		fields := strings.Fields(entry)
+		withException := len(fields) == 3 && strings.EqualFold(fields[1], "WITH")
+		if len(fields) != 1 && !withException || strings.TrimSuffix(fields[0], "*") == "" || strings.ContainsAny(entry, "()") {
+			return fmt.Errorf("invalid license policy entry %q: expected an SPDX license identifier", entry)
+		}
+	}
+	return nil
+}
+
+// Evaluate checks the licenses of every component of the inventory.
+func (p LicensePolicy) Evaluate(inventory Inventory) LicenseResult {
+	result := LicenseResult{Image: inventory.Image, Violations: []LicenseViolation{}, Unknown: []Component{}}
+	for _, component := range inventory.Components {
+		if slices.ContainsFunc(p.Ignore, func(entry string) bool {
+			return matchesEntry(nonEmpty(component.PURL, component.Name), entry)
+		}) {
+			continue
+		}
+		if len(component.Licenses) == 0 {
+			result.Unknown = append(result.Unknown, component)
+			if p.FailOnUnknown {
+				result.Violations = append(result.Violations, LicenseViolation{Component: component, Reasons: []string{"no license"}})
+			}
+			continue
+		}
+		// every license listed for a component applies to it
+		var parts []licenseExpression
+		for _, license := range component.Licenses {
+			parts = append(parts, parseLicenseExpression(license))
+		}
+		expression := parts[0]
+		if len(parts) > 1 {
+			expression = licenseExpression{operator: "AND", operands: parts}
+		}
+		if complies, reasons := p.complies(expression); !complies {
+			result.Violations = append(result.Violations, LicenseViolation{Component: component, License: expression.String(), Reasons: reasons})
+		}
+	}
+	sort.Slice(result.Violations, func(i, j int) bool {
+		return componentLess(result.Violations[i].Component, result.Violations[j].Component)
+	})
+	sort.Slice(result.Unknown, func(i, j int) bool {
+		return componentLess(result.Unknown[i], result.Unknown[j])
+	})
+	return result
+}
+
+func componentLess(a Component, b Component) bool {
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.PURL < b.PURL
+}
+
+// complies evaluates the expression and returns the reasons of the licenses that fail it
+func (p LicensePolicy) complies(expression licenseExpression) (bool, []string) {
+	switch expression.operator {
+	case "AND", "OR":
+		var reasons []string
+		satisfied := 0
+		for _, operand := range expression.operands {
+			complies, operandReasons := p.complies(operand)
+			if complies {
+				satisfied++
+			}
+			for _, reason := range operandReasons {
+				reasons = appendUnique(reasons, reason)
+			}
+		}
+		if expression.operator == "OR" && satisfied > 0 || expression.operator == "AND" && satisfied == len(expression.operands) {
+			return true, nil
+		}
+		return false, reasons
+	}
+	if expression.exception != "" && matchesLicense(p.Allow, expression.String(), "") {
+		return true, nil
+	}
+	if matchesLicense(p.Deny, expression.license, expression.exception) {
+		return false, []string{expression.String() + " is denied"}
+	}
+	if len(p.Allow) > 0 && !matchesLicense(p.Allow, expression.license, expression.exception) {
+		return false, []string{expression.String() + " is not allowed"}
+	}
+	return true, nil
+}
+
+// matchesLicense reports whether a policy entry matches the license, or the license with its exception
+func matchesLicense(patterns []string, license string, exception string) bool {
+	candidates := []string{license}
+	if exception != "" {
+		candidates = append(candidates, license+" WITH "+exception)
+	}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		prefix, wildcard := strings.CutSuffix(pattern, "*")
+		if !wildcard {
+			prefix = NormalizeLicenseID(pattern)
+		}
+		for _, candidate := range candidates {
+			if wildcard && strings.HasPrefix(strings.ToLower(candidate), strings.ToLower(prefix)) ||
+				!wildcard && strings.EqualFold(candidate, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// licenseExpression is a parsed SPDX license expression: a license, with an optional exception,
+// or an AND or OR of expressions
+type licenseExpression struct {
+	operator  string
+	operands  []licenseExpression
+	license   string
+	exception string
+}
+
+func (e licenseExpression) String() string {
+	if e.operator == "" {
+		if e.exception != "" {
+			return e.license + " WITH " + e.exception
+		}
+		return e.license
+	}
+	parts := make([]string, 0, len(e.operands))
+	for _, operand := range e.operands {
+		part := operand.String()
+		// nested expressions of the other operator keep their parentheses
+		if operand.operator != "" && operand.operator != e.operator {
+			part = "(" + part + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " "+e.operator+" ")
+}
+
+// NormalizeLicenseExpression rewrites a license expression with SPDX identifiers for common license
+// names and deprecated identifiers, e.g. "Apache License 2.0 OR GPL-2.0+" becomes
+// "Apache-2.0 OR GPL-2.0-or-later".
+func NormalizeLicenseExpression(expression string) string {
+	return parseLicenseExpression(expression).String()
+}
+
+// parseLicenseExpression parses an SPDX license expression. Text that is not a valid expression,
+// such as a license name with spaces, is treated as a single license.
+func parseLicenseExpression(expression string) licenseExpression {
+	parser := licenseParser{tokens: tokenizeLicenseExpression(expression)}
+	parsed, err := parser.parseOr()
+	if err != nil || parser.position != len(parser.tokens) {
+		return licenseExpression{license: NormalizeLicenseID(expression)}
+	}
+	return parsed
+}
+
+// tokenizeLicenseExpression splits an expression into parentheses, operators and licenses. Consecutive
+// words between them are joined, so that license names like "Apache License 2.0" remain a single license.
+func tokenizeLicenseExpression(expression string) []string {
+	expression = strings.ReplaceAll(expression, "(", " ( ")
+	expression = strings.ReplaceAll(expression, ")", " ) ")
+	var tokens []string
+	joinable := false
+	for _, word := range strings.Fields(expression) {
+		isLicense := word != "(" && word != ")" && !isLicenseOperator(word)
+		if isLicense && joinable {
+			tokens[len(tokens)-1] += " " + word
+			continue
+		}
+		tokens = append(tokens, word)
+		joinable = isLicense
+	}
+	return tokens
+}
+
+type licenseParser struct {
+	tokens   []string
+	position int
+}
+
+func (p *licenseParser) peek() string {
+	if p.position < len(p.tokens) {
+		return p.tokens[p.position]
+	}
+	return ""
+}
+
+func (p *licenseParser) parseOr() (licenseExpression, error) {
+	return p.parseOperator("OR", p.parseAnd)
+}
+
+func (p *licenseParser) parseAnd() (licenseExpression, error) {
+	return p.parseOperator("AND", p.parseWith)
+}
+
+func (p *licenseParser) parseOperator(operator string, operand func() (licenseExpression, error)) (licenseExpression, error) {
+	first, err := operand()
+	if err != nil {
+		return licenseExpression{}, err
+	}
+	operands := []licenseExpression{first}
+	for strings.EqualFold(p.peek(), operator) {
+		p.position++
+		next, err := operand()
+		if err != nil {
+			return licenseExpression{}, err
+		}
+		// flatten nested expressions of the same operator, e.g. (MIT OR ISC) OR BSD-2-Clause
+		if next.operator == operator {
+			operands = append(operands, next.operands...)
+		} else {
+			operands = append(operands, next)
+		}
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	if first.operator == operator {
+		operands = append(first.operands, operands[1:]...)
+	}
+	return licenseExpression{operator: operator, operands: operands}, nil
+}
+
+func (p *licenseParser) parseWith() (licenseExpression, error) {
+	token := p.peek()
+	switch {
+	case token == "(":
+		p.position++
+		expression, err := p.parseOr()
+		if err != nil {
+			return licenseExpression{}, err
+		}
+		if p.peek() != ")" {
+			return licenseExpression{}, errors.New("missing closing parenthesis")
+		}
+		p.position++
+		return expression, nil
+	case token == "", token == ")", isLicenseOperator(token):
+		return licenseExpression{}, fmt.Errorf("unexpected %q", token)
+	}
+	p.position++
+	expression := licenseExpression{license: NormalizeLicenseID(token)}
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.position++
+		exception := p.peek()
+		if exception == "" || exception == "(" || exception == ")" || isLicenseOperator(exception) {
+			return licenseExpression{}, errors.New("missing license exception")
+		}
+		p.position++
+		expression.exception = exception
+	}
+	return expression, nil
+}
+
+func isLicenseOperator(token string) bool {
+	return strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR") || strings.EqualFold(token, "WITH")
+}
+
+// licenseAliases maps common license names found in package metadata to SPDX identifiers
+var licenseAliases = map[string]string{
+	"apache 2":                          "Apache-2.0",
+	"apache 2.0":                        "Apache-2.0",
+	"apache-2":                          "Apache-2.0",
+	"apache2":                           "Apache-2.0",
+	"apache license 2.0":                "Apache-2.0",
+	"apache license, version 2.0":       "Apache-2.0",
+	"apache software license":           "Apache-2.0",
+	"mit license":                       "MIT",
+	"the mit license":                   "MIT",
+	"expat":                             "MIT",
+	"bsd 2-clause":                      "BSD-2-Clause",
+	"bsd 3-clause":                      "BSD-3-Clause",
+	"new bsd license":                   "BSD-3-Clause",
+	"isc license":                       "ISC",
+	"mozilla public license 2.0":        "MPL-2.0",
+	"public domain":                     "LicenseRef-Public-Domain",
+	"gnu general public license v2":     "GPL-2.0-only",
+	"gnu general public license v3":     "GPL-3.0-only",
+	"gnu lesser general public license": "LGPL-2.1-or-later",
+}
+
+// deprecatedGNULicense matches the deprecated SPDX identifiers of GNU licenses, e.g. GPL-2.0 and GPL-2.0+
+var deprecatedGNULicense = regexp.MustCompile(`(?i)^((?:A|L)?GPL|GFDL)-(\d\.\d)(\+)?$`)
+
+// NormalizeLicenseID returns the SPDX identifier for a license name or deprecated identifier.
+// Other identifiers are returned unchanged.
+func NormalizeLicenseID(license string) string {
+	license = strings.TrimSpace(license)
+	if alias, found := licenseAliases[strings.ToLower(license)]; found {
+		return alias
+	}
+	if match := deprecatedGNULicense.FindStringSubmatch(license); match != nil {
+		suffix := "-only"
+		if match[3] != "" {
+			suffix = "-or-later"
+		}
+		return strings.ToUpper(match[1]) + "-" + match[2] + suffix
+	}
+	return license
+}
+
+// LicenseRenderer turns the results of a license check into a specific output format.
+type LicenseRenderer interface {
+	RenderLicenses(results []LicenseResult) (string, error)
+}
+
+// NewLicenseRenderer returns the renderer of license check results for the given output format.
+func NewLicenseRenderer(format string) (LicenseRenderer, error) {
+	switch strings.ToLower(format) {
+	case FormatMarkdown, "":
+		return MarkdownRenderer{}, nil
+	case FormatJSON:
+		return JSONRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, expected one of: %s", format, strings.Join(InventoryFormats, ", "))
+	}
+}
+
+func (JSONRenderer) RenderLicenses(results []LicenseResult) (string, error) {
+	if results == nil {
+		results = []LicenseResult{}
+	}
+	return marshalJSON(struct {
+		Results []LicenseResult `json:"results"`
+	}{results})
+}
+
+func (MarkdownRenderer) RenderLicenses(results []LicenseResult) (string, error) {
+	var outputBuilder strings.Builder
+	for _, result := range results {
+		if outputBuilder.Len() > 0 {
+			outputBuilder.WriteString("\n\n")
+		}
+		fmt.Fprintf(&outputBuilder, "### `%s:%s`", result.Image.Name, result.Image.Version)
+		if result.Flavor != "" {
+			fmt.Fprintf(&outputBuilder, " (%s)", result.Flavor)
+		}
+		outputBuilder.WriteString("\n\n")
+		fmt.Fprintf(&outputBuilder, "License violations: %d\n", len(result.Violations))
+		fmt.Fprintf(&outputBuilder, "Components without a license: %d\n\n", len(result.Unknown))
+
+		if len(result.Violations) > 0 {
+			tableString := &strings.Builder{}
+			table := newMarkdownTable(tableString)
+			table.Header([]string{"Package", "Version", "License", "Violation"})
+			rows := make([][]string, 0, len(result.Violations))
+			for _, violation := range result.Violations {
+				rows = append(rows, []string{
+					violation.Component.Name,
+					violation.Component.Version,
+					violation.License,
+					strings.Join(violation.Reasons, "; "),
+				})
+			}
+			if err := table.Bulk(rows); err != nil {
+				return "", err
+			}
+			if err := table.Render(); err != nil {
+				return "", err
+			}
+			outputBuilder.WriteString(tableString.String())
+			outputBuilder.WriteString("\n")
+		}
+		if len(result.Unknown) > 0 {
+			tableString := &strings.Builder{}
+			table := newMarkdownTable(tableString)
+			table.Header([]string{"Package", "Version", "Type"})
+			rows := make([][]string, 0, len(result.Unknown))
+			for _, component := range result.Unknown {
+				rows = append(rows, []string{component.Name, component.Version, component.Type})
+			}
+			if err := table.Bulk(rows); err != nil {
+				return "", err
+			}
+			if err := table.Render(); err != nil {
+				return "", err
+			}
+			outputBuilder.WriteString("<details>\n")
+			outputBuilder.WriteString("<summary>Components without a license</summary>\n\n")
+			outputBuilder.WriteString(tableString.String())
+			outputBuilder.WriteString("\n</details>\n")
+		}
+		outputBuilder.WriteString("\n---\n")
+	}
+	return outputBuilder.String(), nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeLicenseExpression(t *testing.T) {
+	tests := map[string]string{
+		"MIT":                                  "MIT",
+		"Apache License 2.0 OR GPL-2.0+":       "Apache-2.0 OR GPL-2.0-or-later",
+		"(mit or isc) and lgpl-2.1":            "(mit OR isc) AND LGPL-2.1-only",
+		"GPL-2.0 WITH Classpath-exception-2.0": "GPL-2.0-only WITH Classpath-exception-2.0",
+		"(MIT OR ISC) OR BSD-2-Clause":         "MIT OR ISC OR BSD-2-Clause",
+		"BSD (3 clause":                        "BSD (3 clause",
+		"GNU General Public License v2":        "GPL-2.0-only",
+	}
+	for expression, expected := range tests {
+		if normalized := NormalizeLicenseExpression(expression); normalized != expected {
+			t.Errorf("NormalizeLicenseExpression(%q) = %q, expected %q", expression, normalized, expected)
+		}
+	}
+}
+
+func TestLicensePolicyEvaluate(t *testing.T) {
+	inventory := Inventory{Image: Image{Name: "app", Version: "1.0.0"}, Components: map[string]Component{}}
+	for _, component := range []Component{
+		{Name: "allowed", Licenses: []string{"MIT"}},
+		{Name: "denied", Licenses: []string{"AGPL-3.0-only"}},
+		{Name: "deprecated-id", Licenses: []string{"GPL-3.0+"}},
+		{Name: "dual", Licenses: []string{"GPL-2.0-only OR MIT"}},
+		{Name: "both", Licenses: []string{"MIT AND GPL-2.0-only"}},
+		{Name: "not-allowed", Licenses: []string{"WTFPL"}},
+		{Name: "exception", Licenses: []string{"GPL-2.0-only WITH Classpath-exception-2.0"}},
+		{Name: "two-licenses", Licenses: []string{"MIT", "LGPL-2.1-only"}},
+		{Name: "unknown"},
+		{Name: "ignored", PURL: "pkg:apk/alpine/ignored@1.0", Licenses: []string{"GPL-3.0-only"}},
+		{Name: "@scoped/ignored", PURL: "pkg:npm/%40scoped/ignored@1.0", Licenses: []string{"GPL-3.0-only"}},
+	} {
+		inventory.add(component)
+	}
+	policy := LicensePolicy{
+		Allow:  []string{"MIT", "Apache-2.0", "LGPL-*", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Deny:   []string{"AGPL-*", "GPL-*"},
+		Ignore: []string{"pkg:apk/alpine/ignored", "@scoped/ignored@1.0"},
+	}
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+
+	result := policy.Evaluate(inventory)
+	var violations []string
+	for _, violation := range result.Violations {
+		violations = append(violations, violation.Component.Name+": "+strings.Join(violation.Reasons, "; "))
+	}
+	expected := []string{
+		"both: GPL-2.0-only is denied",
+		"denied: AGPL-3.0-only is denied",
+		"deprecated-id: GPL-3.0-or-later is denied",
+		"not-allowed: WTFPL is not allowed",
+	}
+	if strings.Join(violations, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected violations:\n%s\nexpected:\n%s", strings.Join(violations, "\n"), strings.Join(expected, "\n"))
+	}
+	if len(result.Unknown) != 1 || result.Unknown[0].Name != "unknown" {
+		t.Errorf("Expected the component without a license to be reported, got %v", result.Unknown)
+	}
+
+	policy.FailOnUnknown = true
+	if result := policy.Evaluate(inventory); len(result.Violations) != len(expected)+1 {
+		t.Errorf("Expected the component without a license to violate the policy, got %v", result.Violations)
+	}
+
+	// an ignore entry with a version only ignores that version
+	policy.FailOnUnknown = false
+	for entry, ignored := range map[string]bool{"pkg:apk/alpine/ignored@1.0": true, "pkg:apk/alpine/ignored@2.0": false, "ignored": true} {
+		policy.Ignore = []string{entry, "@scoped/ignored"}
+		result := policy.Evaluate(inventory)
+		if violated := len(result.Violations) == len(expected)+1; violated == ignored {
+			t.Errorf("Expected ignore entry %s to ignore the component: %v, got violations %v", entry, ignored, result.Violations)
+		}
+	}
+}
+
+func TestLoadLicensePolicy(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "licenses.yaml")
+	if err := os.WriteFile(valid, []byte("allow:\n  - MIT\ndeny:\n  - AGPL-*\nfailOnUnknown: true\nignore:\n  - busybox\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadLicensePolicy(valid)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(policy.Allow) != 1 || len(policy.Deny) != 1 || !policy.FailOnUnknown || policy.Ignore[0] != "busybox" {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+
+	for _, content := range []string{"failOnUnknown: true\n", "deny:\n  - MIT OR ISC\n"} {
+		invalid := filepath.Join(dir, "invalid.yaml")
+		if err := os.WriteFile(invalid, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadLicensePolicy(invalid); err == nil {
+			t.Errorf("Expected an error for policy %q, got nil", content)
+		}
+	}
+}
+
+func TestRenderLicenses(t *testing.T) {
+	results := []LicenseResult{{
+		Image:      Image{Name: "app", Version: "1.0.0"},
+		Flavor:     "upstream",
+		Violations: []LicenseViolation{{Component: Component{Name: "denied", Version: "1.0"}, License: "AGPL-3.0-only", Reasons: []string{"AGPL-3.0-only is denied"}}},
+		Unknown:    []Component{{Name: "unknown", Version: "2.0", Type: "apk"}},
+	}}
+	output, err := MarkdownRenderer{}.RenderLicenses(results)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, expected := range []string{"### `app:1.0.0` (upstream)", "License violations: 1\n", "Components without a license: 1\n", "| denied  | 1.0     | AGPL-3.0-only | AGPL-3.0-only is denied |"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the markdown, got:\n%s", expected, output)
+		}
+	}
+}
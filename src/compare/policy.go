@@ -0,0 +1,160 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	goyaml "github.com/goccy/go-yaml"
+)
+
+// ErrPolicyViolation is returned when a comparison does not satisfy the configured policy.
+var ErrPolicyViolation = errors.New("vulnerability policy check failed")
+
+const (
+	failOnNewPrefix = "new-"
+	anySeverity     = "any"
+)
+
+// Policy describes the conditions under which a scan comparison should fail.
+//
+// FailOn entries take the form new-<severity> (e.g. new-critical) and fail the
+// comparison when any new vulnerability at or above that severity is found.
+// MaxNew limits the number of new vulnerabilities of an exact severity; the
+// special key "any" limits the total number of new vulnerabilities.
+type Policy struct {
+	FailOn []string       `yaml:"failOn"`
+	MaxNew map[string]int `yaml:"maxNew"`
+}
+
+// LoadPolicy reads a policy file in YAML format.
+func LoadPolicy(path string) (Policy, error) {
+	var policy Policy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, err
+	}
+	if err := goyaml.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("failed to parse policy file %s: %w", path, err)
+	}
+	return policy, policy.Validate()
+}
+
+// AddMaxNew parses max-count rules in the form <severity>=<count> and adds them to the policy.
+func (p *Policy) AddMaxNew(rules []string) error {
+	for _, rule := range rules {
+		severity, count, found := strings.Cut(rule, "=")
+		if !found {
+			return fmt.Errorf("invalid max-new rule %q: expected <severity>=<count>", rule)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil {
+			return fmt.Errorf("invalid max-new rule %q: %w", rule, err)
+		}
+		if p.MaxNew == nil {
+			p.MaxNew = map[string]int{}
+		}
+		p.MaxNew[strings.ToLower(strings.TrimSpace(severity))] = limit
+	}
+	return nil
+}
+
+// IsEmpty reports whether the policy has no rules.
+func (p Policy) IsEmpty() bool {
+	return len(p.FailOn) == 0 && len(p.MaxNew) == 0
+}
+
+// Validate checks that all rules reference known severities.
+func (p Policy) Validate() error {
+	for _, rule := range p.FailOn {
+		if _, err := failOnSeverity(rule); err != nil {
+			return err
+		}
+	}
+	for severity, limit := range p.MaxNew {
+		if _, ok := severityOrder[severity]; !ok && severity != anySeverity {
+			return fmt.Errorf("invalid max-new severity %q", severity)
+		}
+		if limit < 0 {
+			return fmt.Errorf("invalid max-new count for %s: %d", severity, limit)
+		}
+	}
+	return nil
+}
+
+// Evaluate checks the number of new vulnerabilities per severity against the policy
+// and returns a description of every violated rule.
+func (p Policy) Evaluate(newBySeverity map[string]int) []string {
+	var violations []string
+
+	for _, rule := range p.FailOn {
+		threshold, err := failOnSeverity(rule)
+		if err != nil {
+			violations = append(violations, err.Error())
+			continue
+		}
+		count := 0
+		for severity, c := range newBySeverity {
+			if severityRank(severity) <= severityOrder[threshold] {
+				count += c
+			}
+		}
+		if count > 0 {
+			violations = append(violations, fmt.Sprintf("%s: found %d new vulnerabilities with severity %s or higher", rule, count, threshold))
+		}
+	}
+
+	severities := make([]string, 0, len(p.MaxNew))
+	for severity := range p.MaxNew {
+		severities = append(severities, severity)
+	}
+	sort.Strings(severities)
+	for _, severity := range severities {
+		limit := p.MaxNew[severity]
+		count := newBySeverity[severity]
+		if severity == anySeverity {
+			count = 0
+			for _, c := range newBySeverity {
+				count += c
+			}
+		}
+		if count > limit {
+			violations = append(violations, fmt.Sprintf("max-new %s=%d: found %d new vulnerabilities", severity, limit, count))
+		}
+	}
+
+	return violations
+}
+
+// CountNewBySeverity returns the number of new vulnerabilities in newScan grouped by lower-cased severity.
+func CountNewBySeverity(newScan cyclonedx.BOM, vulnStatus map[string]int) map[string]int {
+	counts := map[string]int{}
+	seen := map[string]bool{}
+	for _, vuln := range vulnerabilities(newScan) {
+		vulnUID := getUniqueVulnId(vuln)
+		if seen[vulnUID] || vulnStatus[vulnUID] != 0 {
+			continue
+		}
+		seen[vulnUID] = true
+		counts[vulnSeverity(vuln)]++
+	}
+	return counts
+}
+
+func failOnSeverity(rule string) (string, error) {
+	severity, found := strings.CutPrefix(strings.ToLower(rule), failOnNewPrefix)
+	if !found {
+		return "", fmt.Errorf("invalid fail-on rule %q: expected new-<severity>", rule)
+	}
+	if _, ok := severityOrder[severity]; !ok {
+		return "", fmt.Errorf("invalid fail-on rule %q: unknown severity %q", rule, severity)
+	}
+	return severity, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCountNewBySeverity(t *testing.T) {
+	vulnStatus := GenerateComparisonMap(commonBaseScan, commonNewBaseScan)
+	counts := CountNewBySeverity(commonNewBaseScan, vulnStatus)
+
+	if len(counts) != 1 || counts["low"] != 1 {
+		t.Errorf("Expected exactly one new low vulnerability, got %v", counts)
+	}
+}
+
+func TestPolicyEvaluate_FailOnThreshold(t *testing.T) {
+	tests := []struct {
+		name          string
+		failOn        string
+		counts        map[string]int
+		expectViolate bool
+	}{
+		{"critical found", "new-critical", map[string]int{"critical": 1}, true},
+		{"only lower severities", "new-critical", map[string]int{"high": 2, "low": 1}, false},
+		{"higher severity counts toward threshold", "new-high", map[string]int{"critical": 1}, true},
+		{"uppercase rule", "NEW-HIGH", map[string]int{"high": 1}, true},
+		{"no new vulnerabilities", "new-low", map[string]int{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := Policy{FailOn: []string{tt.failOn}}
+			if err := policy.Validate(); err != nil {
+				t.Fatalf("Unexpected validation error: %v", err)
+			}
+			violations := policy.Evaluate(tt.counts)
+			if tt.expectViolate && len(violations) == 0 {
+				t.Errorf("Expected a violation for %s with %v", tt.failOn, tt.counts)
+			}
+			if !tt.expectViolate && len(violations) != 0 {
+				t.Errorf("Expected no violation for %s with %v, got %v", tt.failOn, tt.counts, violations)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluate_MaxNew(t *testing.T) {
+	policy := Policy{}
+	if err := policy.AddMaxNew([]string{"high=1", "medium = 2", "any=4"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if violations := policy.Evaluate(map[string]int{"high": 1, "medium": 2, "low": 1}); len(violations) != 0 {
+		t.Errorf("Expected no violations at the limits, got %v", violations)
+	}
+
+	violations := policy.Evaluate(map[string]int{"high": 2, "medium": 2, "low": 1})
+	if len(violations) != 2 {
+		t.Fatalf("Expected high and any limits to be violated, got %v", violations)
+	}
+	if !strings.Contains(violations[0], "max-new any=4") || !strings.Contains(violations[1], "max-new high=1") {
+		t.Errorf("Unexpected violations: %v", violations)
+	}
+}
+
+func TestPolicyAddMaxNew_Invalid(t *testing.T) {
+	for _, rule := range []string{"high", "high=lots"} {
+		policy := Policy{}
+		if err := policy.AddMaxNew([]string{rule}); err == nil {
+			t.Errorf("Expected error for max-new rule %q", rule)
+		}
+	}
+}
+
+func TestPolicyValidate_Invalid(t *testing.T) {
+	policies := []Policy{
+		{FailOn: []string{"critical"}},
+		{FailOn: []string{"new-severe"}},
+		{MaxNew: map[string]int{"severe": 1}},
+		{MaxNew: map[string]int{"high": -1}},
+	}
+	for _, policy := range policies {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("Expected validation error for %+v", policy)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	content := `
+failOn:
+  - new-critical
+maxNew:
+  high: 0
+`
+	if err := os.WriteFile(policyPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := LoadPolicy(policyPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(policy.FailOn) != 1 || policy.FailOn[0] != "new-critical" {
+		t.Errorf("Unexpected failOn rules: %v", policy.FailOn)
+	}
+	if limit, ok := policy.MaxNew["high"]; !ok || limit != 0 {
+		t.Errorf("Unexpected maxNew rules: %v", policy.MaxNew)
+	}
+}
+
+func TestLoadPolicy_InvalidRule(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("failOn:\n  - critical\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadPolicy(policyPath); err == nil {
+		t.Fatal("Expected error for invalid failOn rule, got nil")
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// provenancePrefix namespaces the CycloneDX metadata properties the provenance of a scan is recorded in
+const provenancePrefix = "uds-pk:"
+
+// Provenance records what produced a scan, so that its results can be reproduced.
+type Provenance struct {
+	// ToolVersion is the version of uds-pk that ran the scan
+	ToolVersion  string     `json:"toolVersion,omitempty"`
+	GrypeVersion string     `json:"grypeVersion,omitempty"`
+	DBBuilt      *time.Time `json:"dbBuilt,omitempty"`
+	DBSchema     string     `json:"dbSchema,omitempty"`
+	ImageDigest  string     `json:"imageDigest,omitempty"`
+	// SBOMSource describes the SBOM that was scanned, empty when grype cataloged the image itself
+	SBOMSource string     `json:"sbomSource,omitempty"`
+	Timestamp  *time.Time `json:"timestamp,omitempty"`
+}
+
+func (p Provenance) properties() []cyclonedx.Property {
+	var properties []cyclonedx.Property
+	add := func(name string, value string) {
+		if value != "" {
+			properties = append(properties, cyclonedx.Property{Name: provenancePrefix + name, Value: value})
+		}
+	}
+	add("version", p.ToolVersion)
+	add("grype:version", p.GrypeVersion)
+	add("grype:db:built", formatTime(p.DBBuilt))
+	add("grype:db:schema", p.DBSchema)
+	add("image:digest", p.ImageDigest)
+	add("sbom:source", p.SBOMSource)
+	add("scan:timestamp", formatTime(p.Timestamp))
+	return properties
+}
+
+// ReadProvenance returns the provenance recorded in the metadata of a scan, nil when there is none.
+func ReadProvenance(scan cyclonedx.BOM) *Provenance {
+	if scan.Metadata == nil || scan.Metadata.Properties == nil {
+		return nil
+	}
+	var provenance Provenance
+	found := false
+	for _, property := range *scan.Metadata.Properties {
+		name, ok := strings.CutPrefix(property.Name, provenancePrefix)
+		if !ok {
+			continue
+		}
+		found = true
+		switch name {
+		case "version":
+			provenance.ToolVersion = property.Value
+		case "grype:version":
+			provenance.GrypeVersion = property.Value
+		case "grype:db:built":
+			provenance.DBBuilt = parseTime(property.Value)
+		case "grype:db:schema":
+			provenance.DBSchema = property.Value
+		case "image:digest":
+			provenance.ImageDigest = property.Value
+		case "sbom:source":
+			provenance.SBOMSource = property.Value
+		case "scan:timestamp":
+			provenance.Timestamp = parseTime(property.Value)
+		}
+	}
+	if !found {
+		return nil
+	}
+	return &provenance
+}
+
+// WriteProvenance records the provenance in the metadata properties of a scan file, replacing a previously
+// recorded one. Without an image digest, the digest grype recorded as the version of the scanned image is used.
+func WriteProvenance(scanPath string, provenance Provenance) error {
+	data, err := os.ReadFile(scanPath)
+	if err != nil {
+		return err
+	}
+	// the scan is edited as raw JSON, so that everything grype wrote is kept as it is
+	var scan map[string]json.RawMessage
+	if err := json.Unmarshal(data, &scan); err != nil {
+		return fmt.Errorf("failed to parse scan %s: %w", scanPath, err)
+	}
+	metadata := map[string]json.RawMessage{}
+	if raw, found := scan["metadata"]; found {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return fmt.Errorf("failed to parse the metadata of scan %s: %w", scanPath, err)
+		}
+	}
+	if provenance.ImageDigest == "" {
+		var component cyclonedx.Component
+		if err := json.Unmarshal(metadata["component"], &component); err == nil && isDigest(component.Version) {
+			provenance.ImageDigest = component.Version
+		}
+	}
+	var properties []cyclonedx.Property
+	if raw, found := metadata["properties"]; found {
+		var existing []cyclonedx.Property
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return fmt.Errorf("failed to parse the metadata properties of scan %s: %w", scanPath, err)
+		}
+		for _, property := range existing {
+			if !strings.HasPrefix(property.Name, provenancePrefix) {
+				properties = append(properties, property)
+			}
+		}
+	}
+	properties = append(properties, provenance.properties()...)
+
+	if metadata["properties"], err = json.Marshal(properties); err != nil {
+		return err
+	}
+	if scan["metadata"], err = json.Marshal(metadata); err != nil {
+		return err
+	}
+	output, err := marshalJSON(scan)
+	if err != nil {
+		return fmt.Errorf("failed to write scan %s: %w", scanPath, err)
+	}
+	return os.WriteFile(scanPath, []byte(output), 0o644)
+}
+
+// SBOMOrigin returns the tool that generated an SBOM, e.g. "syft 1.20.0", and the manifest digest of the image
+// it describes, when the SBOM records them.
+func SBOMOrigin(sbomPath string) (string, string, error) {
+	data, err := os.ReadFile(sbomPath)
+	if err != nil {
+		return "", "", err
+	}
+	var sbom struct {
+		Descriptor struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"descriptor"`
+		Source struct {
+			Metadata struct {
+				ManifestDigest string `json:"manifestDigest"`
+			} `json:"metadata"`
+		} `json:"source"`
+	}
+	if err := json.Unmarshal(data, &sbom); err != nil {
+		return "", "", fmt.Errorf("failed to parse SBOM %s: %w", sbomPath, err)
+	}
+	generator := strings.TrimSpace(sbom.Descriptor.Name + " " + sbom.Descriptor.Version)
+	return generator, sbom.Source.Metadata.ManifestDigest, nil
+}
+
+func isDigest(value string) bool {
+	return strings.HasPrefix(value, "sha256:") || strings.HasPrefix(value, "sha512:")
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseTime(value string) *time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// renderProvenanceMarkdown renders the footer of a report with what produced each scan, empty when no scan
+// recorded its provenance
+func renderProvenanceMarkdown(report Report) (string, error) {
+	var toolVersions []string
+	rows := [][]string{}
+	seen := map[string]bool{}
+	for _, comparison := range report.Comparisons {
+		sides := []struct {
+			image      Image
+			provenance *Provenance
+		}{{comparison.BaseImage, comparison.BaseProvenance}, {comparison.NewImage, comparison.NewProvenance}}
+		if comparison.NoBaseline {
+			sides = sides[1:]
+		}
+		for _, side := range sides {
+			provenance := side.provenance
+			if provenance == nil {
+				continue
+			}
+			if provenance.ToolVersion != "" {
+				toolVersions = appendUnique(toolVersions, provenance.ToolVersion)
+			}
+			source := provenance.SBOMSource
+			if source == "" {
+				source = "image"
+			}
+			database := formatTime(provenance.DBBuilt)
+			if provenance.DBSchema != "" {
+				database = strings.TrimSpace(database + " (schema " + provenance.DBSchema + ")")
+			}
+			row := []string{
+				fmt.Sprintf("`%s:%s`", side.image.Name, side.image.Version),
+				provenance.ImageDigest,
+				source,
+				provenance.GrypeVersion,
+				database,
+				formatTime(provenance.Timestamp),
+			}
+			if key := strings.Join(row, "|"); !seen[key] {
+				seen[key] = true
+				rows = append(rows, row)
+			}
+		}
+	}
+	if len(rows) == 0 && report.DatabaseBuilt == nil {
+		return "", nil
+	}
+
+	var outputBuilder strings.Builder
+	outputBuilder.WriteString("\n\n---\n\n#### Provenance\n\n")
+	if len(toolVersions) > 0 {
+		fmt.Fprintf(&outputBuilder, "Generated by uds-pk %s\n\n", strings.Join(toolVersions, ", "))
+	}
+	if report.DatabaseBuilt != nil {
+		fmt.Fprintf(&outputBuilder, "Vulnerability database built: %s\n\n", formatTime(report.DatabaseBuilt))
+	}
+	if len(rows) > 0 {
+		var tableString strings.Builder
+		table := newMarkdownTable(&tableString)
+		table.Header([]string{"Image", "Digest", "Scanned from", "Grype", "Database built", "Scanned at"})
+		if err := table.Bulk(rows); err != nil {
+			return "", err
+		}
+		if err := table.Render(); err != nil {
+			return "", err
+		}
+		outputBuilder.WriteString(tableString.String())
+	}
+	return strings.TrimSuffix(outputBuilder.String(), "\n\n") + "\n", nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteProvenance(t *testing.T) {
+	scanPath := writeSBOM(t, `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.6",
+  "metadata": {
+    "component": {"name": "registry1.dso.mil/ironbank/app", "version": "sha256:abc123"},
+    "properties": [{"name": "syft:image:labels", "value": "x"}, {"name": "uds-pk:version", "value": "0.1.0"}]
+  },
+  "vulnerabilities": [{"id": "CVE-2024-0001", "bom-ref": "urn:1"}]
+}`)
+	built := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := WriteProvenance(scanPath, Provenance{ToolVersion: "1.2.3", GrypeVersion: "0.99.0", DBBuilt: &built, DBSchema: "v6.0.2"}); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	scan, err := loadScanJson(scanPath)
+	if err != nil {
+		t.Fatalf("Expected the scan to stay valid, got: %v", err)
+	}
+	if len(*scan.Vulnerabilities) != 1 || len(*scan.Metadata.Properties) != 6 || (*scan.Metadata.Properties)[0].Name != "syft:image:labels" {
+		t.Errorf("Expected the scan to be kept and the previous provenance to be replaced, got %+v", scan.Metadata.Properties)
+	}
+	provenance := ReadProvenance(scan)
+	if provenance == nil || provenance.ToolVersion != "1.2.3" || provenance.ImageDigest != "sha256:abc123" || !provenance.DBBuilt.Equal(built) {
+		t.Errorf("Unexpected provenance %+v", provenance)
+	}
+}
+
+func TestRenderProvenanceMarkdown(t *testing.T) {
+	scanned := time.Date(2026, 2, 3, 4, 5, 6, 0, time.UTC)
+	report := Report{Comparisons: []Comparison{{
+		BaseImage:      Image{Name: "app", Version: "1.0.0"},
+		NewImage:       Image{Name: "app", Version: "1.1.0"},
+		BaseProvenance: &Provenance{ToolVersion: "1.2.3", GrypeVersion: "0.99.0", SBOMSource: "released package", Timestamp: &scanned},
+		NewProvenance:  &Provenance{ToolVersion: "1.2.3", GrypeVersion: "0.99.0", ImageDigest: "sha256:abc123", Timestamp: &scanned},
+	}}}
+	footer, err := renderProvenanceMarkdown(report)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	for _, expected := range []string{
+		"#### Provenance\n\nGenerated by uds-pk 1.2.3\n",
+		"| `app:1.0.0` |               | released package | 0.99.0 |",
+		"| `app:1.1.0` | sha256:abc123 | image            | 0.99.0 |",
+	} {
+		if !strings.Contains(footer, expected) {
+			t.Errorf("Expected %q in the footer, got:\n%s", expected, footer)
+		}
+	}
+
+	if footer, _ := renderProvenanceMarkdown(Report{Comparisons: []Comparison{{NewImage: Image{Name: "app"}}}}); footer != "" {
+		t.Errorf("Expected no footer without provenance, got:\n%s", footer)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Report is the outcome of comparing the scans of one or more images.
+type Report struct {
+	// Name of the package the images belong to, if any
+	Name        string
+	Comparisons []Comparison
+	// Violations of the vulnerability policy, which decide the verdict of the report
+	Violations []string
+	// Consolidated reports start with a summary across all compared images
+	Consolidated bool
+	// DatabaseBuilt is when the vulnerability database of the scans was built, when it is known
+	DatabaseBuilt *time.Time
+}
+
+// Renderer turns a report into a specific output format.
+type Renderer interface {
+	Render(report Report) (string, error)
+}
+
+const (
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+	FormatSARIF    = "sarif"
+	FormatJUnit    = "junit"
+)
+
+// Formats lists the supported output formats.
+var Formats = []string{FormatMarkdown, FormatJSON, FormatSARIF, FormatJUnit}
+
+// NewRenderer returns the renderer for the given output format.
+func NewRenderer(format string) (Renderer, error) {
+	switch strings.ToLower(format) {
+	case FormatMarkdown, "":
+		return MarkdownRenderer{}, nil
+	case FormatJSON:
+		return JSONRenderer{}, nil
+	case FormatSARIF:
+		return SARIFRenderer{}, nil
+	case FormatJUnit:
+		return JUnitRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+type jsonReport struct {
+	Name        string       `json:"name,omitempty"`
+	Comparisons []Comparison `json:"comparisons"`
+	Violations  []string     `json:"violations,omitempty"`
+	// DatabaseBuilt is when the vulnerability database of the scans was built
+	DatabaseBuilt *time.Time `json:"databaseBuilt,omitempty"`
+}
+
+// JSONRenderer renders comparisons as a JSON document with the new, fixed and existing vulnerabilities of each image.
+type JSONRenderer struct{}
+
+func (JSONRenderer) Render(report Report) (string, error) {
+	comparisons := report.Comparisons
+	if comparisons == nil {
+		comparisons = []Comparison{}
+	}
+	return marshalJSON(jsonReport{Name: report.Name, Comparisons: comparisons, Violations: report.Violations, DatabaseBuilt: report.DatabaseBuilt})
+}
+
+// marshalJSON indents the document and keeps characters like & in package URLs readable.
+func marshalJSON(v any) (string, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
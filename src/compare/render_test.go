@@ -0,0 +1,169 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+func testComparison(t *testing.T) Comparison {
+	t.Helper()
+	vulnStatus := GenerateComparisonMap(commonBaseScan, commonNewBaseScan)
+	comparison, err := NewComparison(commonBaseScan, commonNewBaseScan, vulnStatus, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	return comparison
+}
+
+func TestNewRenderer(t *testing.T) {
+	for _, format := range append(Formats, "", "SARIF") {
+		if _, err := NewRenderer(format); err != nil {
+			t.Errorf("Expected renderer for format %q, got error: %v", format, err)
+		}
+	}
+	if _, err := NewRenderer("html"); err == nil {
+		t.Error("Expected error for unsupported format, got nil")
+	}
+}
+
+func TestMarkdownRenderer_NoComparisons(t *testing.T) {
+	output, err := MarkdownRenderer{}.Render(Report{Consolidated: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if output != "" {
+		t.Errorf("Expected empty output, got %q", output)
+	}
+}
+
+func TestJSONRenderer(t *testing.T) {
+	output, err := JSONRenderer{}.Render(Report{Comparisons: []Comparison{testComparison(t)}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var report jsonReport
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("Expected valid JSON, got: %v", err)
+	}
+	if len(report.Comparisons) != 1 {
+		t.Fatalf("Expected 1 comparison, got %d", len(report.Comparisons))
+	}
+	comparison := report.Comparisons[0]
+	if len(comparison.New) != 1 || comparison.New[0].ID != "VULN-NEW" || comparison.New[0].Package != "pkgNew" {
+		t.Errorf("Unexpected new vulnerabilities %+v", comparison.New)
+	}
+	if len(comparison.Fixed) != 1 || len(comparison.Existing) != 1 {
+		t.Errorf("Expected 1 fixed and 1 existing vulnerability, got %+v", comparison)
+	}
+}
+
+func TestSARIFRenderer(t *testing.T) {
+	output, err := SARIFRenderer{}.Render(Report{Comparisons: []Comparison{testComparison(t)}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(output), &log); err != nil {
+		t.Fatalf("Expected valid JSON, got: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Unexpected SARIF document %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 1 || len(run.Tool.Driver.Rules) != 1 {
+		t.Fatalf("Expected only the new vulnerability to be reported, got %+v", run)
+	}
+	result := run.Results[0]
+	if result.RuleID != "VULN-NEW" || result.Level != "note" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if uri := result.Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "BaseComponent" {
+		t.Errorf("Expected the image as location, got %q", uri)
+	}
+	if severity := run.Tool.Driver.Rules[0].Properties.SecuritySeverity; severity != "2.0" {
+		t.Errorf("Expected low security severity, got %q", severity)
+	}
+}
+
+func TestJUnitRenderer(t *testing.T) {
+	noNew := testComparison(t)
+	noNew.New = nil
+	output, err := JUnitRenderer{}.Render(Report{Comparisons: []Comparison{testComparison(t), noNew}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.HasPrefix(output, xml.Header) {
+		t.Errorf("Expected XML header, got %q", output)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal([]byte(output), &suites); err != nil {
+		t.Fatalf("Expected valid XML, got: %v", err)
+	}
+	if suites.Tests != 2 || suites.Failures != 1 || len(suites.Suites) != 2 {
+		t.Fatalf("Unexpected test suites %+v", suites)
+	}
+	failed := suites.Suites[0].TestCases[0]
+	if failed.Failure == nil || failed.Name != "VULN-NEW in pkgNew" {
+		t.Errorf("Expected a failed test case for the new vulnerability, got %+v", failed)
+	}
+	if passed := suites.Suites[1].TestCases[0]; passed.Failure != nil {
+		t.Errorf("Expected a passing test case without new vulnerabilities, got %+v", passed)
+	}
+}
+
+func TestVulnerabilityEntryPackageDetails(t *testing.T) {
+	vuln := cyclonedx.Vulnerability{
+		ID:             "CVE-2022-48174",
+		Affects:        &[]cyclonedx.Affects{{Ref: "pkg:apk/alpine/busybox@1.35.0-r31?arch=x86_64&distro=alpine-3.17.10"}},
+		Recommendation: "Upgrade busybox to 1.36.1-r0.",
+	}
+	entry := newVulnerabilityEntry(vuln, nil)
+	if entry.Package != "busybox" || entry.InstalledVersion != "1.35.0-r31" || entry.FixedVersion != "1.36.1-r0" {
+		t.Errorf("Unexpected package details %+v", entry)
+	}
+	if entry.Severity != "unknown" {
+		t.Errorf("Expected unknown severity without ratings, got %q", entry.Severity)
+	}
+
+	vuln = cyclonedx.Vulnerability{
+		ID: "GHSA-1234",
+		Affects: &[]cyclonedx.Affects{{
+			Ref: "pkg:golang/github.com/example/lib@v1.2.3",
+			Range: &[]cyclonedx.AffectedVersions{
+				{Version: "v1.2.3", Status: cyclonedx.VulnerabilityStatusAffected},
+				{Version: "v1.2.4", Status: cyclonedx.VulnerabilityStatusNotAffected},
+			},
+		}},
+	}
+	entry = newVulnerabilityEntry(vuln, nil)
+	if entry.Package != "github.com/example/lib" || entry.InstalledVersion != "v1.2.3" || entry.FixedVersion != "v1.2.4" {
+		t.Errorf("Unexpected package details %+v", entry)
+	}
+}
+
+func TestMarkdownRenderer_Architecture(t *testing.T) {
+	comparison := testComparison(t)
+	comparison.Flavor = "registry1"
+	comparison.Arch = "arm64"
+	if variant := comparison.Variant(); variant != "registry1, arm64" {
+		t.Errorf("Expected variant %q, got %q", "registry1, arm64", variant)
+	}
+
+	output, err := MarkdownRenderer{}.Render(Report{Comparisons: []Comparison{comparison}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(output, "Architecture: arm64\n") {
+		t.Errorf("Expected the architecture in the output, got: %s", output)
+	}
+}
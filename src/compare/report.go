@@ -0,0 +1,292 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+// Image identifies the scanned image of one side of a comparison.
+type Image struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// VulnerabilityEntry is a single vulnerability of a package as shown in comparison reports.
+type VulnerabilityEntry struct {
+	ID               string  `json:"id"`
+	Severity         string  `json:"severity"`
+	Package          string  `json:"package"`
+	PURL             string  `json:"purl,omitempty"`
+	InstalledVersion string  `json:"installedVersion,omitempty"`
+	FixedVersion     string  `json:"fixedVersion,omitempty"`
+	Score            float64 `json:"score,omitempty"`
+	URL              string  `json:"url,omitempty"`
+	Description      string  `json:"description,omitempty"`
+}
+
+// SuppressedEntry is a vulnerability that was left out of the comparison by a VEX statement or ignore entry.
+type SuppressedEntry struct {
+	VulnerabilityEntry
+	Justification string     `json:"justification,omitempty"`
+	Expires       *time.Time `json:"expires,omitempty"`
+	Source        string     `json:"source,omitempty"`
+}
+
+// Comparison holds the result of comparing a base scan with a new scan.
+type Comparison struct {
+	BaseImage  Image                `json:"baseImage"`
+	NewImage   Image                `json:"newImage"`
+	Flavor     string               `json:"flavor,omitempty"`
+	Arch       string               `json:"arch,omitempty"`
+	NoBaseline bool                 `json:"noBaseline,omitempty"`
+	New        []VulnerabilityEntry `json:"new"`
+	Fixed      []VulnerabilityEntry `json:"fixed"`
+	Existing   []VulnerabilityEntry `json:"existing"`
+	Suppressed []SuppressedEntry    `json:"suppressed,omitempty"`
+	// Components are the component changes between the SBOMs of the images, when they were compared
+	Components *InventoryDiff `json:"components,omitempty"`
+	// BaseProvenance and NewProvenance record what produced the scans, when it was recorded in them
+	BaseProvenance *Provenance `json:"baseProvenance,omitempty"`
+	NewProvenance  *Provenance `json:"newProvenance,omitempty"`
+}
+
+// Variant describes the flavor and architecture the comparison was made for, e.g. "registry1, arm64".
+func (c Comparison) Variant() string {
+	var parts []string
+	for _, part := range []string{c.Flavor, c.Arch} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// NewComparison sorts the vulnerabilities of both scans into new, fixed and existing entries.
+func NewComparison(baseScan cyclonedx.BOM, newScan cyclonedx.BOM, vulnStatus map[string]int, suppressed []SuppressedVulnerability) (Comparison, error) {
+	comparison := Comparison{
+		BaseImage:      scanImage(baseScan),
+		NewImage:       scanImage(newScan),
+		New:            []VulnerabilityEntry{},
+		Fixed:          []VulnerabilityEntry{},
+		Existing:       []VulnerabilityEntry{},
+		BaseProvenance: ReadProvenance(baseScan),
+		NewProvenance:  ReadProvenance(newScan),
+	}
+
+	baseComponents := indexComponents(baseScan)
+	newComponents := indexComponents(newScan)
+
+	for vulnUID, status := range vulnStatus {
+		// fixed vulnerabilities are only in the base scan, the others are shown as found in the new scan
+		scan, components := newScan, newComponents
+		if status == 2 {
+			scan, components = baseScan, baseComponents
+		}
+		vuln, err := getVulnByUID(vulnUID, vulnerabilities(scan))
+		if err != nil {
+			return comparison, err
+		}
+		entry := newVulnerabilityEntry(vuln, components)
+		switch status {
+		case 0:
+			comparison.New = append(comparison.New, entry)
+		case 1:
+			comparison.Existing = append(comparison.Existing, entry)
+		case 2:
+			comparison.Fixed = append(comparison.Fixed, entry)
+		}
+	}
+	sortEntries(comparison.New)
+	sortEntries(comparison.Fixed)
+	sortEntries(comparison.Existing)
+
+	allComponents := map[string]cyclonedx.Component{}
+	for ref, component := range baseComponents {
+		allComponents[ref] = component
+	}
+	for ref, component := range newComponents {
+		allComponents[ref] = component
+	}
+	seen := map[string]bool{}
+	for _, s := range suppressed {
+		vulnUID := getUniqueVulnId(s.Vulnerability)
+		if seen[vulnUID] {
+			continue
+		}
+		seen[vulnUID] = true
+		comparison.Suppressed = append(comparison.Suppressed, SuppressedEntry{
+			VulnerabilityEntry: newVulnerabilityEntry(s.Vulnerability, allComponents),
+			Justification:      s.Suppression.Justification,
+			Expires:            s.Suppression.Expires,
+			Source:             s.Suppression.Source,
+		})
+	}
+	sort.SliceStable(comparison.Suppressed, func(i, j int) bool {
+		return entryLess(comparison.Suppressed[i].VulnerabilityEntry, comparison.Suppressed[j].VulnerabilityEntry)
+	})
+
+	return comparison, nil
+}
+
+// newVulnerabilityEntry resolves the affected package of a vulnerability against the components of the scan.
+// When the reference is not a component of the scan, the package details are taken from the reference itself.
+func newVulnerabilityEntry(vuln cyclonedx.Vulnerability, components map[string]cyclonedx.Component) VulnerabilityEntry {
+	ref := affectedRef(vuln)
+	entry := VulnerabilityEntry{
+		ID:               vuln.ID,
+		Severity:         vulnSeverity(vuln),
+		Package:          packageName(ref),
+		PURL:             ref,
+		InstalledVersion: packageVersion(ref),
+		FixedVersion:     fixedVersion(vuln),
+		Score:            vulnScore(vuln),
+		URL:              vulnURL(vuln),
+		Description:      vuln.Description,
+	}
+	if component, ok := components[ref]; ok {
+		if component.Name != "" {
+			entry.Package = component.Name
+		}
+		if component.Version != "" {
+			entry.InstalledVersion = component.Version
+		}
+		if component.PackageURL != "" {
+			entry.PURL = component.PackageURL
+		}
+	}
+	return entry
+}
+
+func scanImage(scan cyclonedx.BOM) Image {
+	if scan.Metadata == nil || scan.Metadata.Component == nil {
+		return Image{}
+	}
+	return Image{Name: scan.Metadata.Component.Name, Version: scan.Metadata.Component.Version}
+}
+
+// indexComponents maps the bom-ref of every component in the scan, including nested ones, to the component.
+func indexComponents(scan cyclonedx.BOM) map[string]cyclonedx.Component {
+	index := map[string]cyclonedx.Component{}
+	var add func(components *[]cyclonedx.Component)
+	add = func(components *[]cyclonedx.Component) {
+		if components == nil {
+			return
+		}
+		for _, component := range *components {
+			if component.BOMRef != "" {
+				index[component.BOMRef] = component
+			}
+			add(component.Components)
+		}
+	}
+	add(scan.Components)
+	return index
+}
+
+func sortEntries(entries []VulnerabilityEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entryLess(entries[i], entries[j])
+	})
+}
+
+func entryLess(a VulnerabilityEntry, b VulnerabilityEntry) bool {
+	if severityRank(a.Severity) != severityRank(b.Severity) {
+		return severityRank(a.Severity) < severityRank(b.Severity)
+	}
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.ID != b.ID {
+		return a.ID < b.ID
+	}
+	return a.PURL < b.PURL
+}
+
+func vulnURL(vuln cyclonedx.Vulnerability) string {
+	if vuln.Source != nil {
+		if parsedUrl, err := url.Parse(vuln.Source.URL); err == nil && parsedUrl.Scheme != "" {
+			return vuln.Source.URL
+		}
+	}
+	if vuln.Advisories != nil && len(*vuln.Advisories) > 0 {
+		return (*vuln.Advisories)[0].URL
+	}
+	return ""
+}
+
+// osPackageTypes are package URL types whose namespace is the distribution rather than part of the package name
+var osPackageTypes = map[string]bool{"apk": true, "deb": true, "rpm": true, "alpm": true}
+
+// packageName returns the name of the package of a package URL, e.g. busybox for pkg:apk/alpine/busybox@1.36.1-r0.
+func packageName(purl string) string {
+	name := packageWithoutVersion(purl)
+	if !strings.HasPrefix(name, "pkg:") {
+		return name
+	}
+	purlType, path, _ := strings.Cut(strings.TrimPrefix(name, "pkg:"), "/")
+	if osPackageTypes[purlType] {
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+	return path
+}
+
+// packageVersion returns the version of a package URL, or an empty string if it has none.
+func packageVersion(purl string) string {
+	_, version := splitPackageVersion(purl)
+	return unescapePath(version)
+}
+
+// vulnScore returns the highest score among the ratings of the vulnerability, or 0 if none is scored.
+func vulnScore(vuln cyclonedx.Vulnerability) float64 {
+	score := 0.0
+	if vuln.Ratings == nil {
+		return score
+	}
+	for _, rating := range *vuln.Ratings {
+		if rating.Score != nil && *rating.Score > score {
+			score = *rating.Score
+		}
+	}
+	return score
+}
+
+var upgradeRecommendation = regexp.MustCompile(`(?i)upgrade .*?to (?:version )?([^\s,;]+)`)
+
+// fixedVersion returns the version that fixes the vulnerability, taken from the unaffected versions
+// of the affected package, a fix version property or an upgrade recommendation.
+func fixedVersion(vuln cyclonedx.Vulnerability) string {
+	if vuln.Affects != nil {
+		for _, affects := range *vuln.Affects {
+			if affects.Range == nil {
+				continue
+			}
+			for _, version := range *affects.Range {
+				if version.Status == cyclonedx.VulnerabilityStatusNotAffected && version.Version != "" {
+					return version.Version
+				}
+			}
+		}
+	}
+	if vuln.Properties != nil {
+		for _, property := range *vuln.Properties {
+			name := strings.ToLower(property.Name)
+			if strings.Contains(name, "fix") && strings.Contains(name, "version") && property.Value != "" {
+				// multiple fix versions are listed comma separated, the first one is the closest upgrade
+				version, _, _ := strings.Cut(property.Value, ",")
+				return strings.TrimSpace(version)
+			}
+		}
+	}
+	if match := upgradeRecommendation.FindStringSubmatch(vuln.Recommendation); match != nil {
+		return strings.TrimSuffix(match[1], ".")
+	}
+	return ""
+}
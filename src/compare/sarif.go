@@ -0,0 +1,167 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"fmt"
+)
+
+const (
+	sarifSchema        = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion       = "2.1.0"
+	toolName           = "uds-pk"
+	toolInformationURI = "https://github.com/defenseunicorns/uds-pk"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string              `json:"id"`
+	ShortDescription sarifMessage        `json:"shortDescription"`
+	FullDescription  *sarifMessage       `json:"fullDescription,omitempty"`
+	HelpURI          string              `json:"helpUri,omitempty"`
+	Properties       sarifRuleProperties `json:"properties"`
+}
+
+type sarifRuleProperties struct {
+	SecuritySeverity string   `json:"security-severity"`
+	Tags             []string `json:"tags"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFRenderer renders the new vulnerabilities of each comparison as SARIF results so that
+// they show up in GitHub code scanning. Fixed and existing vulnerabilities are not reported.
+type SARIFRenderer struct{}
+
+func (SARIFRenderer) Render(report Report) (string, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           toolName,
+			InformationURI: toolInformationURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndex := map[string]int{}
+
+	for _, comparison := range report.Comparisons {
+		for _, entry := range comparison.New {
+			index, ok := ruleIndex[entry.ID]
+			if !ok {
+				index = len(run.Tool.Driver.Rules)
+				ruleIndex[entry.ID] = index
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, newSARIFRule(entry))
+			}
+			run.Results = append(run.Results, sarifResult{
+				RuleID:    entry.ID,
+				RuleIndex: index,
+				Level:     sarifLevel(entry.Severity),
+				Message:   sarifMessage{Text: sarifResultMessage(comparison, entry)},
+				Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: comparison.NewImage.Name},
+				}}},
+			})
+		}
+	}
+
+	return marshalJSON(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+func newSARIFRule(entry VulnerabilityEntry) sarifRule {
+	rule := sarifRule{
+		ID:               entry.ID,
+		ShortDescription: sarifMessage{Text: fmt.Sprintf("%s (%s)", entry.ID, entry.Severity)},
+		HelpURI:          entry.URL,
+		Properties: sarifRuleProperties{
+			SecuritySeverity: sarifSecuritySeverity(entry),
+			Tags:             []string{"security", "vulnerability", entry.Severity},
+		},
+	}
+	if entry.Description != "" {
+		rule.FullDescription = &sarifMessage{Text: entry.Description}
+	}
+	return rule
+}
+
+func sarifResultMessage(comparison Comparison, entry VulnerabilityEntry) string {
+	message := fmt.Sprintf("New %s vulnerability %s in %s %s of image %s:%s",
+		entry.Severity, entry.ID, entry.Package, entry.InstalledVersion, comparison.NewImage.Name, comparison.NewImage.Version)
+	if entry.FixedVersion != "" {
+		message += fmt.Sprintf(", fixed in %s", entry.FixedVersion)
+	}
+	return message
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifSecuritySeverity returns the CVSS score GitHub uses to classify security alerts. Without a
+// score, the severity is mapped into the matching score range.
+func sarifSecuritySeverity(entry VulnerabilityEntry) string {
+	if entry.Score > 0 {
+		return formatScore(entry.Score)
+	}
+	switch entry.Severity {
+	case "critical":
+		return "9.5"
+	case "high":
+		return "8.0"
+	case "medium":
+		return "5.5"
+	case "low":
+		return "2.0"
+	default:
+		return "0.0"
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// affectedCVE is a vulnerability of the new scans with all the images and packages it affects.
+type affectedCVE struct {
+	ID       string
+	Severity string
+	Score    float64
+	New      bool
+	Packages []string
+	Images   []string
+}
+
+// renderSummaryMarkdown renders the verdict, a summary per image and the deduplicated list of
+// vulnerabilities across all compared images.
+func renderSummaryMarkdown(report Report) (string, error) {
+	var outputBuilder strings.Builder
+
+	if report.Name != "" {
+		fmt.Fprintf(&outputBuilder, "## Vulnerability report: %s\n\n", report.Name)
+	} else {
+		outputBuilder.WriteString("## Vulnerability report\n\n")
+	}
+	outputBuilder.WriteString(verdict(report))
+	outputBuilder.WriteString("\n")
+
+	summaryTableString := &strings.Builder{}
+	summaryTable := newMarkdownTable(summaryTableString)
+	summaryTable.Header([]string{"Flavor", "Image", "New", "Fixed", "Existing"})
+
+	var newEntries, fixedEntries, existingEntries []VulnerabilityEntry
+	rows := [][]string{}
+	for _, comparison := range report.Comparisons {
+		rows = append(rows, []string{
+			comparison.Variant(),
+			imageTransition(comparison),
+			formatSeverityCounts(comparison.New),
+			formatSeverityCounts(comparison.Fixed),
+			formatSeverityCounts(comparison.Existing),
+		})
+		newEntries = append(newEntries, comparison.New...)
+		fixedEntries = append(fixedEntries, comparison.Fixed...)
+		existingEntries = append(existingEntries, comparison.Existing...)
+	}
+	rows = append(rows, []string{
+		"**Total**",
+		"",
+		formatSeverityCounts(newEntries),
+		formatSeverityCounts(fixedEntries),
+		formatSeverityCounts(existingEntries),
+	})
+	if err := summaryTable.Bulk(rows); err != nil {
+		return "", err
+	}
+	if err := summaryTable.Render(); err != nil {
+		return "", err
+	}
+	outputBuilder.WriteString("### Summary\n\n")
+	outputBuilder.WriteString(summaryTableString.String())
+	outputBuilder.WriteString("\n")
+
+	cves := aggregateCVEs(report.Comparisons)
+	cveTableString := &strings.Builder{}
+	cveTable := newMarkdownTable(cveTableString)
+	cveTable.Header([]string{"ID", "Severity", "Score", "Status", "Packages", "Images"})
+	cveRows := make([][]string, 0, len(cves))
+	for _, cve := range cves {
+		status := "existing"
+		if cve.New {
+			status = "new"
+		}
+		cveRows = append(cveRows, []string{
+			cve.ID,
+			cve.Severity,
+			formatScore(cve.Score),
+			status,
+			strings.Join(cve.Packages, ", "),
+			strings.Join(cve.Images, ", "),
+		})
+	}
+	if err := cveTable.Bulk(cveRows); err != nil {
+		return "", err
+	}
+	if err := cveTable.Render(); err != nil {
+		return "", err
+	}
+	outputBuilder.WriteString("<details>\n")
+	fmt.Fprintf(&outputBuilder, "<summary>Vulnerabilities across all images (%d)</summary>\n\n", len(cves))
+	outputBuilder.WriteString(cveTableString.String())
+	outputBuilder.WriteString("\n</details>\n\n")
+
+	outputBuilder.WriteString("## Images\n\n")
+
+	return outputBuilder.String(), nil
+}
+
+func verdict(report Report) string {
+	if len(report.Violations) > 0 {
+		var verdictBuilder strings.Builder
+		verdictBuilder.WriteString("**Verdict:** :x: The vulnerability policy was violated\n\n")
+		for _, violation := range report.Violations {
+			fmt.Fprintf(&verdictBuilder, "- %s\n", violation)
+		}
+		return verdictBuilder.String()
+	}
+	var newEntries []VulnerabilityEntry
+	for _, comparison := range report.Comparisons {
+		newEntries = append(newEntries, comparison.New...)
+	}
+	if len(newEntries) == 0 {
+		return "**Verdict:** :white_check_mark: No new vulnerabilities\n"
+	}
+	return fmt.Sprintf("**Verdict:** :warning: %s new vulnerabilities\n", formatSeverityCounts(newEntries))
+}
+
+func imageTransition(comparison Comparison) string {
+	if comparison.NoBaseline {
+		return fmt.Sprintf("%s `%s` (no released scan)", comparison.NewImage.Name, comparison.NewImage.Version)
+	}
+	if comparison.BaseImage.Name == comparison.NewImage.Name {
+		return fmt.Sprintf("%s `%s` -> `%s`", comparison.NewImage.Name, comparison.BaseImage.Version, comparison.NewImage.Version)
+	}
+	return fmt.Sprintf("`%s:%s` -> `%s:%s`", comparison.BaseImage.Name, comparison.BaseImage.Version, comparison.NewImage.Name, comparison.NewImage.Version)
+}
+
+// formatSeverityCounts formats the number of entries with a breakdown per severity, e.g. "5 (1 critical, 4 medium)".
+func formatSeverityCounts(entries []VulnerabilityEntry) string {
+	if len(entries) == 0 {
+		return "0"
+	}
+	counts := map[string]int{}
+	for _, entry := range entries {
+		counts[entry.Severity]++
+	}
+	severities := make([]string, 0, len(counts))
+	for severity := range counts {
+		severities = append(severities, severity)
+	}
+	sort.Slice(severities, func(i, j int) bool {
+		if severityRank(severities[i]) != severityRank(severities[j]) {
+			return severityRank(severities[i]) < severityRank(severities[j])
+		}
+		return severities[i] < severities[j]
+	})
+	parts := make([]string, 0, len(severities))
+	for _, severity := range severities {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+	}
+	return fmt.Sprintf("%d (%s)", len(entries), strings.Join(parts, ", "))
+}
+
+// aggregateCVEs deduplicates the new and existing vulnerabilities of all comparisons by ID.
+func aggregateCVEs(comparisons []Comparison) []*affectedCVE {
+	byID := map[string]*affectedCVE{}
+	var cves []*affectedCVE
+	add := func(comparison Comparison, entry VulnerabilityEntry, isNew bool) {
+		cve, ok := byID[entry.ID]
+		if !ok {
+			cve = &affectedCVE{ID: entry.ID, Severity: entry.Severity}
+			byID[entry.ID] = cve
+			cves = append(cves, cve)
+		}
+		if severityRank(entry.Severity) < severityRank(cve.Severity) {
+			cve.Severity = entry.Severity
+		}
+		if entry.Score > cve.Score {
+			cve.Score = entry.Score
+		}
+		cve.New = cve.New || isNew
+		cve.Packages = appendUnique(cve.Packages, entry.Package)
+		image := comparison.NewImage.Name
+		if variant := comparison.Variant(); variant != "" {
+			image = fmt.Sprintf("%s (%s)", image, variant)
+		}
+		cve.Images = appendUnique(cve.Images, image)
+	}
+	for _, comparison := range comparisons {
+		for _, entry := range comparison.New {
+			add(comparison, entry, true)
+		}
+		for _, entry := range comparison.Existing {
+			add(comparison, entry, false)
+		}
+	}
+
+	sort.SliceStable(cves, func(i, j int) bool {
+		return entryLess(
+			VulnerabilityEntry{ID: cves[i].ID, Severity: cves[i].Severity, Score: cves[i].Score},
+			VulnerabilityEntry{ID: cves[j].ID, Severity: cves[j].Severity, Score: cves[j].Score},
+		)
+	})
+	return cves
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"strings"
+	"testing"
+)
+
+func consolidatedTestReport() Report {
+	return Report{
+		Name: "example",
+		Comparisons: []Comparison{
+			{
+				BaseImage: Image{Name: "app", Version: "1.0"},
+				NewImage:  Image{Name: "app", Version: "1.1"},
+				Flavor:    "registry1",
+				New: []VulnerabilityEntry{
+					{ID: "CVE-1", Severity: "critical", Score: 9.8, Package: "openssl"},
+					{ID: "CVE-2", Severity: "medium", Score: 5.0, Package: "zlib"},
+				},
+				Fixed:    []VulnerabilityEntry{{ID: "CVE-3", Severity: "high", Package: "curl"}},
+				Existing: []VulnerabilityEntry{{ID: "CVE-4", Severity: "low", Package: "musl"}},
+			},
+			{
+				BaseImage: Image{Name: "app", Version: "1.0"},
+				NewImage:  Image{Name: "app", Version: "1.1"},
+				Flavor:    "upstream",
+				New:       []VulnerabilityEntry{{ID: "CVE-1", Severity: "critical", Score: 9.8, Package: "libssl"}},
+				Fixed:     []VulnerabilityEntry{},
+				Existing:  []VulnerabilityEntry{{ID: "CVE-2", Severity: "medium", Score: 5.0, Package: "zlib"}},
+			},
+		},
+		Consolidated: true,
+	}
+}
+
+func TestFormatSeverityCounts(t *testing.T) {
+	entries := []VulnerabilityEntry{{Severity: "medium"}, {Severity: "critical"}, {Severity: "medium"}, {Severity: "negligible"}}
+	if counts := formatSeverityCounts(entries); counts != "4 (1 critical, 2 medium, 1 negligible)" {
+		t.Errorf("Unexpected counts %q", counts)
+	}
+	if counts := formatSeverityCounts(nil); counts != "0" {
+		t.Errorf("Expected 0 without entries, got %q", counts)
+	}
+}
+
+func TestAggregateCVEs(t *testing.T) {
+	cves := aggregateCVEs(consolidatedTestReport().Comparisons)
+
+	if len(cves) != 3 {
+		t.Fatalf("Expected 3 deduplicated vulnerabilities, got %d", len(cves))
+	}
+	first := cves[0]
+	if first.ID != "CVE-1" || !first.New {
+		t.Errorf("Expected CVE-1 first and new, got %+v", first)
+	}
+	if strings.Join(first.Packages, ",") != "openssl,libssl" {
+		t.Errorf("Unexpected packages %v", first.Packages)
+	}
+	if strings.Join(first.Images, ",") != "app (registry1),app (upstream)" {
+		t.Errorf("Unexpected images %v", first.Images)
+	}
+	if cves[1].ID != "CVE-2" || !cves[1].New || len(cves[1].Images) != 2 {
+		t.Errorf("Expected CVE-2 to be new in one image and existing in the other, got %+v", cves[1])
+	}
+	if cves[2].ID != "CVE-4" || cves[2].New {
+		t.Errorf("Expected CVE-4 to be existing, got %+v", cves[2])
+	}
+}
+
+func TestMarkdownRenderer_Consolidated(t *testing.T) {
+	report := consolidatedTestReport()
+	markdown, err := MarkdownRenderer{}.Render(report)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for _, expected := range []string{
+		"## Vulnerability report: example",
+		"**Verdict:** :warning: 3 (2 critical, 1 medium) new vulnerabilities",
+		"| registry1 | app `1.0` -> `1.1` | 2 (1 critical, 1 medium) | 1 (1 high) | 1 (1 low)",
+		"| **Total** |",
+		"<summary>Vulnerabilities across all images (3)</summary>",
+		"| CVE-1 | critical | 9.8   | new      | openssl, libssl | app (registry1), app (upstream) |",
+		"## Images",
+		"### app `1.0` -> `1.1`",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+	if strings.Index(markdown, "## Images") > strings.Index(markdown, "### app `1.0` -> `1.1`") {
+		t.Error("Expected the summary before the image details")
+	}
+}
+
+func TestVerdict(t *testing.T) {
+	report := Report{Violations: []string{"fail-on new-critical: found 1 new vulnerabilities"}}
+	if v := verdict(report); !strings.Contains(v, ":x:") || !strings.Contains(v, "- fail-on new-critical") {
+		t.Errorf("Expected a failed verdict listing the violations, got %q", v)
+	}
+
+	report = Report{Comparisons: []Comparison{{Existing: []VulnerabilityEntry{{ID: "CVE-1", Severity: "low"}}}}}
+	if v := verdict(report); !strings.Contains(v, "No new vulnerabilities") {
+		t.Errorf("Expected a passing verdict, got %q", v)
+	}
+}
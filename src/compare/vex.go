@@ -0,0 +1,366 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	goyaml "github.com/goccy/go-yaml"
+)
+
+// Suppression marks a vulnerability as accepted so that it is left out of comparisons.
+// An empty Package applies the suppression to every package affected by the vulnerability.
+type Suppression struct {
+	ID            string
+	Package       string
+	Justification string
+	Expires       *time.Time
+	Source        string
+}
+
+// VEX is the set of suppressions loaded from OpenVEX, CycloneDX VEX and YAML ignore files.
+type VEX struct {
+	Suppressions []Suppression
+}
+
+// SuppressedVulnerability is a vulnerability that was removed from a scan by a suppression.
+type SuppressedVulnerability struct {
+	Vulnerability cyclonedx.Vulnerability
+	Suppression   Suppression
+}
+
+type ignoreFile struct {
+	Ignore []ignoreEntry `yaml:"ignore"`
+}
+
+type ignoreEntry struct {
+	ID            string `yaml:"id"`
+	Package       string `yaml:"package"`
+	Justification string `yaml:"justification"`
+	Expires       string `yaml:"expires"`
+}
+
+type openVEXDocument struct {
+	Context    string             `json:"@context"`
+	Statements []openVEXStatement `json:"statements"`
+}
+
+type openVEXStatement struct {
+	Vulnerability   openVEXVulnerability `json:"vulnerability"`
+	Products        []openVEXProduct     `json:"products"`
+	Subcomponents   []openVEXProduct     `json:"subcomponents"`
+	Status          string               `json:"status"`
+	Justification   string               `json:"justification"`
+	ImpactStatement string               `json:"impact_statement"`
+	StatusNotes     string               `json:"status_notes"`
+}
+
+// openVEXVulnerability accepts both the object form of OpenVEX v0.2.0 and the plain string of earlier versions.
+type openVEXVulnerability struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+func (v *openVEXVulnerability) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		v.Name = name
+		return nil
+	}
+	type plain openVEXVulnerability
+	return json.Unmarshal(data, (*plain)(v))
+}
+
+// openVEXProduct accepts both the object form of OpenVEX v0.2.0 and the plain string of earlier versions.
+type openVEXProduct struct {
+	ID            string           `json:"@id"`
+	Subcomponents []openVEXProduct `json:"subcomponents"`
+}
+
+func (p *openVEXProduct) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		p.ID = id
+		return nil
+	}
+	type plain openVEXProduct
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+// LoadVEX reads suppressions from each of the given files. The format is detected from the content:
+// OpenVEX and CycloneDX VEX documents are JSON, anything else is read as a YAML ignore list.
+func LoadVEX(paths ...string) (VEX, error) {
+	vex := VEX{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return vex, err
+		}
+		var suppressions []Suppression
+		if json.Valid(data) {
+			suppressions, err = parseJSONVEX(data, path)
+		} else {
+			suppressions, err = parseIgnoreFile(data, path)
+		}
+		if err != nil {
+			return vex, fmt.Errorf("failed to parse VEX file %s: %w", path, err)
+		}
+		vex.Suppressions = append(vex.Suppressions, suppressions...)
+	}
+	return vex, nil
+}
+
+// Expired returns the suppressions whose expiry date has passed. Expired suppressions are not applied.
+func (v VEX) Expired(now time.Time) []Suppression {
+	var expired []Suppression
+	for _, suppression := range v.Suppressions {
+		if suppression.isExpired(now) {
+			expired = append(expired, suppression)
+		}
+	}
+	return expired
+}
+
+// Apply removes suppressed vulnerabilities from the scan and returns them.
+func (v VEX) Apply(scan *cyclonedx.BOM, now time.Time) []SuppressedVulnerability {
+	if len(v.Suppressions) == 0 || scan.Vulnerabilities == nil {
+		return nil
+	}
+	var suppressed []SuppressedVulnerability
+	kept := []cyclonedx.Vulnerability{}
+	for _, vuln := range *scan.Vulnerabilities {
+		if suppression, ok := v.match(vuln, now); ok {
+			suppressed = append(suppressed, SuppressedVulnerability{Vulnerability: vuln, Suppression: suppression})
+		} else {
+			kept = append(kept, vuln)
+		}
+	}
+	scan.Vulnerabilities = &kept
+	return suppressed
+}
+
+func (v VEX) match(vuln cyclonedx.Vulnerability, now time.Time) (Suppression, bool) {
+	ids := []string{vuln.ID}
+	if vuln.References != nil {
+		for _, reference := range *vuln.References {
+			ids = append(ids, reference.ID)
+		}
+	}
+	var packages []string
+	if vuln.Affects != nil {
+		for _, affects := range *vuln.Affects {
+			packages = append(packages, affects.Ref)
+		}
+	}
+
+	for _, suppression := range v.Suppressions {
+		if suppression.isExpired(now) || !containsFold(ids, suppression.ID) {
+			continue
+		}
+		if suppression.Package == "" || matchesEntry(packages, suppression.Package) {
+			return suppression, true
+		}
+	}
+	return Suppression{}, false
+}
+
+func (s Suppression) isExpired(now time.Time) bool {
+	// the expiry date is inclusive, so the suppression lapses at the end of that day
+	return s.Expires != nil && !now.Before(s.Expires.AddDate(0, 0, 1))
+}
+
+func parseIgnoreFile(data []byte, path string) ([]Suppression, error) {
+	var file ignoreFile
+	if err := goyaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	suppressions := make([]Suppression, 0, len(file.Ignore))
+	for _, entry := range file.Ignore {
+		if entry.ID == "" {
+			return nil, fmt.Errorf("every ignore entry must have an id")
+		}
+		suppression := Suppression{
+			ID:            entry.ID,
+			Package:       entry.Package,
+			Justification: entry.Justification,
+			Source:        path,
+		}
+		if entry.Expires != "" {
+			expires, err := time.Parse(time.DateOnly, entry.Expires)
+			if err != nil {
+				return nil, fmt.Errorf("invalid expiry date for %s: %w", entry.ID, err)
+			}
+			suppression.Expires = &expires
+		}
+		suppressions = append(suppressions, suppression)
+	}
+	return suppressions, nil
+}
+
+func parseJSONVEX(data []byte, path string) ([]Suppression, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+	if _, ok := probe["statements"]; ok {
+		return parseOpenVEX(data, path)
+	}
+	if _, ok := probe["bomFormat"]; ok {
+		return parseCycloneDXVEX(data, path)
+	}
+	return nil, fmt.Errorf("unrecognized VEX document: expected OpenVEX or CycloneDX")
+}
+
+func parseOpenVEX(data []byte, path string) ([]Suppression, error) {
+	var doc openVEXDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var suppressions []Suppression
+	for _, statement := range doc.Statements {
+		// only statements saying the products are unaffected suppress a vulnerability
+		if statement.Status != "not_affected" && statement.Status != "fixed" {
+			continue
+		}
+		justification := strings.Join(nonEmpty(statement.Status, statement.Justification, statement.ImpactStatement, statement.StatusNotes), ": ")
+
+		var packages []string
+		for _, subcomponent := range statement.Subcomponents {
+			packages = append(packages, subcomponent.ID)
+		}
+		for _, product := range statement.Products {
+			for _, subcomponent := range product.Subcomponents {
+				packages = append(packages, subcomponent.ID)
+			}
+		}
+		if len(packages) == 0 {
+			packages = []string{""}
+		}
+
+		ids := append([]string{statement.Vulnerability.Name}, statement.Vulnerability.Aliases...)
+		for _, id := range nonEmpty(ids...) {
+			for _, pkg := range packages {
+				suppressions = append(suppressions, Suppression{ID: id, Package: pkg, Justification: justification, Source: path})
+			}
+		}
+	}
+	return suppressions, nil
+}
+
+func parseCycloneDXVEX(data []byte, path string) ([]Suppression, error) {
+	var bom cyclonedx.BOM
+	if err := cyclonedx.NewBOMDecoder(bytes.NewReader(data), cyclonedx.BOMFileFormatJSON).Decode(&bom); err != nil {
+		return nil, err
+	}
+	if bom.Vulnerabilities == nil {
+		return nil, nil
+	}
+	var suppressions []Suppression
+	for _, vuln := range *bom.Vulnerabilities {
+		if vuln.Analysis == nil {
+			continue
+		}
+		switch vuln.Analysis.State {
+		case cyclonedx.IASNotAffected, cyclonedx.IASFalsePositive, cyclonedx.IASResolved, cyclonedx.IASResolvedWithPedigree:
+		default:
+			continue
+		}
+		justification := strings.Join(nonEmpty(string(vuln.Analysis.State), string(vuln.Analysis.Justification), vuln.Analysis.Detail), ": ")
+
+		// only package URLs can be matched against scan results, other references apply to all packages
+		packages := []string{""}
+		if vuln.Affects != nil {
+			var purls []string
+			for _, affects := range *vuln.Affects {
+				if strings.HasPrefix(affects.Ref, "pkg:") {
+					purls = append(purls, affects.Ref)
+				}
+			}
+			if len(purls) > 0 {
+				packages = purls
+			}
+		}
+		for _, pkg := range packages {
+			suppressions = append(suppressions, Suppression{ID: vuln.ID, Package: pkg, Justification: justification, Source: path})
+		}
+	}
+	return suppressions, nil
+}
+
+// packageWithoutVersion strips the version and qualifiers from a package URL.
+func packageWithoutVersion(purl string) string {
+	name, _ := splitPackageVersion(purl)
+	return name
+}
+
+// splitPackageVersion splits a package URL or package name into the package and its version, without qualifiers
+// and subpath. The version follows the last @ after the last /, so the @ of a scoped npm package, e.g. @babel/core,
+// belongs to its name.
+func splitPackageVersion(purl string) (string, string) {
+	purl, _, _ = strings.Cut(purl, "?")
+	purl, _, _ = strings.Cut(purl, "#")
+	if i := strings.LastIndex(purl, "@"); i > 0 && i > strings.LastIndex(purl, "/") {
+		return purl[:i], purl[i+1:]
+	}
+	return purl, ""
+}
+
+// matchesEntry reports whether a suppression or ignore entry, a package URL or a plain package name, matches one of
+// the packages. An entry with a version only matches that version of a package, one without matches every version.
+func matchesEntry(packages []string, entry string) bool {
+	name, version := unescapePath(packageWithoutVersion(entry)), packageVersion(entry)
+	for _, candidate := range packages {
+		if version != "" && !strings.EqualFold(packageVersion(candidate), version) {
+			continue
+		}
+		candidate = unescapePath(packageWithoutVersion(candidate))
+		if strings.EqualFold(candidate, name) || matchesName(candidate, name) || matchesName(name, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesName reports whether a plain package name is the name of the package URL, with or without its namespace,
+// e.g. openssl or alpine/openssl for pkg:apk/alpine/openssl and @babel/core for pkg:npm/@babel/core
+func matchesName(purl string, name string) bool {
+	if !strings.HasPrefix(purl, "pkg:") || strings.HasPrefix(name, "pkg:") {
+		return false
+	}
+	_, path, _ := strings.Cut(strings.TrimPrefix(purl, "pkg:"), "/")
+	return strings.EqualFold(path, name) || strings.EqualFold(path[strings.LastIndex(path, "/")+1:], name)
+}
+
+func unescapePath(value string) string {
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,274 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CycloneDX/cyclonedx-go"
+)
+
+var vexTestTime = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func writeVEXFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func vexTestScan() cyclonedx.BOM {
+	return cyclonedx.BOM{
+		Vulnerabilities: &[]cyclonedx.Vulnerability{commonFixedVuln, commonExistVuln, commonNewVuln},
+	}
+}
+
+func vulnIDs(scan cyclonedx.BOM) []string {
+	var ids []string
+	for _, vuln := range *scan.Vulnerabilities {
+		ids = append(ids, vuln.ID)
+	}
+	return ids
+}
+
+func TestLoadVEX_IgnoreList(t *testing.T) {
+	path := writeVEXFile(t, "ignore.yaml", `
+ignore:
+  - id: VULN-EXIST
+    package: pkg:generic/pkgExist@v0.9
+    justification: Not reachable from the application.
+    expires: 2026-12-31
+  - id: VULN-NEW
+    justification: Accepted risk.
+`)
+
+	vex, err := LoadVEX(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(vex.Suppressions) != 2 {
+		t.Fatalf("Expected 2 suppressions, got %d", len(vex.Suppressions))
+	}
+	first := vex.Suppressions[0]
+	if first.Expires == nil || first.Expires.Format(time.DateOnly) != "2026-12-31" {
+		t.Errorf("Unexpected expiry: %v", first.Expires)
+	}
+	if first.Source != path {
+		t.Errorf("Expected source %q, got %q", path, first.Source)
+	}
+}
+
+func TestLoadVEX_IgnoreListInvalidExpiry(t *testing.T) {
+	path := writeVEXFile(t, "ignore.yaml", "ignore:\n  - id: VULN-1\n    expires: next week\n")
+	if _, err := LoadVEX(path); err == nil {
+		t.Fatal("Expected error for invalid expiry date, got nil")
+	}
+}
+
+func TestLoadVEX_OpenVEX(t *testing.T) {
+	path := writeVEXFile(t, "openvex.json", `{
+  "@context": "https://openvex.dev/ns/v0.2.0",
+  "statements": [
+    {
+      "vulnerability": {"name": "VULN-EXIST", "aliases": ["GHSA-xxxx"]},
+      "products": [{"@id": "pkg:oci/app", "subcomponents": [{"@id": "pkgExist@v1"}]}],
+      "status": "not_affected",
+      "justification": "vulnerable_code_not_in_execute_path"
+    },
+    {
+      "vulnerability": "VULN-NEW",
+      "products": ["pkg:oci/app"],
+      "status": "affected"
+    }
+  ]
+}`)
+
+	vex, err := LoadVEX(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(vex.Suppressions) != 2 {
+		t.Fatalf("Expected a suppression for the vulnerability and its alias, got %+v", vex.Suppressions)
+	}
+	if vex.Suppressions[0].Package != "pkgExist@v1" {
+		t.Errorf("Expected the subcomponent to restrict the package, got %q", vex.Suppressions[0].Package)
+	}
+	if vex.Suppressions[0].Justification != "not_affected: vulnerable_code_not_in_execute_path" {
+		t.Errorf("Unexpected justification %q", vex.Suppressions[0].Justification)
+	}
+}
+
+func TestLoadVEX_CycloneDX(t *testing.T) {
+	path := writeVEXFile(t, "vex.cdx.json", `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "vulnerabilities": [
+    {
+      "id": "VULN-FIX",
+      "analysis": {"state": "false_positive", "detail": "Wrong package matched."},
+      "affects": [{"ref": "urn:cdx:doc/1#component"}]
+    },
+    {
+      "id": "VULN-NEW",
+      "analysis": {"state": "exploitable"}
+    }
+  ]
+}`)
+
+	vex, err := LoadVEX(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(vex.Suppressions) != 1 {
+		t.Fatalf("Expected 1 suppression, got %+v", vex.Suppressions)
+	}
+	suppression := vex.Suppressions[0]
+	if suppression.ID != "VULN-FIX" || suppression.Package != "" {
+		t.Errorf("Unexpected suppression %+v", suppression)
+	}
+	if suppression.Justification != "false_positive: Wrong package matched." {
+		t.Errorf("Unexpected justification %q", suppression.Justification)
+	}
+}
+
+func TestLoadVEX_UnknownJSON(t *testing.T) {
+	path := writeVEXFile(t, "unknown.json", `{"foo": "bar"}`)
+	if _, err := LoadVEX(path); err == nil {
+		t.Fatal("Expected error for unrecognized JSON document, got nil")
+	}
+}
+
+func TestVEXApply(t *testing.T) {
+	expired := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	lastDay := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	vex := VEX{Suppressions: []Suppression{
+		{ID: "VULN-EXIST", Package: "pkgExist", Justification: "matched by name", Expires: &lastDay},
+		{ID: "VULN-NEW", Package: "otherPkg"},
+		{ID: "VULN-FIX", Expires: &expired},
+	}}
+
+	scan := vexTestScan()
+	suppressed := vex.Apply(&scan, vexTestTime)
+
+	if len(suppressed) != 1 || suppressed[0].Vulnerability.ID != "VULN-EXIST" {
+		t.Fatalf("Expected only VULN-EXIST to be suppressed, got %+v", suppressed)
+	}
+	if suppressed[0].Suppression.Justification != "matched by name" {
+		t.Errorf("Unexpected justification %q", suppressed[0].Suppression.Justification)
+	}
+	if ids := strings.Join(vulnIDs(scan), ","); ids != "VULN-FIX,VULN-NEW" {
+		t.Errorf("Unexpected remaining vulnerabilities %s", ids)
+	}
+
+	if expiredSuppressions := vex.Expired(vexTestTime); len(expiredSuppressions) != 1 || expiredSuppressions[0].ID != "VULN-FIX" {
+		t.Errorf("Expected VULN-FIX suppression to be expired, got %+v", expiredSuppressions)
+	}
+}
+
+func TestVEXApply_MatchesReferences(t *testing.T) {
+	vuln := cyclonedx.Vulnerability{
+		ID:         "GHSA-1234",
+		References: &[]cyclonedx.VulnerabilityReference{{ID: "CVE-2026-0001"}},
+		Affects:    &[]cyclonedx.Affects{{Ref: "pkg:golang/example.com/lib@v1.2.3?type=module"}},
+		Ratings:    &[]cyclonedx.VulnerabilityRating{{Severity: "high"}},
+	}
+	scan := cyclonedx.BOM{Vulnerabilities: &[]cyclonedx.Vulnerability{vuln}}
+	vex := VEX{Suppressions: []Suppression{{ID: "cve-2026-0001", Package: "pkg:golang/example.com/lib@v1.2.3"}}}
+
+	if suppressed := vex.Apply(&scan, vexTestTime); len(suppressed) != 1 {
+		t.Fatalf("Expected the vulnerability to be suppressed through its reference, got %+v", suppressed)
+	}
+	if len(*scan.Vulnerabilities) != 0 {
+		t.Errorf("Expected no remaining vulnerabilities, got %d", len(*scan.Vulnerabilities))
+	}
+}
+
+func TestGenerateComparisonMarkdown_Suppressed(t *testing.T) {
+	expires := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	suppressed := []SuppressedVulnerability{{
+		Vulnerability: commonVuln1,
+		Suppression:   Suppression{ID: commonVuln1.ID, Justification: "Accepted risk.", Expires: &expires},
+	}}
+
+	vulnStatus := GenerateComparisonMap(commonBaseScan, commonNewBaseScan)
+	markdown, err := GenerateComparisonMarkdown(commonBaseScan, commonNewBaseScan, vulnStatus, suppressed)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for _, expected := range []string{
+		"Suppressed vulnerabilities: 1",
+		"<summary>Suppressed vulnerabilities</summary>",
+		"Accepted risk.",
+		"2026-12-31",
+		"pkgA",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected markdown to contain %q", expected)
+		}
+	}
+}
+
+func TestVEXApply_MatchesVersion(t *testing.T) {
+	newScan := func() cyclonedx.BOM {
+		return cyclonedx.BOM{Vulnerabilities: &[]cyclonedx.Vulnerability{{
+			ID:      "CVE-2026-0002",
+			Affects: &[]cyclonedx.Affects{{Ref: "pkg:apk/alpine/openssl@3.1.5-r0?arch=x86_64"}},
+			Ratings: &[]cyclonedx.VulnerabilityRating{{Severity: "high"}},
+		}}}
+	}
+
+	tests := []struct {
+		name       string
+		pkg        string
+		suppressed bool
+	}{
+		{name: "other version", pkg: "pkg:apk/alpine/openssl@3.1.4-r0"},
+		{name: "same version", pkg: "pkg:apk/alpine/openssl@3.1.5-r0", suppressed: true},
+		{name: "without version", pkg: "pkg:apk/alpine/openssl", suppressed: true},
+		{name: "plain name", pkg: "openssl", suppressed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := newScan()
+			vex := VEX{Suppressions: []Suppression{{ID: "CVE-2026-0002", Package: tt.pkg}}}
+			if suppressed := vex.Apply(&scan, vexTestTime); (len(suppressed) == 1) != tt.suppressed {
+				t.Errorf("Expected suppressed to be %v for %s, got %+v", tt.suppressed, tt.pkg, suppressed)
+			}
+		})
+	}
+}
+
+func TestVEXApply_MatchesScopedPackage(t *testing.T) {
+	tests := []struct {
+		pkg        string
+		suppressed bool
+	}{
+		{pkg: "@babel/core", suppressed: true},
+		{pkg: "@babel/core@7.24.0", suppressed: true},
+		{pkg: "@babel/core@7.0.0"},
+		{pkg: "@babel/traverse"},
+		{pkg: "pkg:npm/%40babel/core@7.24.0", suppressed: true},
+		{pkg: "pkg:npm/@babel/core", suppressed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			scan := cyclonedx.BOM{Vulnerabilities: &[]cyclonedx.Vulnerability{{
+				ID:      "CVE-2026-0003",
+				Affects: &[]cyclonedx.Affects{{Ref: "pkg:npm/%40babel/core@7.24.0"}},
+				Ratings: &[]cyclonedx.VulnerabilityRating{{Severity: "high"}},
+			}}}
+			vex := VEX{Suppressions: []Suppression{{ID: "CVE-2026-0003", Package: tt.pkg}}}
+			if suppressed := vex.Apply(&scan, vexTestTime); (len(suppressed) == 1) != tt.suppressed {
+				t.Errorf("Expected suppressed to be %v for %s, got %+v", tt.suppressed, tt.pkg, suppressed)
+			}
+		})
+	}
+}
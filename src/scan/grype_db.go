@@ -0,0 +1,190 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package scan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/defenseunicorns/uds-pk/src/utils"
+)
+
+// GrypeDB is a vulnerability database imported from an archive into its own cache directory. Scans with it
+// run grype offline: grype neither checks for nor downloads database updates, so every scan uses the same database.
+type GrypeDB struct {
+	Archive  string
+	CacheDir string
+	// Built is when the database was built, as reported by grype
+	Built time.Time
+}
+
+// ImportGrypeDB imports the database archive into a cache directory under cacheRoot named after the checksum
+// of the archive, so an archive is only imported once and never mixes with grype's own cache.
+func ImportGrypeDB(archive string, cacheRoot string, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (*GrypeDB, error) {
+	checksum, err := fileChecksum(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read grype database archive %s: %w", archive, err)
+	}
+	db := &GrypeDB{Archive: archive, CacheDir: filepath.Join(cacheRoot, checksum[:12])}
+	if err := os.MkdirAll(db.CacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create grype database cache %s: %w", db.CacheDir, err)
+	}
+
+	if db.Built, err = db.built(processRunner); err != nil {
+		logger.Info("Importing grype database", slog.String("archive", archive), slog.String("cacheDir", db.CacheDir))
+		cmd := db.command(processRunner, "db", "import", archive)
+		configureOutput(cmd, isVerbose)
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to import grype database %s: %w", archive, err)
+		}
+		if db.Built, err = db.built(processRunner); err != nil {
+			return nil, fmt.Errorf("grype database imported from %s is not usable: %w", archive, err)
+		}
+	}
+	logger.Debug("Using grype database", slog.String("cacheDir", db.CacheDir), slog.Time("built", db.Built))
+	return db, nil
+}
+
+// command creates a grype command which uses the database without network access. Without a database it is
+// a plain grype command.
+func (db *GrypeDB) command(processRunner utils.RunProcess, args ...string) utils.CommandRunner {
+	cmd := processRunner("grype", args...)
+	if db != nil {
+		cmd.SetEnv([]string{
+			"GRYPE_DB_CACHE_DIR=" + db.CacheDir,
+			"GRYPE_DB_AUTO_UPDATE=false",
+			"GRYPE_DB_VALIDATE_AGE=false",
+			"GRYPE_CHECK_FOR_APP_UPDATE=false",
+		})
+	}
+	return cmd
+}
+
+// built returns the build date of the database, or an error when grype cannot load it
+func (db *GrypeDB) built(processRunner utils.RunProcess) (time.Time, error) {
+	status, err := db.status(processRunner)
+	return status.Built, err
+}
+
+// DBStatus is the vulnerability database grype scans with.
+type DBStatus struct {
+	Built  time.Time
+	Schema string
+}
+
+// status returns the status of the database, or an error when grype cannot load it. Without a database it is
+// the status of grype's own database.
+func (db *GrypeDB) status(processRunner utils.RunProcess) (DBStatus, error) {
+	output, err := db.output(processRunner, "db", "status", "--output", "json")
+	if err != nil {
+		return DBStatus{}, fmt.Errorf("grype db status failed: %w", err)
+	}
+	return parseDBStatus(output)
+}
+
+// output runs a grype command and returns what it printed to stdout
+func (db *GrypeDB) output(processRunner utils.RunProcess, args ...string) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd := db.command(processRunner, args...)
+	cmd.SetStdout(&stdout)
+	cmd.SetStderr(io.Discard)
+	err := cmd.Run()
+	return stdout.Bytes(), err
+}
+
+// parseDBStatus reads the status from the JSON output of grype 0.88 and later, and from the
+// "Built:" and "Schema:" lines of the text output of older versions
+func parseDBStatus(output []byte) (DBStatus, error) {
+	var status struct {
+		Built         string `json:"built"`
+		SchemaVersion string `json:"schemaVersion"`
+		Valid         *bool  `json:"valid"`
+		Error         string `json:"error"`
+	}
+	if err := json.Unmarshal(output, &status); err == nil {
+		if status.Error != "" || (status.Valid != nil && !*status.Valid) {
+			return DBStatus{}, fmt.Errorf("invalid grype database: %s", status.Error)
+		}
+	} else {
+		for _, line := range strings.Split(string(output), "\n") {
+			key, value, _ := strings.Cut(strings.TrimSpace(line), ":")
+			switch key {
+			case "Built":
+				status.Built = strings.TrimSpace(value)
+			case "Schema":
+				status.SchemaVersion = strings.TrimSpace(value)
+			}
+		}
+	}
+	if status.Built == "" {
+		return DBStatus{}, fmt.Errorf("no grype database build date in: %s", strings.TrimSpace(string(output)))
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05 -0700 MST"} {
+		if t, err := time.Parse(layout, status.Built); err == nil {
+			return DBStatus{Built: t.UTC(), Schema: status.SchemaVersion}, nil
+		}
+	}
+	return DBStatus{}, fmt.Errorf("invalid grype database build date %q", status.Built)
+}
+
+// Tooling is the grype installation and vulnerability database scans run with.
+type Tooling struct {
+	GrypeVersion string
+	DB           DBStatus
+}
+
+// GrypeTooling returns the version of grype and the status of the database it scans with, db or grype's own.
+// What grype does not report is left empty.
+func GrypeTooling(db *GrypeDB, logger *slog.Logger, processRunner utils.RunProcess) Tooling {
+	var tooling Tooling
+	if output, err := db.output(processRunner, "version", "--output", "json"); err != nil {
+		logger.Debug("Cannot determine the grype version", slog.Any("error", err))
+	} else {
+		tooling.GrypeVersion = parseVersion(output)
+	}
+	status, err := db.status(processRunner)
+	if err != nil {
+		logger.Debug("Cannot determine the grype database", slog.Any("error", err))
+	}
+	tooling.DB = status
+	return tooling
+}
+
+// parseVersion reads the version from the JSON output of grype version, or the "Version:" line of its text output
+func parseVersion(output []byte) string {
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(output, &version); err == nil {
+		return version.Version
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if value, found := strings.CutPrefix(strings.TrimSpace(line), "Version:"); found {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close() //nolint:errcheck
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -4,6 +4,8 @@
 package scan
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -21,8 +23,12 @@ import (
 Scanning logic is heavily inspired by https://github.com/defenseunicorns-navy/sonic-components-zarf-scan
 */
 
-func Images(images []string, outputDir string, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (map[string]string, error) {
+// Images scans the images pulled from their registries and returns the scan and the digest of the manifest
+// grype resolved for each image. When arch is set, the image of that architecture is scanned from
+// multi-platform images. When db is set, grype scans offline with that database.
+func Images(images []string, arch string, outputDir string, db *GrypeDB, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (map[string]string, map[string]string, error) {
 	results := map[string]string{}
+	digests := map[string]string{}
 	for _, image := range images {
 		// adding registry: to make `grype` pull the image from the registry
 		// this avoids issues with containerd snapshotting in Docker
@@ -30,17 +36,18 @@ func Images(images []string, outputDir string, logger *slog.Logger, isVerbose bo
 			image = "registry:" + image
 		}
 		logger.Debug("Will scan image", slog.String("image", image))
-		outJson, err := scanImage(image, outputDir, logger, isVerbose, processRunner)
+		outJson, digest, err := scanImage(image, arch, outputDir, db, logger, isVerbose, processRunner)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		} else {
 			results[image] = outJson
+			digests[image] = digest
 		}
 	}
-	return results, nil
+	return results, digests, nil
 }
 
-func SBOMs(sbomsDir, outputDir string, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (map[string]string, error) {
+func SBOMs(sbomsDir, outputDir string, db *GrypeDB, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (map[string]string, error) {
 	// Find only JSON files in the sboms directory
 	pattern := filepath.Join(sbomsDir, "*.json")
 	sbomFiles, err := filepath.Glob(pattern)
@@ -56,7 +63,7 @@ func SBOMs(sbomsDir, outputDir string, logger *slog.Logger, isVerbose bool, proc
 
 	results := map[string]string{}
 	for _, sbomFile := range sbomFiles {
-		outJson, err := scanSBOM(sbomFile, outputDir, logger, isVerbose, processRunner)
+		outJson, err := scanSBOM(sbomFile, outputDir, db, logger, isVerbose, processRunner)
 		if err != nil {
 			return nil, err
 		} else {
@@ -121,7 +128,16 @@ func sanitizeFilename(name string) string {
 	return sanitized
 }
 
-func scanSBOM(sbomFile string, outputDir string, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (string, error) {
+// scanOutputFile is the path of the scan of an image in outputDir. Like SBOMFile, it is named after the image with a
+// hash of the full reference, so scans of images with the same name from different repositories do not overwrite
+// each other.
+func scanOutputFile(image string, outputDir string) string {
+	image = strings.TrimPrefix(image, "registry:")
+	sum := sha256.Sum256([]byte(image))
+	return filepath.Join(outputDir, sanitizeFilename(image)+"_"+hex.EncodeToString(sum[:6])+".json")
+}
+
+func scanSBOM(sbomFile string, outputDir string, db *GrypeDB, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (string, error) {
 	logger.Debug("Scanning SBOM", slog.String("file", sbomFile))
 
 	// Set up the output path if needed
@@ -130,7 +146,7 @@ func scanSBOM(sbomFile string, outputDir string, logger *slog.Logger, isVerbose
 	}
 
 	// Extract image reference from SBOM if possible
-	var safeImageName string
+	var jsonOutputPath string
 
 	// Try to extract the image reference using JSON parsing
 	if data, err := os.ReadFile(sbomFile); err == nil {
@@ -145,20 +161,18 @@ func scanSBOM(sbomFile string, outputDir string, logger *slog.Logger, isVerbose
 		if err := json.Unmarshal(data, &sbom); err == nil && sbom.Source.Metadata.UserInput != "" {
 			imageRef := sbom.Source.Metadata.UserInput
 			logger.Debug("Found image reference in SBOM", slog.String("imageRef", imageRef))
-			safeImageName = sanitizeFilename(filepath.Base(imageRef))
+			jsonOutputPath = scanOutputFile(imageRef, outputDir)
 		}
 	}
 
 	// If we couldn't extract the image ref, use the SBOM filename
-	if safeImageName == "" {
+	if jsonOutputPath == "" {
 		baseName := filepath.Base(sbomFile)
 		fileExt := filepath.Ext(baseName)
-		safeImageName = baseName[:len(baseName)-len(fileExt)]
-		safeImageName = sanitizeFilename(safeImageName)
+		safeImageName := sanitizeFilename(baseName[:len(baseName)-len(fileExt)])
 		logger.Debug("Using SBOM filename for output", slog.String("safeImageName", safeImageName))
+		jsonOutputPath = filepath.Join(outputDir, safeImageName+".json")
 	}
-	jsonFileName := safeImageName + ".json"
-	jsonOutputPath := filepath.Join(outputDir, jsonFileName)
 	logger.Debug("Saving JSON results to output directory", slog.String("fileName", jsonOutputPath))
 
 	// Ensure the output directory exists and is writable
@@ -174,35 +188,68 @@ func scanSBOM(sbomFile string, outputDir string, logger *slog.Logger, isVerbose
 	args := []string{"--add-cpes-if-none", "--output", "cyclonedx-json", "-v", "--file", jsonOutputPath, "sbom:" + sbomFile}
 
 	// Try to scan with retries for database issues
-	return runGrypeCommand(args, jsonOutputPath, logger, isVerbose, processRunner)
+	return runGrypeCommand(args, jsonOutputPath, db, logger, isVerbose, processRunner)
 }
 
-func scanImage(image, outputDir string, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (string, error) {
+func scanImage(image, arch, outputDir string, db *GrypeDB, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (string, string, error) {
 	logger.Debug("Scanning SBOM", slog.String("file", image))
 
 	// Set up the output path if needed
 	if outputDir == "" {
-		return "", errors.New("output directory not specified")
+		return "", "", errors.New("output directory not specified")
 	}
 
-	// If we couldn't extract the image ref, use the SBOM filename
-	safeImageName := sanitizeFilename(image)
-	jsonFileName := safeImageName + ".json"
-	jsonOutputPath := filepath.Join(outputDir, jsonFileName)
+	jsonOutputPath := scanOutputFile(image, outputDir)
 	logger.Debug("Saving JSON results to output directory", slog.String("fileName", jsonOutputPath))
 
 	// Ensure the output directory exists and is writable
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+		return "", "", fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+	}
+
+	// the grype JSON report records the digest of the manifest grype resolved for the image, which the CycloneDX
+	// report does not
+	report, err := os.CreateTemp("", "grype-*.json")
+	if err != nil {
+		return "", "", err
 	}
+	_ = report.Close()
+	defer os.Remove(report.Name()) //nolint:errcheck
 
-	args := []string{"--add-cpes-if-none", "--output", "cyclonedx-json", "-v", "--file", jsonOutputPath, image}
+	args := []string{"--add-cpes-if-none", "--output", "cyclonedx-json", "--output", "json=" + report.Name(), "-v", "--file", jsonOutputPath}
+	if arch != "" {
+		args = append(args, "--platform", "linux/"+arch)
+	}
+	args = append(args, image)
 
 	// Try to scan with retries for database issues
-	return runGrypeCommand(args, jsonOutputPath, logger, isVerbose, processRunner)
+	scanPath, err := runGrypeCommand(args, jsonOutputPath, db, logger, isVerbose, processRunner)
+	if err != nil {
+		return "", "", err
+	}
+	return scanPath, resolvedDigest(report.Name(), logger), nil
+}
+
+// resolvedDigest reads the digest of the scanned image manifest from a grype JSON report
+func resolvedDigest(reportPath string, logger *slog.Logger) string {
+	var report struct {
+		Source struct {
+			Target struct {
+				ManifestDigest string `json:"manifestDigest"`
+			} `json:"target"`
+		} `json:"source"`
+	}
+	data, err := os.ReadFile(reportPath)
+	if err == nil {
+		err = json.Unmarshal(data, &report)
+	}
+	if err != nil {
+		logger.Debug("No image digest in the grype report", slog.String("report", reportPath), slog.Any("error", err))
+	}
+	return report.Source.Target.ManifestDigest
 }
 
-func runGrypeCommand(args []string, jsonOutputPath string, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (string, error) {
+func runGrypeCommand(args []string, jsonOutputPath string, db *GrypeDB, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (string, error) {
 	// Maximum retry attempts for handling database issues
 	maxRetries := 3
 	retryCount := 0
@@ -210,7 +257,7 @@ func runGrypeCommand(args []string, jsonOutputPath string, logger *slog.Logger,
 		// Create the command - this needs to be inside the loop because we can't reuse commands
 
 		logger.Debug("Running grype command", slog.Any("args", args))
-		cmd := processRunner("grype", args...)
+		cmd := db.command(processRunner, args...)
 		configureOutput(cmd, isVerbose)
 
 		logger.Debug("Running scan", slog.Int("attempt", retryCount+1), slog.String("command", "grype "+strings.Join(args, " ")))
@@ -221,6 +268,10 @@ func runGrypeCommand(args []string, jsonOutputPath string, logger *slog.Logger,
 			return jsonOutputPath, nil
 		}
 		logger.Debug("Error from grype command:", slog.Any("error", err))
+		if db != nil {
+			// the database is pinned, updating it would defeat the purpose
+			return "", fmt.Errorf("grype scan failed for %v with the database imported from %s: %w", args, db.Archive, err)
+		}
 		// Check if this is a database error
 		checkCmd := processRunner("grype", "db", "status")
 		configureOutput(checkCmd, isVerbose)
@@ -0,0 +1,100 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package scan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/defenseunicorns/uds-pk/src/utils"
+)
+
+// ImagesFromSBOMs generates an SBOM of each image with syft, in the syft JSON format Zarf stores in packages,
+// and scans the SBOMs. This scans the current images the same way as the SBOMs of a released package.
+// SBOMs already in sbomDir are reused, so a persistent sbomDir acts as a cache across runs.
+func ImagesFromSBOMs(images []string, arch string, sbomDir string, outputDir string, db *GrypeDB, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (map[string]string, error) {
+	sboms, err := GenerateSBOMs(images, arch, sbomDir, logger, isVerbose, processRunner)
+	if err != nil {
+		return nil, err
+	}
+	results := map[string]string{}
+	for image, sbomFile := range sboms {
+		outJson, err := scanSBOM(sbomFile, outputDir, db, logger, isVerbose, processRunner)
+		if err != nil {
+			return nil, err
+		}
+		// keyed like Images, so results of both can be used interchangeably
+		results["registry:"+image] = outJson
+	}
+	return results, nil
+}
+
+// GenerateSBOMs generates an SBOM of each image with syft, reusing the SBOMs already in sbomDir,
+// and returns the SBOM file of each image.
+func GenerateSBOMs(images []string, arch string, sbomDir string, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (map[string]string, error) {
+	if err := os.MkdirAll(sbomDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create SBOM directory %s: %w", sbomDir, err)
+	}
+	sboms := map[string]string{}
+	for _, image := range images {
+		image = strings.TrimPrefix(image, "registry:")
+		sbomFile, err := imageSBOM(image, arch, sbomDir, logger, isVerbose, processRunner)
+		if err != nil {
+			return nil, err
+		}
+		sboms[image] = sbomFile
+	}
+	return sboms, nil
+}
+
+// imageSBOM returns the SBOM of the image in sbomDir, generating it when it is not there yet
+func imageSBOM(image string, arch string, sbomDir string, logger *slog.Logger, isVerbose bool, processRunner utils.RunProcess) (string, error) {
+	sbomFile := SBOMFile(image, arch, sbomDir)
+	if info, err := os.Stat(sbomFile); err == nil && info.Size() > 0 {
+		logger.Debug("Reusing SBOM", slog.String("image", image), slog.String("file", sbomFile))
+		return sbomFile, nil
+	}
+
+	// syft writes to a temporary file first so that an interrupted run does not leave a partial SBOM behind
+	tempFile, err := os.CreateTemp(sbomDir, ".syft-*.json")
+	if err != nil {
+		return "", fmt.Errorf("failed to create SBOM file in %s: %w", sbomDir, err)
+	}
+	_ = tempFile.Close()
+	defer os.Remove(tempFile.Name()) //nolint:errcheck
+
+	// like Zarf, pull the image from the registry and record the image reference as the user input
+	args := []string{"scan", "--from", "registry", "--output", "syft-json=" + tempFile.Name()}
+	if arch != "" {
+		args = append(args, "--platform", "linux/"+arch)
+	}
+	args = append(args, image)
+
+	logger.Debug("Generating SBOM", slog.String("image", image), slog.String("command", "syft "+strings.Join(args, " ")))
+	cmd := processRunner("syft", args...)
+	configureOutput(cmd, isVerbose)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("syft failed to generate an SBOM for %s: %w", image, err)
+	}
+	if err := os.Rename(tempFile.Name(), sbomFile); err != nil {
+		return "", fmt.Errorf("failed to store the SBOM of %s: %w", image, err)
+	}
+	return sbomFile, nil
+}
+
+// SBOMFile is the path of the SBOM ImagesFromSBOMs generates for an image in sbomDir. It is named after the image,
+// with a hash of the full reference and architecture to tell apart images with the same name from different repositories.
+func SBOMFile(image string, arch string, sbomDir string) string {
+	sum := sha256.Sum256([]byte(image + "|" + arch))
+	name := sanitizeFilename(image)
+	if arch != "" {
+		name += "_" + arch
+	}
+	return filepath.Join(sbomDir, name+"_"+hex.EncodeToString(sum[:6])+".json")
+}
@@ -0,0 +1,355 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Checklist formats: CKLB is the JSON format of STIG Viewer 3, CKL the XML format of STIG Viewer 2.
+const (
+	FormatCKLB = "cklb"
+	FormatCKL  = "ckl"
+)
+
+// Formats lists the supported checklist formats.
+var Formats = []string{FormatCKLB, FormatCKL}
+
+// cklHeader is the comment STIG Viewer 2 writes before the checklist
+const cklHeader = "<!--DISA STIG Viewer :: 2.18-->\n"
+
+// CKL XML types matching the STIG Viewer 2 schema.
+
+type cklChecklist struct {
+	XMLName xml.Name  `xml:"CHECKLIST"`
+	Asset   cklAsset  `xml:"ASSET"`
+	STIGs   []cklSTIG `xml:"STIGS>iSTIG"`
+}
+
+type cklAsset struct {
+	Role          string `xml:"ROLE"`
+	AssetType     string `xml:"ASSET_TYPE"`
+	Marking       string `xml:"MARKING"`
+	HostName      string `xml:"HOST_NAME"`
+	HostIP        string `xml:"HOST_IP"`
+	HostMAC       string `xml:"HOST_MAC"`
+	HostFQDN      string `xml:"HOST_FQDN"`
+	TargetComment string `xml:"TARGET_COMMENT"`
+	TechArea      string `xml:"TECH_AREA"`
+	TargetKey     string `xml:"TARGET_KEY"`
+	WebOrDatabase bool   `xml:"WEB_OR_DATABASE"`
+	WebDBSite     string `xml:"WEB_DB_SITE"`
+	WebDBInstance string `xml:"WEB_DB_INSTANCE"`
+}
+
+type cklSTIG struct {
+	Info  []cklSIData `xml:"STIG_INFO>SI_DATA"`
+	Vulns []cklVuln   `xml:"VULN"`
+}
+
+type cklSIData struct {
+	Name string `xml:"SID_NAME"`
+	Data string `xml:"SID_DATA"`
+}
+
+type cklVuln struct {
+	Data                  []cklSTIGData `xml:"STIG_DATA"`
+	Status                string        `xml:"STATUS"`
+	FindingDetails        string        `xml:"FINDING_DETAILS"`
+	Comments              string        `xml:"COMMENTS"`
+	SeverityOverride      string        `xml:"SEVERITY_OVERRIDE"`
+	SeverityJustification string        `xml:"SEVERITY_JUSTIFICATION"`
+}
+
+type cklSTIGData struct {
+	Attribute string `xml:"VULN_ATTRIBUTE"`
+	Data      string `xml:"ATTRIBUTE_DATA"`
+}
+
+// cklStatuses maps the statuses of CKLB rules to those of CKL vulnerabilities
+var cklStatuses = map[string]string{
+	"not_a_finding":  "NotAFinding",
+	"open":           "Open",
+	"not_applicable": "Not_Applicable",
+	"not_reviewed":   "Not_Reviewed",
+}
+
+// MarshalChecklist encodes the checklist in the given format.
+func MarshalChecklist(checklist *Checklist, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case FormatCKLB, "":
+		return json.MarshalIndent(checklist, "", "  ")
+	case FormatCKL:
+		return MarshalCKL(checklist)
+	default:
+		return nil, fmt.Errorf("unsupported checklist format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// MarshalCKL encodes the checklist as a STIG Viewer 2 .ckl XML document.
+func MarshalCKL(checklist *Checklist) ([]byte, error) {
+	ckl := cklChecklist{Asset: cklAsset{AssetType: "Computing", Marking: "CUI"}}
+	if len(checklist.STIGs) > 0 {
+		ckl.Asset.TargetKey = derefString(checklist.STIGs[0].ReferenceIdentifier)
+	}
+	if td := checklist.TargetData; td != nil {
+		ckl.Asset = cklAsset{
+			Role:          td.Role,
+			AssetType:     coalesce(td.TargetType, "Computing"),
+			Marking:       "CUI",
+			HostName:      td.HostName,
+			HostIP:        td.IPAddress,
+			HostMAC:       td.MACAddress,
+			HostFQDN:      td.FQDN,
+			TargetComment: td.Comments,
+			TechArea:      td.TechnologyArea,
+			WebOrDatabase: td.IsWebDatabase,
+			WebDBSite:     td.WebDBSite,
+			WebDBInstance: td.WebDBInstance,
+			TargetKey:     ckl.Asset.TargetKey,
+		}
+	}
+
+	for _, s := range checklist.STIGs {
+		istig := cklSTIG{Info: []cklSIData{
+			{Name: "version"},
+			{Name: "classification", Data: "UNCLASSIFIED"},
+			{Name: "customname"},
+			{Name: "stigid", Data: s.STIGID},
+			{Name: "description"},
+			{Name: "filename"},
+			{Name: "releaseinfo", Data: s.ReleaseInfo},
+			{Name: "title", Data: s.STIGName},
+			{Name: "uuid", Data: s.UUID},
+			{Name: "notice", Data: "terms-of-use"},
+			{Name: "source", Data: "STIG.DOD.MIL"},
+		}}
+		for _, r := range s.Rules {
+			istig.Vulns = append(istig.Vulns, cklVulnFromRule(r, s))
+		}
+		ckl.STIGs = append(ckl.STIGs, istig)
+	}
+
+	data, err := xml.MarshalIndent(ckl, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal CKL: %w", err)
+	}
+	return append([]byte(xml.Header+cklHeader), data...), nil
+}
+
+func cklVulnFromRule(r Rule, s STIG) cklVuln {
+	checkContentRef := ""
+	if r.CheckContentRef != nil {
+		checkContentRef = r.CheckContentRef.Name
+	}
+	class := r.Classification
+	if class == "Unclassified" {
+		class = "Unclass"
+	}
+	data := []cklSTIGData{
+		{"Vuln_Num", r.GroupID},
+		{"Severity", r.Severity},
+		{"Group_Title", groupTitle(r)},
+		{"Rule_ID", r.RuleID},
+		{"Rule_Ver", r.RuleVersion},
+		{"Rule_Title", r.RuleTitle},
+		{"Vuln_Discuss", r.Discussion},
+		{"IA_Controls", r.IAControls},
+		{"Check_Content", r.CheckContent},
+		{"Fix_Text", r.FixText},
+		{"False_Positives", r.FalsePositives},
+		{"False_Negatives", r.FalseNegatives},
+		{"Documentable", r.Documentable},
+		{"Mitigations", r.Mitigations},
+		{"Potential_Impact", r.PotentialImpacts},
+		{"Third_Party_Tools", r.ThirdPartyTools},
+		{"Mitigation_Control", r.MitigationControl},
+		{"Responsibility", r.Responsibility},
+		{"Security_Override_Guidance", r.SecurityOverride},
+		{"Check_Content_Ref", checkContentRef},
+		{"Weight", r.Weight},
+		{"Class", class},
+		{"STIGRef", stigRef(s)},
+		{"TargetKey", derefString(r.ReferenceID)},
+		{"STIG_UUID", r.SIGUUID},
+	}
+	for _, legacyID := range r.LegacyIDs {
+		data = append(data, cklSTIGData{"LEGACY_ID", legacyID})
+	}
+	for _, cci := range r.CCIs {
+		data = append(data, cklSTIGData{"CCI_REF", cci})
+	}
+
+	status, ok := cklStatuses[r.Status]
+	if !ok {
+		status = "Not_Reviewed"
+	}
+	return cklVuln{
+		Data:           data,
+		Status:         status,
+		FindingDetails: r.FindingDetails,
+		Comments:       r.Comments,
+	}
+}
+
+// ParseCKL decodes a STIG Viewer 2 .ckl XML document into a checklist. CKL files do not record the title of
+// the checklist nor the UUIDs of its rules, so the title is left empty and rules get new UUIDs.
+func ParseCKL(data []byte) (*Checklist, error) {
+	var ckl cklChecklist
+	if err := xml.Unmarshal(data, &ckl); err != nil {
+		return nil, fmt.Errorf("parsing CKL: %w", err)
+	}
+
+	checklist := &Checklist{
+		ID:          uuid.New().String(),
+		CKLBVersion: "1.0",
+		Mode:        1,
+		HasPath:     true,
+		TargetData: &TargetData{
+			TargetType:     ckl.Asset.AssetType,
+			HostName:       ckl.Asset.HostName,
+			IPAddress:      ckl.Asset.HostIP,
+			MACAddress:     ckl.Asset.HostMAC,
+			FQDN:           ckl.Asset.HostFQDN,
+			Comments:       ckl.Asset.TargetComment,
+			Role:           ckl.Asset.Role,
+			IsWebDatabase:  ckl.Asset.WebOrDatabase,
+			TechnologyArea: ckl.Asset.TechArea,
+			WebDBSite:      ckl.Asset.WebDBSite,
+			WebDBInstance:  ckl.Asset.WebDBInstance,
+		},
+		STIGs: []STIG{},
+	}
+
+	for _, istig := range ckl.STIGs {
+		info := map[string]string{}
+		for _, si := range istig.Info {
+			info[si.Name] = si.Data
+		}
+		s := STIG{
+			STIGName:    info["title"],
+			DisplayName: strings.TrimSpace(strings.TrimSuffix(info["title"], "Security Technical Implementation Guide")),
+			STIGID:      info["stigid"],
+			ReleaseInfo: info["releaseinfo"],
+			UUID:        coalesce(info["uuid"], uuid.New().String()),
+			Rules:       []Rule{},
+		}
+		for _, vuln := range istig.Vulns {
+			r := ruleFromCKLVuln(vuln, s.UUID)
+			if s.ReferenceIdentifier == nil {
+				s.ReferenceIdentifier = r.ReferenceID
+			}
+			s.Rules = append(s.Rules, r)
+		}
+		s.Size = len(s.Rules)
+		checklist.STIGs = append(checklist.STIGs, s)
+	}
+	return checklist, nil
+}
+
+func ruleFromCKLVuln(vuln cklVuln, stigUUID string) Rule {
+	attributes := map[string]string{}
+	legacyIDs, ccis := []string{}, []string{}
+	for _, d := range vuln.Data {
+		switch d.Attribute {
+		case "LEGACY_ID":
+			if d.Data != "" {
+				legacyIDs = append(legacyIDs, d.Data)
+			}
+		case "CCI_REF":
+			ccis = append(ccis, d.Data)
+		default:
+			attributes[d.Attribute] = d.Data
+		}
+	}
+
+	status := "not_reviewed"
+	for cklbStatus, cklStatus := range cklStatuses {
+		if cklStatus == vuln.Status {
+			status = cklbStatus
+		}
+	}
+	var checkContentRef *CheckContentRef
+	if name := attributes["Check_Content_Ref"]; name != "" {
+		checkContentRef = &CheckContentRef{Name: name}
+	}
+	var referenceID *string
+	if targetKey := attributes["TargetKey"]; targetKey != "" {
+		referenceID = &targetKey
+	}
+	class := attributes["Class"]
+	if class == "Unclass" {
+		class = "Unclassified"
+	}
+
+	return Rule{
+		GroupIDSrc: attributes["Vuln_Num"],
+		GroupTree: []GroupTreeEntry{{
+			ID:          attributes["Vuln_Num"],
+			Title:       attributes["Group_Title"],
+			Description: "<GroupDescription></GroupDescription>",
+		}},
+		GroupID:           attributes["Vuln_Num"],
+		Severity:          attributes["Severity"],
+		GroupTitle:        attributes["Rule_Title"],
+		RuleIDSrc:         attributes["Rule_ID"],
+		RuleID:            attributes["Rule_ID"],
+		RuleVersion:       attributes["Rule_Ver"],
+		RuleTitle:         attributes["Rule_Title"],
+		FixText:           attributes["Fix_Text"],
+		Weight:            attributes["Weight"],
+		CheckContent:      attributes["Check_Content"],
+		CheckContentRef:   checkContentRef,
+		Classification:    class,
+		Discussion:        attributes["Vuln_Discuss"],
+		FalsePositives:    attributes["False_Positives"],
+		FalseNegatives:    attributes["False_Negatives"],
+		Documentable:      attributes["Documentable"],
+		SecurityOverride:  attributes["Security_Override_Guidance"],
+		PotentialImpacts:  attributes["Potential_Impact"],
+		ThirdPartyTools:   attributes["Third_Party_Tools"],
+		IAControls:        attributes["IA_Controls"],
+		Responsibility:    attributes["Responsibility"],
+		Mitigations:       attributes["Mitigations"],
+		MitigationControl: attributes["Mitigation_Control"],
+		LegacyIDs:         legacyIDs,
+		CCIs:              ccis,
+		ReferenceID:       referenceID,
+		UUID:              uuid.New().String(),
+		SIGUUID:           coalesce(attributes["STIG_UUID"], stigUUID),
+		Status:            status,
+		Overrides:         map[string]any{},
+		Comments:          vuln.Comments,
+		FindingDetails:    vuln.FindingDetails,
+	}
+}
+
+// groupTitle is the title of the group of the rule, which CKL records as Group_Title
+func groupTitle(r Rule) string {
+	if len(r.GroupTree) > 0 {
+		return r.GroupTree[0].Title
+	}
+	return ""
+}
+
+// stigRef is the STIGRef attribute of CKL vulnerabilities, e.g. "Red Hat Enterprise Linux 9 Security Technical
+// Implementation Guide :: Version 2, Release: 7 Benchmark Date: 05 Jan 2026"
+func stigRef(s STIG) string {
+	if s.ReleaseInfo == "" {
+		return s.STIGName
+	}
+	return s.STIGName + " :: " + s.ReleaseInfo
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testChecklist(t *testing.T) *Checklist {
+	t.Helper()
+	s, err := ParseXCCDF(writeXCCDFFixture(t, t.TempDir()), testProfile)
+	require.NoError(t, err)
+	s.Rules[0].Status = "open"
+	s.Rules[0].FindingDetails = "Cipher suites <TLS_RSA> & friends\nare still enabled."
+	s.Rules[0].Comments = "Tracked in issue 42."
+	s.Rules[1].Status = "not_a_finding"
+	return BuildChecklist(testProfile, s)
+}
+
+// withoutCKLGaps clears what CKL files do not record, so checklists can be compared after a round trip
+func withoutCKLGaps(checklist *Checklist) *Checklist {
+	c := *checklist
+	c.ID, c.Title = "", ""
+	c.STIGs = nil
+	for _, s := range checklist.STIGs {
+		rules := make([]Rule, len(s.Rules))
+		for i, r := range s.Rules {
+			r.UUID, r.GroupIDSrc, r.RuleIDSrc = "", "", ""
+			if r.CheckContentRef != nil {
+				r.CheckContentRef = &CheckContentRef{Name: r.CheckContentRef.Name}
+			}
+			rules[i] = r
+		}
+		s.Rules = rules
+		c.STIGs = append(c.STIGs, s)
+	}
+	return &c
+}
+
+func TestMarshalCKL(t *testing.T) {
+	data, err := MarshalCKL(testChecklist(t))
+	require.NoError(t, err)
+	ckl := string(data)
+
+	require.True(t, strings.HasPrefix(ckl, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!--DISA STIG Viewer :: 2.18-->\n<CHECKLIST>"))
+	require.Contains(t, ckl, "<HOST_NAME>test-app</HOST_NAME>")
+	require.Contains(t, ckl, "<TARGET_KEY>4093</TARGET_KEY>")
+	require.Contains(t, ckl, "<SID_NAME>stigid</SID_NAME>\n\t\t\t\t\t<SID_DATA>Application_Security_Development_STIG</SID_DATA>")
+	require.Contains(t, ckl, "<VULN_ATTRIBUTE>Rule_Ver</VULN_ATTRIBUTE>\n\t\t\t\t\t<ATTRIBUTE_DATA>APSC-DV-000160</ATTRIBUTE_DATA>")
+	require.Contains(t, ckl, "<VULN_ATTRIBUTE>Group_Title</VULN_ATTRIBUTE>\n\t\t\t\t\t<ATTRIBUTE_DATA>SRG-APP-000001</ATTRIBUTE_DATA>")
+	require.Contains(t, ckl, "<VULN_ATTRIBUTE>CCI_REF</VULN_ATTRIBUTE>\n\t\t\t\t\t<ATTRIBUTE_DATA>CCI-000068</ATTRIBUTE_DATA>")
+	require.Contains(t, ckl, "<STATUS>Open</STATUS>")
+	require.Contains(t, ckl, "<STATUS>NotAFinding</STATUS>")
+	require.Contains(t, ckl, "<FINDING_DETAILS>Cipher suites &lt;TLS_RSA&gt; &amp; friends&#xA;are still enabled.</FINDING_DETAILS>")
+	require.Contains(t, ckl, "<COMMENTS>Tracked in issue 42.</COMMENTS>")
+}
+
+func TestMarshalCKL_StatusMapping(t *testing.T) {
+	for status, expected := range map[string]string{
+		"not_a_finding":  "NotAFinding",
+		"open":           "Open",
+		"not_applicable": "Not_Applicable",
+		"not_reviewed":   "Not_Reviewed",
+		"":               "Not_Reviewed",
+	} {
+		checklist := &Checklist{STIGs: []STIG{{Rules: []Rule{{Status: status}}}}}
+		data, err := MarshalCKL(checklist)
+		require.NoError(t, err)
+		require.Contains(t, string(data), "<STATUS>"+expected+"</STATUS>", status)
+	}
+}
+
+func TestCKLRoundTrip(t *testing.T) {
+	checklist := testChecklist(t)
+
+	data, err := MarshalCKL(checklist)
+	require.NoError(t, err)
+	parsed, err := ParseCKL(data)
+	require.NoError(t, err)
+
+	require.NotEmpty(t, parsed.ID)
+	require.NotEmpty(t, parsed.STIGs[0].Rules[0].UUID)
+	require.Equal(t, withoutCKLGaps(checklist), withoutCKLGaps(parsed))
+
+	// the parsed checklist encodes to the same CKL
+	again, err := MarshalCKL(parsed)
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(again))
+}
+
+func TestCKLRoundTrip_MultipleSTIGs(t *testing.T) {
+	first := testChecklist(t)
+	second := testChecklist(t)
+	second.STIGs[0].STIGID = "Other_STIG"
+	combined := CombineChecklists("combined", []*Checklist{first, second})
+
+	data, err := MarshalCKL(combined)
+	require.NoError(t, err)
+	parsed, err := ParseCKL(data)
+	require.NoError(t, err)
+
+	require.Len(t, parsed.STIGs, 2)
+	require.Equal(t, "Other_STIG", parsed.STIGs[1].STIGID)
+	require.Equal(t, 2, parsed.STIGs[1].Size)
+	require.Equal(t, withoutCKLGaps(combined), withoutCKLGaps(parsed))
+}
+
+func TestParseCKL_InvalidXML(t *testing.T) {
+	_, err := ParseCKL([]byte("<CHECKLIST><ASSET>"))
+	require.ErrorContains(t, err, "parsing CKL")
+}
+
+func TestMarshalChecklist(t *testing.T) {
+	checklist := testChecklist(t)
+
+	data, err := MarshalChecklist(checklist, FormatCKLB)
+	require.NoError(t, err)
+	var decoded Checklist
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, checklist.ID, decoded.ID)
+
+	data, err = MarshalChecklist(checklist, FormatCKL)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "<CHECKLIST>")
+
+	_, err = MarshalChecklist(checklist, "xlsx")
+	require.ErrorContains(t, err, `unsupported checklist format "xlsx"`)
+}
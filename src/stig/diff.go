@@ -0,0 +1,282 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/renderer"
+	"github.com/olekukonko/tablewriter/tw"
+)
+
+// Output formats of checklist differences.
+const (
+	DiffFormatMarkdown = "markdown"
+	DiffFormatJSON     = "json"
+)
+
+// DiffFormats lists the supported output formats of checklist differences.
+var DiffFormats = []string{DiffFormatMarkdown, DiffFormatJSON}
+
+// ChecklistDiff holds the differences between the STIGs of an old and a new checklist.
+type ChecklistDiff struct {
+	STIGs []STIGDiff `json:"stigs"`
+}
+
+// STIGDiff holds the rules added, removed and changed between two revisions of a STIG, matched by rule version.
+type STIGDiff struct {
+	STIGID        string         `json:"stigId"`
+	DisplayName   string         `json:"displayName"`
+	OldRelease    string         `json:"oldRelease,omitempty"`
+	NewRelease    string         `json:"newRelease,omitempty"`
+	Added         []DiffRule     `json:"added"`
+	Removed       []DiffRule     `json:"removed"`
+	StatusChanges []StatusChange `json:"statusChanges"`
+	TextChanges   []TextChange   `json:"textChanges"`
+}
+
+// DiffRule is a rule present in only one of the checklists.
+type DiffRule struct {
+	RuleVersion string `json:"ruleVersion"`
+	GroupID     string `json:"groupId"`
+	Severity    string `json:"severity"`
+	Title       string `json:"title"`
+	Status      string `json:"status"`
+}
+
+// StatusChange is a rule whose disposition changed.
+type StatusChange struct {
+	RuleVersion string `json:"ruleVersion"`
+	GroupID     string `json:"groupId"`
+	Title       string `json:"title"`
+	OldStatus   string `json:"oldStatus"`
+	NewStatus   string `json:"newStatus"`
+}
+
+// TextChange is a rule whose content changed, with the names of the changed fields, e.g. check_content.
+type TextChange struct {
+	RuleVersion string   `json:"ruleVersion"`
+	GroupID     string   `json:"groupId"`
+	Title       string   `json:"title"`
+	Fields      []string `json:"fields"`
+}
+
+// Empty reports whether the STIG has the same rules with the same statuses in both checklists.
+func (d STIGDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.StatusChanges) == 0 && len(d.TextChanges) == 0
+}
+
+// DiffChecklists compares the STIGs of two checklists by STIG ID, or pairs them when each checklist has a
+// single STIG, and their rules by rule version. STIGs found in only one checklist have all their rules added or
+// removed.
+func DiffChecklists(old *Checklist, new *Checklist) ChecklistDiff {
+	diff := ChecklistDiff{STIGs: []STIGDiff{}}
+	if len(old.STIGs) == 1 && len(new.STIGs) == 1 {
+		diff.STIGs = append(diff.STIGs, diffSTIGs(&old.STIGs[0], &new.STIGs[0]))
+		return diff
+	}
+	for i := range new.STIGs {
+		diff.STIGs = append(diff.STIGs, diffSTIGs(findSTIG(old, new.STIGs[i].STIGID), &new.STIGs[i]))
+	}
+	for i := range old.STIGs {
+		if findSTIG(new, old.STIGs[i].STIGID) == nil {
+			diff.STIGs = append(diff.STIGs, diffSTIGs(&old.STIGs[i], nil))
+		}
+	}
+	return diff
+}
+
+// diffSTIGs compares two revisions of a STIG, either of which can be missing
+func diffSTIGs(old *STIG, new *STIG) STIGDiff {
+	if old == nil {
+		old = &STIG{}
+	}
+	if new == nil {
+		new = &STIG{STIGID: old.STIGID, DisplayName: old.DisplayName}
+	}
+	diff := STIGDiff{
+		STIGID:        new.STIGID,
+		DisplayName:   new.DisplayName,
+		OldRelease:    old.ReleaseInfo,
+		NewRelease:    new.ReleaseInfo,
+		Added:         []DiffRule{},
+		Removed:       []DiffRule{},
+		StatusChanges: []StatusChange{},
+		TextChanges:   []TextChange{},
+	}
+
+	oldRules := map[string]*Rule{}
+	for i := range old.Rules {
+		oldRules[old.Rules[i].RuleVersion] = &old.Rules[i]
+	}
+	newRules := map[string]bool{}
+	for i := range new.Rules {
+		r := &new.Rules[i]
+		newRules[r.RuleVersion] = true
+		o, found := oldRules[r.RuleVersion]
+		if !found {
+			diff.Added = append(diff.Added, diffRule(r))
+			continue
+		}
+		if o.Status != r.Status {
+			diff.StatusChanges = append(diff.StatusChanges, StatusChange{
+				RuleVersion: r.RuleVersion,
+				GroupID:     r.GroupID,
+				Title:       r.RuleTitle,
+				OldStatus:   o.Status,
+				NewStatus:   r.Status,
+			})
+		}
+		if fields := changedFields(o, r); len(fields) > 0 {
+			diff.TextChanges = append(diff.TextChanges, TextChange{
+				RuleVersion: r.RuleVersion,
+				GroupID:     r.GroupID,
+				Title:       r.RuleTitle,
+				Fields:      fields,
+			})
+		}
+	}
+	for i := range old.Rules {
+		if !newRules[old.Rules[i].RuleVersion] {
+			diff.Removed = append(diff.Removed, diffRule(&old.Rules[i]))
+		}
+	}
+	return diff
+}
+
+func diffRule(r *Rule) DiffRule {
+	return DiffRule{RuleVersion: r.RuleVersion, GroupID: r.GroupID, Severity: r.Severity, Title: r.RuleTitle, Status: r.Status}
+}
+
+// changedFields returns the names of the fields of the rule content DISA changed between revisions
+func changedFields(old *Rule, new *Rule) []string {
+	var fields []string
+	for _, field := range []struct {
+		name     string
+		old, new string
+	}{
+		{"group_id", old.GroupID, new.GroupID},
+		{"rule_id", ruleIDWithoutRevision(old.RuleID), ruleIDWithoutRevision(new.RuleID)},
+		{"severity", old.Severity, new.Severity},
+		{"rule_title", old.RuleTitle, new.RuleTitle},
+		{"discussion", old.Discussion, new.Discussion},
+		{"check_content", old.CheckContent, new.CheckContent},
+		{"fix_text", old.FixText, new.FixText},
+	} {
+		if strings.TrimSpace(field.old) != strings.TrimSpace(field.new) {
+			fields = append(fields, field.name)
+		}
+	}
+	if !slices.Equal(old.CCIs, new.CCIs) {
+		fields = append(fields, "ccis")
+	}
+	return fields
+}
+
+// ruleRevision matches the revision suffix of a rule ID, e.g. r961029 of SV-222400r961029_rule
+var ruleRevision = regexp.MustCompile(`r\d+(_rule)?$`)
+
+// ruleIDWithoutRevision strips the revision DISA bumps in every release of a STIG from a rule ID
+func ruleIDWithoutRevision(ruleID string) string {
+	return ruleRevision.ReplaceAllString(ruleID, "")
+}
+
+// RenderDiff renders checklist differences in the given output format.
+func RenderDiff(diff ChecklistDiff, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case DiffFormatMarkdown, "":
+		return renderDiffMarkdown(diff)
+	case DiffFormatJSON:
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		// check content and discussions quote XML and shell snippets, keep them readable
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buffer.String(), "\n"), nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q, expected one of: %s", format, strings.Join(DiffFormats, ", "))
+	}
+}
+
+func renderDiffMarkdown(diff ChecklistDiff) (string, error) {
+	var outputBuilder strings.Builder
+	for _, s := range diff.STIGs {
+		if outputBuilder.Len() > 0 {
+			outputBuilder.WriteString("\n\n")
+		}
+		fmt.Fprintf(&outputBuilder, "### %s (%s)\n\n", coalesce(s.DisplayName, s.STIGID), s.STIGID)
+		if s.OldRelease != s.NewRelease {
+			fmt.Fprintf(&outputBuilder, "%s -> %s\n\n", coalesce(s.OldRelease, "none"), coalesce(s.NewRelease, "none"))
+		}
+		fmt.Fprintf(&outputBuilder, "Added rules: %d\n", len(s.Added))
+		fmt.Fprintf(&outputBuilder, "Removed rules: %d\n", len(s.Removed))
+		fmt.Fprintf(&outputBuilder, "Status changes: %d\n", len(s.StatusChanges))
+		fmt.Fprintf(&outputBuilder, "Text changes: %d\n\n", len(s.TextChanges))
+
+		ruleRows := func(rules []DiffRule) [][]string {
+			rows := make([][]string, 0, len(rules))
+			for _, r := range rules {
+				rows = append(rows, []string{r.RuleVersion, r.GroupID, r.Severity, r.Title, r.Status})
+			}
+			return rows
+		}
+		statusRows := make([][]string, 0, len(s.StatusChanges))
+		for _, c := range s.StatusChanges {
+			statusRows = append(statusRows, []string{c.RuleVersion, c.GroupID, c.Title, c.OldStatus, c.NewStatus})
+		}
+		textRows := make([][]string, 0, len(s.TextChanges))
+		for _, c := range s.TextChanges {
+			textRows = append(textRows, []string{c.RuleVersion, c.GroupID, c.Title, strings.Join(c.Fields, ", ")})
+		}
+		sections := []struct {
+			summary string
+			header  []string
+			rows    [][]string
+		}{
+			{"Status changes", []string{"Rule", "Group", "Title", "Old Status", "New Status"}, statusRows},
+			{"Added rules", []string{"Rule", "Group", "Severity", "Title", "Status"}, ruleRows(s.Added)},
+			{"Removed rules", []string{"Rule", "Group", "Severity", "Title", "Status"}, ruleRows(s.Removed)},
+			{"Text changes", []string{"Rule", "Group", "Title", "Changed"}, textRows},
+		}
+		for _, section := range sections {
+			if len(section.rows) == 0 {
+				continue
+			}
+			tableString := &strings.Builder{}
+			table := newMarkdownTable(tableString)
+			table.Header(section.header)
+			if err := table.Bulk(section.rows); err != nil {
+				return "", err
+			}
+			if err := table.Render(); err != nil {
+				return "", err
+			}
+			outputBuilder.WriteString("<details>\n")
+			fmt.Fprintf(&outputBuilder, "<summary>%s</summary>\n\n", section.summary)
+			outputBuilder.WriteString(tableString.String())
+			outputBuilder.WriteString("\n</details>\n")
+		}
+		outputBuilder.WriteString("\n---\n")
+	}
+	return outputBuilder.String(), nil
+}
+
+func newMarkdownTable(tableString *strings.Builder) *tablewriter.Table {
+	renderConfig :=
+		tablewriter.WithConfig(tablewriter.Config{Header: tw.CellConfig{
+			Alignment:    tw.CellAlignment{Global: tw.AlignLeft},
+			ColMaxWidths: tw.CellWidth{Global: 10000},
+		}})
+
+	return tablewriter.NewTable(tableString, tablewriter.WithRenderer(renderer.NewMarkdown()), renderConfig)
+}
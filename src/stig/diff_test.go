@@ -0,0 +1,106 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func diffFixture() (*Checklist, *Checklist) {
+	old := &Checklist{STIGs: []STIG{{
+		STIGID:      "Application_Security_Development_STIG",
+		DisplayName: "Application Security and Development",
+		ReleaseInfo: "Release: 4 Benchmark Date: 01 Jan 2025",
+		Rules: []Rule{
+			{RuleVersion: "APSC-DV-000160", GroupID: "V-222400", RuleID: "SV-222400r1", RuleTitle: "Encryption", Severity: "medium", CheckContent: "Verify encryption.", Status: "not_a_finding"},
+			{RuleVersion: "APSC-DV-002900", GroupID: "V-222602", RuleID: "SV-222602r1", RuleTitle: "Audit storage", Severity: "medium", CheckContent: "Verify audit storage.", Status: "not_reviewed"},
+			{RuleVersion: "APSC-DV-001680", GroupID: "V-222601", RuleID: "SV-222601r1", RuleTitle: "Password length", Severity: "high", Status: "not_applicable"},
+		},
+	}}}
+	new := &Checklist{STIGs: []STIG{{
+		STIGID:      "Application_Security_Development_STIG",
+		DisplayName: "Application Security and Development",
+		ReleaseInfo: "Release: 5 Benchmark Date: 01 Jul 2025",
+		Rules: []Rule{
+			{RuleVersion: "APSC-DV-000160", GroupID: "V-222400", RuleID: "SV-222400r1", RuleTitle: "Encryption", Severity: "medium", CheckContent: "Verify encryption.", Status: "open"},
+			{RuleVersion: "APSC-DV-002900", GroupID: "V-222602", RuleID: "SV-222602r2", RuleTitle: "Audit storage", Severity: "medium", CheckContent: "Verify audit storage is allocated.", Status: "not_reviewed", CCIs: []string{"CCI-001849"}},
+			{RuleVersion: "APSC-DV-003300", GroupID: "V-265000", RuleID: "SV-265000r1", RuleTitle: "Session tokens", Severity: "low", Status: "not_reviewed"},
+		},
+	}}}
+	return old, new
+}
+
+func TestDiffChecklists(t *testing.T) {
+	old, new := diffFixture()
+
+	diff := DiffChecklists(old, new)
+
+	require.Len(t, diff.STIGs, 1)
+	s := diff.STIGs[0]
+	require.Equal(t, "Release: 4 Benchmark Date: 01 Jan 2025", s.OldRelease)
+	require.Equal(t, "Release: 5 Benchmark Date: 01 Jul 2025", s.NewRelease)
+	require.Equal(t, []DiffRule{{RuleVersion: "APSC-DV-003300", GroupID: "V-265000", Severity: "low", Title: "Session tokens", Status: "not_reviewed"}}, s.Added)
+	require.Equal(t, []DiffRule{{RuleVersion: "APSC-DV-001680", GroupID: "V-222601", Severity: "high", Title: "Password length", Status: "not_applicable"}}, s.Removed)
+	require.Equal(t, []StatusChange{{RuleVersion: "APSC-DV-000160", GroupID: "V-222400", Title: "Encryption", OldStatus: "not_a_finding", NewStatus: "open"}}, s.StatusChanges)
+	require.Equal(t, []TextChange{{RuleVersion: "APSC-DV-002900", GroupID: "V-222602", Title: "Audit storage", Fields: []string{"check_content", "ccis"}}}, s.TextChanges)
+	require.False(t, s.Empty())
+
+	require.True(t, DiffChecklists(old, old).STIGs[0].Empty())
+}
+
+func TestChangedFields_RuleID(t *testing.T) {
+	// a new revision of the same rule is not a change, another rule ID is
+	require.Empty(t, changedFields(&Rule{RuleID: "SV-222400r508029"}, &Rule{RuleID: "SV-222400r961029"}))
+	require.Empty(t, changedFields(&Rule{RuleID: "SV-222400r508029_rule"}, &Rule{RuleID: "SV-222400r961029_rule"}))
+	require.Equal(t, []string{"rule_id"}, changedFields(&Rule{RuleID: "SV-222400r961029"}, &Rule{RuleID: "SV-222401r961029"}))
+}
+
+func TestDiffChecklists_MatchesSTIGsByID(t *testing.T) {
+	old, new := diffFixture()
+	rhel9 := STIG{STIGID: "RHEL_9_STIG", DisplayName: "Red Hat Enterprise Linux 9", Rules: []Rule{{RuleVersion: "RHEL-09-215070", Status: "not_applicable"}}}
+	old.STIGs = append(old.STIGs, rhel9)
+	new.STIGs = append([]STIG{{STIGID: "Kubernetes_STIG", Rules: []Rule{{RuleVersion: "CNTR-K8-000110"}}}}, new.STIGs...)
+
+	diff := DiffChecklists(old, new)
+
+	require.Len(t, diff.STIGs, 3)
+	require.Equal(t, "Kubernetes_STIG", diff.STIGs[0].STIGID)
+	require.Len(t, diff.STIGs[0].Added, 1)
+	require.Equal(t, "Application_Security_Development_STIG", diff.STIGs[1].STIGID)
+	require.Len(t, diff.STIGs[1].StatusChanges, 1)
+	require.Equal(t, "RHEL_9_STIG", diff.STIGs[2].STIGID)
+	require.Equal(t, "Red Hat Enterprise Linux 9", diff.STIGs[2].DisplayName)
+	require.Len(t, diff.STIGs[2].Removed, 1)
+	require.Empty(t, diff.STIGs[2].Added)
+}
+
+func TestRenderDiff(t *testing.T) {
+	old, new := diffFixture()
+	diff := DiffChecklists(old, new)
+
+	markdown, err := RenderDiff(diff, DiffFormatMarkdown)
+	require.NoError(t, err)
+	for _, expected := range []string{
+		"### Application Security and Development (Application_Security_Development_STIG)\n\n",
+		"Release: 4 Benchmark Date: 01 Jan 2025 -> Release: 5 Benchmark Date: 01 Jul 2025\n\n",
+		"Added rules: 1\nRemoved rules: 1\nStatus changes: 1\nText changes: 1\n",
+		"<summary>Status changes</summary>",
+		"| APSC-DV-000160 | V-222400 | Encryption | not_a_finding | open       |",
+		"| APSC-DV-002900 | V-222602 | Audit storage | check_content, ccis |",
+	} {
+		require.Contains(t, markdown, expected)
+	}
+
+	output, err := RenderDiff(diff, DiffFormatJSON)
+	require.NoError(t, err)
+	var decoded ChecklistDiff
+	require.NoError(t, json.Unmarshal([]byte(output), &decoded))
+	require.Equal(t, diff, decoded)
+
+	_, err = RenderDiff(diff, "html")
+	require.ErrorContains(t, err, `unsupported output format "html"`)
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Output formats of evaluation traces.
+const (
+	ExplainFormatText = "text"
+	ExplainFormatJSON = "json"
+)
+
+// ExplainFormats lists the supported output formats of evaluation traces.
+var ExplainFormats = []string{ExplainFormatText, ExplainFormatJSON}
+
+// Explanation traces how the rules of the STIGs of a checklist got their automated status.
+type Explanation struct {
+	STIGs []STIGExplanation `json:"stigs"`
+}
+
+// STIGExplanation traces the evaluation of the rules of a STIG.
+type STIGExplanation struct {
+	STIGID      string            `json:"stigId"`
+	DisplayName string            `json:"displayName"`
+	Rules       []RuleExplanation `json:"rules"`
+}
+
+// RuleExplanation traces the evaluation of a rule and the profile override applied to it.
+type RuleExplanation struct {
+	RuleVersion string `json:"ruleVersion"`
+	GroupID     string `json:"groupId"`
+	Title       string `json:"title"`
+	// Status is the status of the rule in the checklist, after the profile override
+	Status     string     `json:"status"`
+	Evaluation Evaluation `json:"evaluation"`
+	// Overridden lists the fields the profile override replaced: status, finding_details and comments
+	Overridden []string `json:"overridden"`
+}
+
+// ExplainSTIG traces the evaluation of the rules of a STIG parsed for the selected STIG of the profile.
+func ExplainSTIG(profile *Profile, s *STIG) (STIGExplanation, error) {
+	definition, err := definitionForProfile(profile)
+	if err != nil {
+		return STIGExplanation{}, err
+	}
+	explanation := STIGExplanation{STIGID: s.STIGID, DisplayName: s.DisplayName, Rules: []RuleExplanation{}}
+	for _, r := range s.Rules {
+		// rules are evaluated with the group ID of the XCCDF, as when the checklist is generated
+		evaluation := ExplainEvaluation(profile, definition.ID, coalesce(r.GroupIDSrc, r.GroupID), r.RuleVersion, r.RuleTitle, r.CheckContent)
+		rule := RuleExplanation{
+			RuleVersion: r.RuleVersion,
+			GroupID:     r.GroupID,
+			Title:       r.RuleTitle,
+			Status:      evaluation.Status,
+			Evaluation:  evaluation,
+			Overridden:  []string{},
+		}
+		if ov, ok := profile.Overrides[r.RuleVersion]; ok {
+			if ov.Status != "" {
+				rule.Status = ov.Status
+				rule.Overridden = append(rule.Overridden, "status")
+			}
+			if ov.FindingDetails != "" {
+				rule.Overridden = append(rule.Overridden, "finding_details")
+			}
+			if ov.Comments != "" {
+				rule.Overridden = append(rule.Overridden, "comments")
+			}
+		}
+		explanation.Rules = append(explanation.Rules, rule)
+	}
+	return explanation, nil
+}
+
+// RenderExplanation renders an evaluation trace in the given output format.
+func RenderExplanation(explanation Explanation, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case ExplainFormatText, "":
+		return renderExplanationText(explanation), nil
+	case ExplainFormatJSON:
+		var buffer bytes.Buffer
+		encoder := json.NewEncoder(&buffer)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(explanation); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buffer.String(), "\n"), nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q, expected one of: %s", format, strings.Join(ExplainFormats, ", "))
+	}
+}
+
+func renderExplanationText(explanation Explanation) string {
+	var outputBuilder strings.Builder
+	for _, s := range explanation.STIGs {
+		if outputBuilder.Len() > 0 {
+			outputBuilder.WriteString("\n")
+		}
+		fmt.Fprintf(&outputBuilder, "%s (%s)\n", coalesce(s.DisplayName, s.STIGID), s.STIGID)
+		for _, r := range s.Rules {
+			e := r.Evaluation
+			fmt.Fprintf(&outputBuilder, "\n%s (%s): %s\n", r.RuleVersion, r.GroupID, r.Status)
+			if e.Rule == "" {
+				outputBuilder.WriteString("  no evaluation rule matched\n")
+			} else {
+				fmt.Fprintf(&outputBuilder, "  rule %s of %s: %s\n", e.Rule, e.RuleSet, e.Status)
+			}
+			for _, condition := range e.Conditions {
+				fmt.Fprintf(&outputBuilder, "    %s\n", condition)
+			}
+			if len(e.ProfileFields) > 0 {
+				fields := make([]string, 0, len(e.ProfileFields))
+				for _, field := range e.ProfileFields {
+					if text, ok := field.Value.(string); ok {
+						fields = append(fields, fmt.Sprintf("%s=%q", field.Key, text))
+					} else {
+						fields = append(fields, fmt.Sprintf("%s=%v", field.Key, field.Value))
+					}
+				}
+				fmt.Fprintf(&outputBuilder, "  profile: %s\n", strings.Join(fields, ", "))
+			}
+			if len(r.Overridden) > 0 {
+				fmt.Fprintf(&outputBuilder, "  overridden by the profile: %s\n", strings.Join(r.Overridden, ", "))
+			}
+		}
+	}
+	return outputBuilder.String()
+}
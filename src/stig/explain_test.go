@@ -0,0 +1,122 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func explainFixture(t *testing.T) Explanation {
+	t.Helper()
+	p := *testProfile
+	p.Overrides = map[string]Override{"APSC-DV-002900": {Status: "not_a_finding", Comments: "Retention is set to a year."}}
+	s, err := ParseXCCDF(writeXCCDFFixture(t, t.TempDir()), &p)
+	require.NoError(t, err)
+
+	explained, err := ExplainSTIG(&p, s)
+	require.NoError(t, err)
+	return Explanation{STIGs: []STIGExplanation{explained}}
+}
+
+func TestExplainSTIG(t *testing.T) {
+	explanation := explainFixture(t)
+
+	require.Len(t, explanation.STIGs, 1)
+	s := explanation.STIGs[0]
+	require.Equal(t, "Application_Security_Development_STIG", s.STIGID)
+	require.Len(t, s.Rules, 2)
+
+	encryption := s.Rules[0]
+	require.Equal(t, "APSC-DV-000160", encryption.RuleVersion)
+	require.Equal(t, "V-100001", encryption.GroupID)
+	require.Equal(t, "not_a_finding", encryption.Status)
+	require.Equal(t, "rules/asd_v6r4.yaml", encryption.Evaluation.RuleSet)
+	require.Equal(t, "encryption-tls", encryption.Evaluation.Rule)
+	require.Equal(t, []string{"rule version is APSC-DV-000160", "profile service_mesh is set"}, encryption.Evaluation.Conditions)
+	require.Equal(t, []ProfileField{{Key: "service_mesh", Value: "Istio"}}, encryption.Evaluation.ProfileFields)
+	require.Empty(t, encryption.Overridden)
+
+	retention := s.Rules[1]
+	require.Equal(t, "not_a_finding", retention.Status)
+	require.Equal(t, "not_reviewed", retention.Evaluation.Status)
+	require.Equal(t, "audit-trail-retention", retention.Evaluation.Rule)
+	require.Equal(t, []string{"status", "comments"}, retention.Overridden)
+}
+
+func TestExplainSTIG_SameGroupIDAsGenerate(t *testing.T) {
+	ruleSet, err := parseRuleSet([]byte(`kind: UDS STIG Rules
+rules:
+  - id: group-review
+    when: { rule_versions: [APSC-DV-000160] }
+    status: open
+    finding_details: "{{if eq .GroupID \"xccdf_mil.disa.stig_group_V-100001\"}}Reviewed with the group.{{end}}"
+`), "group-rules.yaml")
+	require.NoError(t, err)
+	p := *testProfile
+	p.RuleSets = []*RuleSet{ruleSet}
+	xccdfPath := filepath.Join(t.TempDir(), "test-xccdf.xml")
+	require.NoError(t, os.WriteFile(xccdfPath, []byte(strings.ReplaceAll(minimalXCCDF, `<Group id="V-`, `<Group id="xccdf_mil.disa.stig_group_V-`)), 0644))
+
+	s, err := ParseXCCDF(xccdfPath, &p)
+	require.NoError(t, err)
+	explained, err := ExplainSTIG(&p, s)
+	require.NoError(t, err)
+
+	require.Equal(t, "V-100001", explained.Rules[0].GroupID)
+	for i, r := range s.Rules {
+		require.Equal(t, r.Status, explained.Rules[i].Status, r.RuleVersion)
+		require.Equal(t, r.FindingDetails, explained.Rules[i].Evaluation.FindingDetails, r.RuleVersion)
+	}
+	require.Equal(t, "Reviewed with the group.", explained.Rules[0].Evaluation.FindingDetails)
+}
+
+func TestExplainEvaluation(t *testing.T) {
+	p := *evalProfile
+	p.Chars.Language = "Go"
+
+	evaluation := ExplainEvaluation(&p, ASDSTIGProfileKey, "V-1", "APSC-DV-002590", "Overflow attacks", "Check it.")
+	require.Equal(t, "overflow-attacks", evaluation.Rule)
+	require.Equal(t, []string{"rule version is APSC-DV-002590", "profile language is any of python, go, java, ruby, javascript, typescript"}, evaluation.Conditions)
+
+	evaluation = ExplainEvaluation(&p, ASDSTIGProfileKey, "V-1", "APSC-DV-999999", "The application must set the SAML NotOnOrAfter condition.", "Check it.")
+	require.Equal(t, "saml-assertion-attributes", evaluation.Rule)
+	require.Equal(t, []string{`title contains "saml"`, `title contains "notonorafter"`, "profile uses_saml is false"}, evaluation.Conditions)
+	require.Equal(t, []ProfileField{{Key: "uses_saml", Value: false}}, evaluation.ProfileFields)
+
+	evaluation = ExplainEvaluation(&p, "windows_server_2022_v2r2", "V-1", "WN22-00-000010", "Windows", "Check it.")
+	require.Equal(t, "not_reviewed", evaluation.Status)
+	require.Empty(t, evaluation.Rule)
+	require.Empty(t, evaluation.Conditions)
+}
+
+func TestRenderExplanation(t *testing.T) {
+	explanation := explainFixture(t)
+
+	text, err := RenderExplanation(explanation, ExplainFormatText)
+	require.NoError(t, err)
+	for _, expected := range []string{
+		"Application Security and Development (Application_Security_Development_STIG)\n",
+		"\nAPSC-DV-000160 (V-100001): not_a_finding\n  rule encryption-tls of rules/asd_v6r4.yaml: not_a_finding\n    rule version is APSC-DV-000160\n    profile service_mesh is set\n  profile: service_mesh=\"Istio\"\n",
+		"\nAPSC-DV-002900 (V-100002): not_a_finding\n  rule audit-trail-retention of rules/asd_v6r4.yaml: not_reviewed\n    rule version is APSC-DV-002900\n  overridden by the profile: status, comments\n",
+	} {
+		require.Contains(t, text, expected)
+	}
+
+	output, err := RenderExplanation(explanation, ExplainFormatJSON)
+	require.NoError(t, err)
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal([]byte(output), &decoded))
+	rules := decoded["stigs"].([]any)[0].(map[string]any)["rules"].([]any)
+	require.Equal(t, "encryption-tls", rules[0].(map[string]any)["evaluation"].(map[string]any)["rule"])
+	require.Equal(t, []any{"status", "comments"}, rules[1].(map[string]any)["overridden"])
+
+	_, err = RenderExplanation(explanation, "html")
+	require.ErrorContains(t, err, `unsupported output format "html"`)
+}
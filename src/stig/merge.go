@@ -0,0 +1,164 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MergeResult describes what MergeChecklist carried over from the previous checklist.
+type MergeResult struct {
+	// Carried is the number of rules whose review was carried over
+	Carried   int
+	Conflicts []MergeConflict
+}
+
+// MergeConflict is a reviewed rule the automated evaluation now disagrees with. The review is kept.
+type MergeConflict struct {
+	STIGID          string
+	RuleVersion     string
+	GroupID         string
+	ReviewedStatus  string
+	EvaluatedStatus string
+}
+
+func (c MergeConflict) String() string {
+	return fmt.Sprintf("%s (%s): reviewed as %s, evaluated as %s", c.RuleVersion, c.GroupID, c.ReviewedStatus, c.EvaluatedStatus)
+}
+
+// LoadChecklist reads a .cklb or, by its extension, a .ckl checklist.
+func LoadChecklist(path string) (*Checklist, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if strings.EqualFold(filepath.Ext(path), "."+FormatCKL) {
+		return ParseCKL(data)
+	}
+	var checklist Checklist
+	if err := json.Unmarshal(data, &checklist); err != nil {
+		return nil, fmt.Errorf("parsing CKLB %s: %w", path, err)
+	}
+	return &checklist, nil
+}
+
+// MergeChecklist carries the reviews of a previous checklist over to a newly generated one, so that what
+// assessors recorded survives regeneration. STIGs are matched by STIG ID and rules by rule version, or group ID
+// when the rule version changed, and keep the UUIDs of the previous checklist. The checklist keeps the ID of the
+// previous one when both cover the same STIGs.
+//
+// A previous rule counts as reviewed when its status is anything but not_reviewed and it is not an automated
+// disposition, i.e. its status, finding details and comments are not what an evaluation rule gives it for the
+// profile the STIG was generated with, keyed by STIG ID. Automated dispositions follow the profile, so they are
+// evaluated again rather than carried over when, e.g., a characteristic changed. The status, finding details and
+// comments of a reviewed rule replace the evaluated ones, except for rules with a profile override, as the
+// profile always wins. When the evaluation now reaches a different status than the review, the review is kept
+// and reported as a conflict. Rules left not_reviewed keep the evaluated status, but the finding details and
+// comments an assessor added to them are carried over.
+func MergeChecklist(checklist *Checklist, previous *Checklist, profiles map[string]*Profile) MergeResult {
+	var result MergeResult
+	if previous.ID != "" && sameSTIGs(checklist, previous) {
+		checklist.ID = previous.ID
+	}
+	for i := range checklist.STIGs {
+		s := &checklist.STIGs[i]
+		prev := findSTIG(previous, s.STIGID)
+		if prev == nil {
+			continue
+		}
+		if prev.UUID != "" {
+			s.UUID = prev.UUID
+		}
+
+		byVersion := map[string]*Rule{}
+		byGroup := map[string]*Rule{}
+		for j := range prev.Rules {
+			byVersion[prev.Rules[j].RuleVersion] = &prev.Rules[j]
+			byGroup[prev.Rules[j].GroupID] = &prev.Rules[j]
+		}
+
+		for j := range s.Rules {
+			r := &s.Rules[j]
+			r.SIGUUID = s.UUID
+			prevRule, ok := byVersion[r.RuleVersion]
+			if !ok {
+				if prevRule, ok = byGroup[r.GroupID]; !ok {
+					continue
+				}
+			}
+			if prevRule.UUID != "" {
+				r.UUID = prevRule.UUID
+			}
+
+			profile := profiles[s.STIGID]
+			if profile != nil {
+				if _, overridden := profile.Overrides[r.RuleVersion]; overridden || isAutomatedDisposition(profile, *prevRule) {
+					continue
+				}
+			}
+			if prevRule.Status == "not_reviewed" || prevRule.Status == "" {
+				if carryNotes(r, prevRule, profile) {
+					result.Carried++
+				}
+				continue
+			}
+			if r.Status != "not_reviewed" && r.Status != prevRule.Status {
+				result.Conflicts = append(result.Conflicts, MergeConflict{
+					STIGID:          s.STIGID,
+					RuleVersion:     r.RuleVersion,
+					GroupID:         r.GroupID,
+					ReviewedStatus:  prevRule.Status,
+					EvaluatedStatus: r.Status,
+				})
+			}
+			r.Status = prevRule.Status
+			r.FindingDetails = prevRule.FindingDetails
+			r.Comments = prevRule.Comments
+			result.Carried++
+		}
+	}
+	return result
+}
+
+// carryNotes carries the finding details and comments of a rule left not_reviewed over, without its status. Finding
+// details that only say the rule requires manual review are not notes and keep the evaluated ones.
+func carryNotes(r *Rule, prevRule *Rule, profile *Profile) bool {
+	details := prevRule.FindingDetails
+	if profile != nil && details == manualReviewDetails(prevRule.RuleVersion, profile) {
+		details = ""
+	}
+	if details == "" && prevRule.Comments == "" {
+		return false
+	}
+	if details != "" {
+		r.FindingDetails = details
+	}
+	r.Comments = prevRule.Comments
+	return true
+}
+
+func findSTIG(checklist *Checklist, stigID string) *STIG {
+	for i := range checklist.STIGs {
+		if checklist.STIGs[i].STIGID == stigID {
+			return &checklist.STIGs[i]
+		}
+	}
+	return nil
+}
+
+func sameSTIGs(checklist *Checklist, other *Checklist) bool {
+	if len(checklist.STIGs) != len(other.STIGs) {
+		return false
+	}
+	for _, s := range checklist.STIGs {
+		if findSTIG(other, s.STIGID) == nil {
+			return false
+		}
+	}
+	return true
+}
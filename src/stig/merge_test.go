@@ -0,0 +1,210 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// reviewedChecklist is a generated checklist after an assessor reviewed it: APSC-DV-000160, evaluated as
+// not_a_finding, was found open and APSC-DV-002900, left not_reviewed, was found not_a_finding.
+func reviewedChecklist(t *testing.T) *Checklist {
+	t.Helper()
+	s, err := ParseXCCDF(writeXCCDFFixture(t, t.TempDir()), testProfile)
+	require.NoError(t, err)
+	s.Rules[0].Status = "open"
+	s.Rules[0].FindingDetails = "TLS 1.0 is still enabled."
+	s.Rules[1].Status = "not_a_finding"
+	s.Rules[1].Comments = "Retention is set to 90 days."
+	return BuildChecklist(testProfile, s)
+}
+
+func generatedChecklist(t *testing.T) *Checklist {
+	t.Helper()
+	s, err := ParseXCCDF(writeXCCDFFixture(t, t.TempDir()), testProfile)
+	require.NoError(t, err)
+	return BuildChecklist(testProfile, s)
+}
+
+func TestMergeChecklist(t *testing.T) {
+	previous := reviewedChecklist(t)
+	checklist := generatedChecklist(t)
+
+	result := MergeChecklist(checklist, previous, nil)
+
+	require.Equal(t, 2, result.Carried)
+	require.Equal(t, []MergeConflict{{
+		STIGID:          "Application_Security_Development_STIG",
+		RuleVersion:     "APSC-DV-000160",
+		GroupID:         "V-100001",
+		ReviewedStatus:  "open",
+		EvaluatedStatus: "not_a_finding",
+	}}, result.Conflicts)
+	require.Equal(t, "APSC-DV-000160 (V-100001): reviewed as open, evaluated as not_a_finding", result.Conflicts[0].String())
+
+	// UUIDs are stable
+	require.Equal(t, previous.ID, checklist.ID)
+	s := checklist.STIGs[0]
+	require.Equal(t, previous.STIGs[0].UUID, s.UUID)
+	for i, r := range s.Rules {
+		require.Equal(t, previous.STIGs[0].Rules[i].UUID, r.UUID)
+		require.Equal(t, s.UUID, r.SIGUUID)
+	}
+
+	// reviews are kept
+	require.Equal(t, "open", s.Rules[0].Status)
+	require.Equal(t, "TLS 1.0 is still enabled.", s.Rules[0].FindingDetails)
+	require.Equal(t, "not_a_finding", s.Rules[1].Status)
+	require.Equal(t, "Retention is set to 90 days.", s.Rules[1].Comments)
+}
+
+func TestMergeChecklist_ProfileOverridesWin(t *testing.T) {
+	previous := reviewedChecklist(t)
+	checklist := generatedChecklist(t)
+	evaluated := checklist.STIGs[0].Rules[0]
+
+	profile := *testProfile
+	profile.Overrides = map[string]Override{"APSC-DV-000160": {Status: "not_a_finding"}}
+	result := MergeChecklist(checklist, previous, map[string]*Profile{"Application_Security_Development_STIG": &profile})
+
+	require.Equal(t, 1, result.Carried)
+	require.Empty(t, result.Conflicts)
+	require.Equal(t, evaluated.Status, checklist.STIGs[0].Rules[0].Status)
+	require.Equal(t, evaluated.FindingDetails, checklist.STIGs[0].Rules[0].FindingDetails)
+	require.Equal(t, previous.STIGs[0].Rules[0].UUID, checklist.STIGs[0].Rules[0].UUID)
+}
+
+func TestMergeChecklist_ProfileChanged(t *testing.T) {
+	// APSC-DV-002900 becomes a PKI rule, evaluated as not_applicable for applications that do not use PKI
+	xccdfPath := filepath.Join(t.TempDir(), "test-xccdf.xml")
+	require.NoError(t, os.WriteFile(xccdfPath, []byte(strings.Replace(minimalXCCDF, "APSC-DV-002900", "APSC-DV-001550", 1)), 0644))
+	build := func(p *Profile) *Checklist {
+		s, err := ParseXCCDF(xccdfPath, p)
+		require.NoError(t, err)
+		return BuildChecklist(p, s)
+	}
+
+	withoutPKI := *testProfile
+	previous := build(&withoutPKI)
+	require.Equal(t, "not_applicable", previous.STIGs[0].Rules[1].Status)
+	previous.STIGs[0].Rules[0].Status = "open"
+	previous.STIGs[0].Rules[0].FindingDetails = "TLS 1.0 is still enabled."
+
+	withPKI := *testProfile
+	withPKI.Chars.UsesPKI = true
+	checklist := build(&withPKI)
+	evaluated := checklist.STIGs[0].Rules[1]
+	require.NotEqual(t, "not_applicable", evaluated.Status)
+
+	result := MergeChecklist(checklist, previous, map[string]*Profile{"Application_Security_Development_STIG": &withPKI})
+
+	// the automated disposition follows the profile, the review is kept
+	require.Equal(t, 1, result.Carried)
+	require.Len(t, result.Conflicts, 1)
+	require.Equal(t, "APSC-DV-000160", result.Conflicts[0].RuleVersion)
+	require.Equal(t, "open", checklist.STIGs[0].Rules[0].Status)
+	require.Equal(t, evaluated.Status, checklist.STIGs[0].Rules[1].Status)
+	require.Equal(t, evaluated.FindingDetails, checklist.STIGs[0].Rules[1].FindingDetails)
+	require.Equal(t, previous.STIGs[0].Rules[1].UUID, checklist.STIGs[0].Rules[1].UUID)
+
+	// without the profile it was generated with, the previous disposition counts as a review
+	checklist = build(&withPKI)
+	result = MergeChecklist(checklist, previous, nil)
+	require.Equal(t, 2, result.Carried)
+	require.Equal(t, "not_applicable", checklist.STIGs[0].Rules[1].Status)
+}
+
+func TestMergeChecklist_MatchesGroupIDWhenRuleVersionChanged(t *testing.T) {
+	previous := reviewedChecklist(t)
+	previous.STIGs[0].Rules[1].RuleVersion = "APSC-DV-002899"
+	previous.STIGs[0].Rules[0].Status = "not_reviewed"
+	previous.STIGs[0].Rules[0].FindingDetails = ""
+	checklist := generatedChecklist(t)
+
+	result := MergeChecklist(checklist, previous, nil)
+
+	require.Equal(t, 1, result.Carried)
+	require.Empty(t, result.Conflicts)
+	require.Equal(t, "not_a_finding", checklist.STIGs[0].Rules[0].Status)
+	require.Equal(t, "not_a_finding", checklist.STIGs[0].Rules[1].Status)
+	require.Equal(t, previous.STIGs[0].Rules[1].UUID, checklist.STIGs[0].Rules[1].UUID)
+}
+
+func TestMergeChecklist_CarriesNotesOfNotReviewedRules(t *testing.T) {
+	profiles := map[string]*Profile{"Application_Security_Development_STIG": testProfile}
+	previous := generatedChecklist(t)
+	require.Equal(t, "not_reviewed", previous.STIGs[0].Rules[1].Status)
+	previous.STIGs[0].Rules[1].Comments = "Waiting for the retention policy of the logging team."
+	checklist := generatedChecklist(t)
+	evaluated := checklist.STIGs[0].Rules[1]
+
+	result := MergeChecklist(checklist, previous, profiles)
+
+	require.Equal(t, 1, result.Carried)
+	require.Empty(t, result.Conflicts)
+	require.Equal(t, "not_reviewed", checklist.STIGs[0].Rules[1].Status)
+	require.Equal(t, evaluated.FindingDetails, checklist.STIGs[0].Rules[1].FindingDetails)
+	require.Equal(t, "Waiting for the retention policy of the logging team.", checklist.STIGs[0].Rules[1].Comments)
+
+	// finding details are carried over as well, but not the status
+	previous.STIGs[0].Rules[1].FindingDetails = "Audit records are kept for 30 days."
+	checklist = generatedChecklist(t)
+	checklist.STIGs[0].Rules[1].Status = "not_a_finding"
+	result = MergeChecklist(checklist, previous, profiles)
+	require.Equal(t, 1, result.Carried)
+	require.Equal(t, "not_a_finding", checklist.STIGs[0].Rules[1].Status)
+	require.Equal(t, "Audit records are kept for 30 days.", checklist.STIGs[0].Rules[1].FindingDetails)
+	require.Equal(t, "Waiting for the retention policy of the logging team.", checklist.STIGs[0].Rules[1].Comments)
+
+	// a rule left not_reviewed without notes carries nothing
+	result = MergeChecklist(generatedChecklist(t), generatedChecklist(t), profiles)
+	require.Zero(t, result.Carried)
+}
+
+func TestMergeChecklist_OtherSTIGs(t *testing.T) {
+	previous := reviewedChecklist(t)
+	previous.STIGs[0].STIGID = "RHEL_9_STIG"
+	checklist := generatedChecklist(t)
+	id := checklist.ID
+
+	result := MergeChecklist(checklist, previous, nil)
+
+	require.Zero(t, result.Carried)
+	require.Equal(t, id, checklist.ID)
+	require.NotEqual(t, previous.STIGs[0].UUID, checklist.STIGs[0].UUID)
+}
+
+func TestLoadChecklist(t *testing.T) {
+	dir := t.TempDir()
+	checklist := reviewedChecklist(t)
+
+	cklb, err := json.Marshal(checklist)
+	require.NoError(t, err)
+	cklbPath := filepath.Join(dir, "previous.cklb")
+	require.NoError(t, os.WriteFile(cklbPath, cklb, 0644))
+	loaded, err := LoadChecklist(cklbPath)
+	require.NoError(t, err)
+	require.Equal(t, checklist, loaded)
+
+	ckl, err := MarshalCKL(checklist)
+	require.NoError(t, err)
+	cklPath := filepath.Join(dir, "previous.ckl")
+	require.NoError(t, os.WriteFile(cklPath, ckl, 0644))
+	loaded, err = LoadChecklist(cklPath)
+	require.NoError(t, err)
+	require.Equal(t, "open", loaded.STIGs[0].Rules[0].Status)
+	require.Equal(t, checklist.STIGs[0].UUID, loaded.STIGs[0].UUID)
+
+	_, err = LoadChecklist(filepath.Join(dir, "missing.cklb"))
+	require.Error(t, err)
+	require.NoError(t, os.WriteFile(cklbPath, []byte("<CHECKLIST/>"), 0644))
+	_, err = LoadChecklist(cklbPath)
+	require.ErrorContains(t, err, "parsing CKLB")
+}
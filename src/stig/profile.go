@@ -6,14 +6,15 @@ package stig
 import (
 	"fmt"
 	"os"
-
-	"gopkg.in/yaml.v3"
 )
 
 const (
-	ProfileKind           = "UDS STIG Profile"
-	ASDSTIGProfileKey     = "asd_v6r4"
-	RHEL9STIGProfileKey   = "rhel9_v2r7"
+	ProfileKind                    = "UDS STIG Profile"
+	ASDSTIGProfileKey              = "asd_v6r4"
+	RHEL9STIGProfileKey            = "rhel9_v2r7"
+	KubernetesSTIGProfileKey       = "kubernetes_v2r3"
+	ContainerPlatformSRGProfileKey = "container_platform_v2r1"
+	WebServerSRGProfileKey         = "web_server_v4r1"
 )
 
 func LoadProfile(path string) (*Profile, error) {
@@ -21,26 +22,14 @@ func LoadProfile(path string) (*Profile, error) {
 	if err != nil {
 		return nil, fmt.Errorf("reading %s: %w", path, err)
 	}
-	var p Profile
-	if err := yaml.Unmarshal(data, &p); err != nil {
+	p, problems, err := parseProfile(data)
+	if err != nil {
 		return nil, fmt.Errorf("parsing %s: %w", path, err)
 	}
-	p.AppName = p.Metadata.Name
-	p.FQDN = p.Metadata.FQDN
-	p.Description = p.Metadata.Description
-	if p.Kind != "" && p.Kind != ProfileKind {
-		return nil, fmt.Errorf("kind must be %q in %s", ProfileKind, path)
-	}
-	if selected := p.selectDefaultSTIG(); selected != nil {
-		p.SelectedSTIG = selected
-		p.Chars = selected.Characteristics
-		p.Platform = selected.Platform
-		p.Overrides = selected.Overrides
+	if len(problems) > 0 {
+		return nil, &ProfileError{Path: path, Problems: problems}
 	}
-	if p.AppName == "" {
-		return nil, fmt.Errorf("metadata.name is required in %s", path)
-	}
-	return &p, nil
+	return p, nil
 }
 
 func (p *Profile) SelectSTIG(id string) *STIGProfile {
@@ -52,11 +41,40 @@ func (p *Profile) SelectSTIG(id string) *STIGProfile {
 	return nil
 }
 
-func (p *Profile) selectDefaultSTIG() *STIGProfile {
+// UseSTIG makes the STIG with the id the one checklists are generated for, in place of the first supported one.
+func (p *Profile) UseSTIG(id string) error {
+	selected := p.SelectSTIG(id)
+	if selected == nil {
+		return fmt.Errorf("STIG %q is not listed in the profile", id)
+	}
+	if _, err := LookupSTIGDefinition(id); err != nil {
+		return err
+	}
+	p.use(selected)
+	return nil
+}
+
+// SupportedSTIGs returns the STIGs of the profile checklists can be generated for, in profile order.
+func (p *Profile) SupportedSTIGs() []*STIGProfile {
+	var supported []*STIGProfile
 	for i := range p.STIGs {
 		if _, err := LookupSTIGDefinition(p.STIGs[i].ID); err == nil {
-			return &p.STIGs[i]
+			supported = append(supported, &p.STIGs[i])
 		}
 	}
+	return supported
+}
+
+func (p *Profile) selectDefaultSTIG() *STIGProfile {
+	if supported := p.SupportedSTIGs(); len(supported) > 0 {
+		return supported[0]
+	}
 	return nil
 }
+
+func (p *Profile) use(selected *STIGProfile) {
+	p.SelectedSTIG = selected
+	p.Chars = selected.Characteristics
+	p.Platform = selected.Platform
+	p.Overrides = selected.Overrides
+}
@@ -115,6 +115,46 @@ stigs:
 	require.Equal(t, "Standalone Kubernetes server", profile.Platform.HostRole)
 }
 
+func TestProfile_UseSTIG(t *testing.T) {
+	dir := t.TempDir()
+	profilePath := filepath.Join(dir, "multi-profile.yaml")
+	content := `
+kind: UDS STIG Profile
+metadata:
+  name: test-app
+stigs:
+  - id: asd_v6r4
+    characteristics:
+      uses_saml: true
+  - id: unsupported_v0r0
+  - id: rhel9_v2r7
+    platform:
+      host_role: Standalone Kubernetes server
+    overrides:
+      RHEL-09-000001:
+        status: not_a_finding
+`
+	err := os.WriteFile(profilePath, []byte(content), 0644)
+	require.NoError(t, err)
+
+	profile, err := LoadProfile(profilePath)
+	require.NoError(t, err)
+	supported := profile.SupportedSTIGs()
+	require.Len(t, supported, 2)
+	require.Equal(t, ASDSTIGProfileKey, supported[0].ID)
+	require.Equal(t, RHEL9STIGProfileKey, supported[1].ID)
+
+	require.NoError(t, profile.UseSTIG(RHEL9STIGProfileKey))
+	require.Equal(t, RHEL9STIGProfileKey, profile.SelectedSTIG.ID)
+	require.False(t, profile.Chars.UsesSAML)
+	require.Equal(t, "Standalone Kubernetes server", profile.Platform.HostRole)
+	require.Contains(t, profile.Overrides, "RHEL-09-000001")
+
+	require.ErrorContains(t, profile.UseSTIG("unsupported_v0r0"), "unsupported STIG")
+	require.ErrorContains(t, profile.UseSTIG("missing_v1r1"), "not listed in the profile")
+	require.Equal(t, RHEL9STIGProfileKey, profile.SelectedSTIG.ID)
+}
+
 func TestLoadProfile_FileNotFound(t *testing.T) {
 	_, err := LoadProfile("/nonexistent/profile.yaml")
 	require.Error(t, err)
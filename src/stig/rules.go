@@ -0,0 +1,460 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleSetKind identifies STIG rule set files.
+const RuleSetKind = "UDS STIG Rules"
+
+// Statuses lists the statuses a rule can be evaluated to.
+var Statuses = []string{"not_a_finding", "not_applicable", "not_reviewed", "open"}
+
+//go:embed rules/*.yaml
+var defaultRuleFiles embed.FS
+
+// RuleSet is a declarative set of evaluation rules for one STIG. The first rule whose conditions match a STIG
+// rule decides its status and finding details.
+type RuleSet struct {
+	Kind string `yaml:"kind"`
+	// STIG is the ID of the STIG the rules evaluate, e.g. asd_v6r4
+	STIG  string           `yaml:"stig"`
+	Rules []EvaluationRule `yaml:"rules"`
+
+	// Source is the file the rule set was loaded from
+	Source string `yaml:"-"`
+}
+
+// EvaluationRule matches STIG rules and decides their status. Finding details and comments are Go templates
+// executed with the profile, e.g. {{.AppName}} or {{.Platform.AuthProvider}}, and the RuleVersion, RuleTitle and
+// GroupID of the STIG rule.
+type EvaluationRule struct {
+	// ID names the rule in explanations
+	ID             string        `yaml:"id"`
+	When           RuleCondition `yaml:"when"`
+	Status         string        `yaml:"status"`
+	FindingDetails string        `yaml:"finding_details"`
+	Comments       string        `yaml:"comments,omitempty"`
+
+	findingDetails *template.Template
+	comments       *template.Template
+}
+
+// RuleCondition holds the conditions of an evaluation rule, which all have to match. A rule without conditions
+// matches every STIG rule.
+type RuleCondition struct {
+	// RuleVersions matches any of the rule versions, e.g. APSC-DV-000160
+	RuleVersions []string `yaml:"rule_versions,omitempty"`
+	// Title and Check match keywords in the rule title and check content, CheckStart in the first 200
+	// characters of the check content
+	Title      Keywords `yaml:"title,omitempty"`
+	Check      Keywords `yaml:"check,omitempty"`
+	CheckStart Keywords `yaml:"check_start,omitempty"`
+	// Profile matches characteristics and platform fields by their profile key. A boolean field matches a
+	// boolean, a text field matches true when it is set, false when it is empty, a text when it is equal, a list
+	// when it is equal to any of its entries and { not: [...] } when it is set and equal to none of them, all
+	// ignoring case.
+	Profile map[string]any `yaml:"profile,omitempty"`
+}
+
+// Keywords match text case-insensitively: it has to contain all of All and, when given, any of Any.
+type Keywords struct {
+	Any []string `yaml:"any,omitempty"`
+	All []string `yaml:"all,omitempty"`
+}
+
+// Evaluation is the outcome of evaluating a STIG rule, with the evaluation rule that decided it.
+type Evaluation struct {
+	Status         string `json:"status"`
+	FindingDetails string `json:"findingDetails"`
+	Comments       string `json:"comments,omitempty"`
+	// RuleSet is the source of the rule set of the evaluation rule, empty when no rule matched
+	RuleSet string `json:"ruleSet,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	// Conditions describes the conditions of the evaluation rule that matched, e.g. title contains "soap"
+	Conditions []string `json:"conditions"`
+	// ProfileFields holds the profile fields the evaluation rule depended on
+	ProfileFields []ProfileField `json:"profileFields"`
+}
+
+// ProfileField is a characteristic or platform field of the profile by its profile key.
+type ProfileField struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
+// ruleData is what finding details and comments templates are executed with
+type ruleData struct {
+	*Profile
+	RuleVersion string
+	RuleTitle   string
+	GroupID     string
+}
+
+// LoadRuleSet reads a rule set file.
+func LoadRuleSet(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return parseRuleSet(data, path)
+}
+
+func parseRuleSet(data []byte, source string) (*RuleSet, error) {
+	var ruleSet RuleSet
+	if err := yaml.Unmarshal(data, &ruleSet); err != nil {
+		return nil, fmt.Errorf("parsing rules %s: %w", source, err)
+	}
+	ruleSet.Source = source
+	if ruleSet.Kind != RuleSetKind {
+		return nil, fmt.Errorf("rules %s: expected kind %q, got %q", source, RuleSetKind, ruleSet.Kind)
+	}
+	for i := range ruleSet.Rules {
+		if err := ruleSet.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("rules %s: rule %d (%s): %w", source, i+1, ruleSet.Rules[i].ID, err)
+		}
+	}
+	return &ruleSet, nil
+}
+
+// compile validates the rule and parses its templates
+func (r *EvaluationRule) compile() error {
+	if !slices.Contains(Statuses, r.Status) {
+		return fmt.Errorf("invalid status %q, expected one of: %s", r.Status, strings.Join(Statuses, ", "))
+	}
+	for key, value := range r.When.Profile {
+		field, ok := profileField(&Profile{}, key)
+		if !ok {
+			return fmt.Errorf("unknown profile field %q", key)
+		}
+		if _, err := matchField(field, value); err != nil {
+			return fmt.Errorf("profile field %q: %w", key, err)
+		}
+	}
+
+	var err error
+	if r.findingDetails, err = template.New("finding_details").Option("missingkey=error").Parse(r.FindingDetails); err != nil {
+		return err
+	}
+	if r.comments, err = template.New("comments").Option("missingkey=error").Parse(r.Comments); err != nil {
+		return err
+	}
+	// templates referencing fields the profile does not have only fail when executed
+	if _, _, err := r.render(ruleData{Profile: &Profile{}}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// matches reports whether the conditions of the rule match the STIG rule
+func (r *EvaluationRule) matches(p *Profile, ruleVersion, title, checkContent string) bool {
+	w := r.When
+	if len(w.RuleVersions) > 0 && !inSet(ruleVersion, w.RuleVersions...) {
+		return false
+	}
+	if !w.Title.match(title) || !w.Check.match(checkContent) || !w.CheckStart.match(checkContent[:min(200, len(checkContent))]) {
+		return false
+	}
+	for key, value := range w.Profile {
+		field, _ := profileField(p, key)
+		if matched, _ := matchField(field, value); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *EvaluationRule) render(data ruleData) (string, string, error) {
+	var details, comments bytes.Buffer
+	if err := r.findingDetails.Execute(&details, data); err != nil {
+		return "", "", err
+	}
+	if err := r.comments.Execute(&comments, data); err != nil {
+		return "", "", err
+	}
+	return details.String(), comments.String(), nil
+}
+
+// explain describes the matching conditions of the rule
+func (r *EvaluationRule) explain(p *Profile, ruleVersion, title, checkContent string) ([]string, []ProfileField) {
+	w := r.When
+	conditions := []string{}
+	if len(w.RuleVersions) > 0 {
+		conditions = append(conditions, fmt.Sprintf("rule version is %s", ruleVersion))
+	}
+	conditions = append(conditions, w.Title.explain("title", title)...)
+	conditions = append(conditions, w.Check.explain("check", checkContent)...)
+	conditions = append(conditions, w.CheckStart.explain("check start", checkContent[:min(200, len(checkContent))])...)
+
+	fields := []ProfileField{}
+	for key := range w.Profile {
+		field, _ := profileField(p, key)
+		fields = append(fields, ProfileField{Key: key, Value: field.Interface()})
+	}
+	slices.SortFunc(fields, func(a, b ProfileField) int { return strings.Compare(a.Key, b.Key) })
+	for _, field := range fields {
+		conditions = append(conditions, fmt.Sprintf("profile %s %s", field.Key, describeCondition(field.Value, w.Profile[field.Key])))
+	}
+	return conditions, fields
+}
+
+func (k Keywords) match(text string) bool {
+	lower := strings.ToLower(text)
+	for _, keyword := range k.All {
+		if !strings.Contains(lower, strings.ToLower(keyword)) {
+			return false
+		}
+	}
+	return len(k.Any) == 0 || containsAny(lower, lowerAll(k.Any)...)
+}
+
+// explain names the keywords found in the text
+func (k Keywords) explain(name, text string) []string {
+	lower := strings.ToLower(text)
+	var conditions []string
+	for _, keyword := range k.All {
+		conditions = append(conditions, fmt.Sprintf("%s contains %q", name, keyword))
+	}
+	for _, keyword := range k.Any {
+		if strings.Contains(lower, strings.ToLower(keyword)) {
+			conditions = append(conditions, fmt.Sprintf("%s contains %q", name, keyword))
+			break
+		}
+	}
+	return conditions
+}
+
+// describeCondition describes the condition on a profile field with the given value
+func describeCondition(fieldValue, value any) string {
+	_, text := fieldValue.(string)
+	switch expected := value.(type) {
+	case bool:
+		if !text {
+			return fmt.Sprintf("is %t", expected)
+		}
+		if expected {
+			return "is set"
+		}
+		return "is empty"
+	case []any:
+		entries := make([]string, 0, len(expected))
+		for _, entry := range expected {
+			entries = append(entries, fmt.Sprint(entry))
+		}
+		return "is any of " + strings.Join(entries, ", ")
+	case map[string]any:
+		entries := make([]string, 0, len(expected))
+		excluded, _ := expected["not"].([]any)
+		for _, entry := range excluded {
+			entries = append(entries, fmt.Sprint(entry))
+		}
+		return "is set and none of " + strings.Join(entries, ", ")
+	default:
+		return fmt.Sprintf("is %v", expected)
+	}
+}
+
+// profileField returns the characteristic or platform field of the profile with the given key
+func profileField(p *Profile, key string) (reflect.Value, bool) {
+	for _, v := range []reflect.Value{reflect.ValueOf(&p.Chars).Elem(), reflect.ValueOf(&p.Platform).Elem()} {
+		for i := 0; i < v.NumField(); i++ {
+			if tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ","); tag == key {
+				return v.Field(i), true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// matchField reports whether the field matches the value of a profile condition
+func matchField(field reflect.Value, value any) (bool, error) {
+	switch field.Kind() {
+	case reflect.Bool:
+		expected, ok := value.(bool)
+		if !ok {
+			return false, fmt.Errorf("expected true or false, got %v", value)
+		}
+		return field.Bool() == expected, nil
+	case reflect.String:
+		actual := field.String()
+		switch expected := value.(type) {
+		case bool:
+			return (actual != "") == expected, nil
+		case string:
+			return strings.EqualFold(actual, expected), nil
+		case []any:
+			return equalsAny(actual, expected)
+		case map[string]any:
+			excluded, ok := expected["not"].([]any)
+			if !ok || len(expected) != 1 {
+				return false, fmt.Errorf("expected { not: [...] } with a list of texts, got %v", value)
+			}
+			matched, err := equalsAny(actual, excluded)
+			return actual != "" && !matched, err
+		default:
+			return false, fmt.Errorf("expected true, false, a text, a list of texts or { not: [...] }, got %v", value)
+		}
+	default:
+		return false, fmt.Errorf("unsupported field type %s", field.Kind())
+	}
+}
+
+// equalsAny reports whether the text is equal to any of the entries of a profile condition, ignoring case
+func equalsAny(actual string, entries []any) (bool, error) {
+	for _, entry := range entries {
+		text, ok := entry.(string)
+		if !ok {
+			return false, fmt.Errorf("expected a list of texts, got %v", entries)
+		}
+		if strings.EqualFold(actual, text) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// evaluate evaluates the STIG rule with the first rule matching it, and reports whether a rule matched
+func (s *RuleSet) evaluate(p *Profile, groupID, ruleVersion, ruleTitle, checkContent string) (Evaluation, bool) {
+	for i := range s.Rules {
+		r := &s.Rules[i]
+		if !r.matches(p, ruleVersion, ruleTitle, checkContent) {
+			continue
+		}
+		evaluation := Evaluation{Status: r.Status, RuleSet: s.Source, Rule: r.ID}
+		evaluation.Conditions, evaluation.ProfileFields = r.explain(p, ruleVersion, ruleTitle, checkContent)
+		details, comments, err := r.render(ruleData{Profile: p, RuleVersion: ruleVersion, RuleTitle: ruleTitle, GroupID: groupID})
+		if err != nil {
+			evaluation.Status = "not_reviewed"
+			evaluation.FindingDetails = fmt.Sprintf("Evaluation rule %s of %s failed: %v", r.ID, s.Source, err)
+			return evaluation, true
+		}
+		evaluation.FindingDetails, evaluation.Comments = details, comments
+		return evaluation, true
+	}
+	return Evaluation{}, false
+}
+
+// defaultRuleSets parses the embedded rule sets once, keyed by STIG ID
+var defaultRuleSets = sync.OnceValues(func() (map[string]*RuleSet, error) {
+	entries, err := defaultRuleFiles.ReadDir("rules")
+	if err != nil {
+		return nil, err
+	}
+	ruleSets := map[string]*RuleSet{}
+	for _, entry := range entries {
+		name := path.Join("rules", entry.Name())
+		data, err := defaultRuleFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		ruleSet, err := parseRuleSet(data, name)
+		if err != nil {
+			return nil, err
+		}
+		ruleSets[ruleSet.STIG] = ruleSet
+	}
+	return ruleSets, nil
+})
+
+// DefaultRuleSet returns the rule set shipped for the STIG.
+func DefaultRuleSet(stigID string) (*RuleSet, error) {
+	ruleSets, err := defaultRuleSets()
+	if err != nil {
+		return nil, fmt.Errorf("loading default rules: %w", err)
+	}
+	ruleSet, ok := ruleSets[stigID]
+	if !ok {
+		return nil, fmt.Errorf("no default rules for STIG %q", stigID)
+	}
+	return ruleSet, nil
+}
+
+// ruleSetsFor returns the rule sets evaluating the STIG, the profile's own before the default ones
+func ruleSetsFor(p *Profile, stigID string) []*RuleSet {
+	var ruleSets []*RuleSet
+	for _, ruleSet := range p.RuleSets {
+		if ruleSet.STIG == "" || ruleSet.STIG == stigID {
+			ruleSets = append(ruleSets, ruleSet)
+		}
+	}
+	rulesID := stigID
+	if definition, err := LookupSTIGDefinition(stigID); err == nil && definition.Rules != "" {
+		rulesID = definition.Rules
+	}
+	if ruleSet, err := DefaultRuleSet(rulesID); err == nil {
+		ruleSets = append(ruleSets, ruleSet)
+	}
+	return ruleSets
+}
+
+// EvaluateRules evaluates a STIG rule with the rule sets of the STIG, falling back to not_reviewed when no
+// rule matches.
+func EvaluateRules(p *Profile, stigID, groupID, ruleVersion, ruleTitle, checkContent string) (string, string, string) {
+	evaluation := ExplainEvaluation(p, stigID, groupID, ruleVersion, ruleTitle, checkContent)
+	return evaluation.Status, evaluation.FindingDetails, evaluation.Comments
+}
+
+// ExplainEvaluation evaluates a STIG rule like EvaluateRules and records the evaluation rule that decided it.
+func ExplainEvaluation(p *Profile, stigID, groupID, ruleVersion, ruleTitle, checkContent string) Evaluation {
+	for _, ruleSet := range ruleSetsFor(p, stigID) {
+		if evaluation, ok := ruleSet.evaluate(p, groupID, ruleVersion, ruleTitle, checkContent); ok {
+			return evaluation
+		}
+	}
+	return Evaluation{
+		Status:         "not_reviewed",
+		FindingDetails: manualReviewDetails(ruleVersion, p),
+		Conditions:     []string{},
+		ProfileFields:  []ProfileField{},
+	}
+}
+
+// isAutomatedDisposition reports whether the status, finding details and comments of a checklist rule are what an
+// evaluation rule of the selected STIG of the profile gives it, whether or not the evaluation rule matches it now.
+// Finding details depend on few profile fields, e.g. the application name, unlike the conditions of evaluation
+// rules, so this recognizes what an evaluation produced before a characteristic of the profile changed.
+// manualReviewDetails are the finding details of a rule no evaluation rule matched
+func manualReviewDetails(ruleVersion string, p *Profile) string {
+	return fmt.Sprintf("Rule %s requires manual review for %s.", ruleVersion, p.AppName)
+}
+
+func isAutomatedDisposition(p *Profile, r Rule) bool {
+	if p.SelectedSTIG == nil {
+		return false
+	}
+	data := ruleData{Profile: p, RuleVersion: r.RuleVersion, RuleTitle: r.RuleTitle, GroupID: coalesce(r.GroupIDSrc, r.GroupID)}
+	for _, ruleSet := range ruleSetsFor(p, p.SelectedSTIG.ID) {
+		for i := range ruleSet.Rules {
+			if ruleSet.Rules[i].Status != r.Status {
+				continue
+			}
+			details, comments, err := ruleSet.Rules[i].render(data)
+			if err == nil && details == r.FindingDetails && comments == r.Comments {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func lowerAll(values []string) []string {
+	lower := make([]string, len(values))
+	for i, v := range values {
+		lower[i] = strings.ToLower(v)
+	}
+	return lower
+}
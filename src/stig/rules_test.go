@@ -0,0 +1,280 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const userRules = `kind: UDS STIG Rules
+stig: asd_v6r4
+rules:
+  - id: encryption-terminated-at-gateway
+    when:
+      rule_versions: [APSC-DV-000160]
+      profile: { service_mesh: Istio Ambient, language: [python, go] }
+    status: open
+    finding_details: "{{.RuleVersion}}: {{.AppName}} terminates TLS at the {{or .Platform.TLSProvider \"platform\"}} gateway."
+    comments: Tracked in {{.Platform.DefectTracking}}.
+  - id: session-tokens
+    when:
+      title: { all: [session], any: [token, cookie] }
+      check_start: { all: [verify] }
+      profile: { is_stateless: false }
+    status: not_applicable
+    finding_details: The {{.AppName}} application keeps no sessions.
+`
+
+func TestDefaultRuleSet(t *testing.T) {
+	for id := range stigDefinitions {
+		ruleSet, err := DefaultRuleSet(id)
+		require.NoError(t, err)
+		require.Equal(t, id, ruleSet.STIG)
+		require.Equal(t, "rules/"+id+".yaml", ruleSet.Source)
+		require.NotEmpty(t, ruleSet.Rules)
+		// every STIG rule is evaluated by the last rule at the latest
+		require.Zero(t, ruleSet.Rules[len(ruleSet.Rules)-1].When)
+	}
+
+	_, err := DefaultRuleSet("windows_server_2022_v2r2")
+	require.ErrorContains(t, err, `no default rules for STIG "windows_server_2022_v2r2"`)
+}
+
+func TestEvaluateRules_UserRulesFirst(t *testing.T) {
+	ruleSet, err := parseRuleSet([]byte(userRules), "user-rules.yaml")
+	require.NoError(t, err)
+	p := *evalProfile
+	p.Platform.DefectTracking = "Jira"
+	p.RuleSets = []*RuleSet{ruleSet}
+
+	status, details, comments := EvaluateRules(&p, ASDSTIGProfileKey, "V-1", "APSC-DV-000160", "Encryption", "Check TLS.")
+	require.Equal(t, "open", status)
+	require.Equal(t, "APSC-DV-000160: eval-app terminates TLS at the platform gateway.", details)
+	require.Equal(t, "Tracked in Jira.", comments)
+
+	// other rules fall through to the default rules
+	status, details, _ = EvaluateRules(&p, ASDSTIGProfileKey, "V-1", "APSC-DV-000460", "Access control", "Check it.")
+	require.Equal(t, "not_a_finding", status)
+	require.Contains(t, details, "enforces approved authorizations")
+
+	// rule sets of other STIGs are ignored
+	status, _, _ = EvaluateRules(&p, RHEL9STIGProfileKey, "V-1", "APSC-DV-000160", "Encryption", "Check TLS.")
+	require.Equal(t, "not_reviewed", status)
+
+	// STIGs without rules are left for review
+	status, details, _ = EvaluateRules(&p, "windows_server_2022_v2r2", "V-1", "WN22-00-000010", "Windows", "Check it.")
+	require.Equal(t, "not_reviewed", status)
+	require.Equal(t, "Rule WN22-00-000010 requires manual review for eval-app.", details)
+}
+
+func TestEvaluateRules_PlatformSTIGs(t *testing.T) {
+	p := *evalProfile
+	p.Platform.KubernetesDistribution = "EKS"
+	p.Platform.CentralizedLogging = true
+
+	tests := []struct {
+		name        string
+		stigID      string
+		ruleVersion string
+		ruleTitle   string
+		status      string
+		details     string
+	}{
+		{
+			name:        "managed control plane",
+			stigID:      KubernetesSTIGProfileKey,
+			ruleVersion: "CNTR-K8-000150",
+			ruleTitle:   "The Kubernetes API Server must use TLS 1.2, at a minimum, to protect the confidentiality of sensitive data during electronic dissemination.",
+			status:      "not_applicable",
+			details:     "control plane of the EKS cluster running eval-app is operated by the cloud provider",
+		},
+		{
+			name:        "kubernetes network policies",
+			stigID:      KubernetesSTIGProfileKey,
+			ruleVersion: "CNTR-K8-000920",
+			ruleTitle:   "The Kubernetes cluster must use non-privileged host ports for user pods that comply with the Ports, Protocols, and Services Management (PPSM) CAL.",
+			status:      "not_a_finding",
+			details:     "Network policies restrict the traffic of the eval-app pods",
+		},
+		{
+			name:        "kubernetes cluster review",
+			stigID:      KubernetesSTIGProfileKey,
+			ruleVersion: "CNTR-K8-002010",
+			ruleTitle:   "Kubernetes must have a pod security admission control file configured.",
+			status:      "not_reviewed",
+			details:     "Rule CNTR-K8-002010 requires review of the EKS cluster configuration",
+		},
+		{
+			name:        "container platform mTLS",
+			stigID:      ContainerPlatformSRGProfileKey,
+			ruleVersion: "SRG-APP-000439-CTR-001080",
+			ruleTitle:   "The container platform must protect the confidentiality and integrity of transmitted information.",
+			status:      "not_a_finding",
+			details:     "encrypted with mTLS by Istio ambient",
+		},
+		{
+			name:        "container platform signed images",
+			stigID:      ContainerPlatformSRGProfileKey,
+			ruleVersion: "SRG-APP-000131-CTR-000285",
+			ruleTitle:   "The container platform must verify container images have been digitally signed by the Certificate Authority (CA) prior to deployment.",
+			status:      "not_a_finding",
+			details:     "signed with Cosign",
+		},
+		{
+			name:        "web server non-privileged account",
+			stigID:      WebServerSRGProfileKey,
+			ruleVersion: "SRG-APP-000211-WSR-000030",
+			ruleTitle:   "The web server must run as a non-privileged account following the principle of least privilege.",
+			status:      "not_a_finding",
+			details:     "runs as non-root (appuser)",
+		},
+		{
+			name:        "web server centralized logging",
+			stigID:      WebServerSRGProfileKey,
+			ruleVersion: "SRG-APP-000358-WSR-000063",
+			ruleTitle:   "The web server must use a logging mechanism that is configured to off-load log records onto a different system.",
+			status:      "not_a_finding",
+			details:     "forwarded to the centralized logging",
+		},
+		{
+			name:        "web server review",
+			stigID:      WebServerSRGProfileKey,
+			ruleVersion: "SRG-APP-000266-WSR-000142",
+			ruleTitle:   "The web server must display a default hosted application web page.",
+			status:      "not_reviewed",
+			details:     "requires review of the eval-app web server configuration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, details, _ := EvaluateRules(&p, tt.stigID, "V-1", tt.ruleVersion, tt.ruleTitle, "Check it.")
+			require.Equal(t, tt.status, status, details)
+			require.Contains(t, details, tt.details)
+		})
+	}
+
+	// the distributions are matched exactly
+	p.Platform.KubernetesDistribution = "gke"
+	status, _, _ := EvaluateRules(&p, KubernetesSTIGProfileKey, "V-1", "CNTR-K8-000150", "The Kubernetes API Server must use TLS 1.2.", "Check it.")
+	require.Equal(t, "not_applicable", status)
+
+	// self-managed control planes are left for review, including self-managed variants of the managed ones
+	for _, distribution := range []string{"RKE2", "eks-anywhere", "EKS Distro", "aks-hci", "gke-on-prem"} {
+		p.Platform.KubernetesDistribution = distribution
+		status, _, _ := EvaluateRules(&p, KubernetesSTIGProfileKey, "V-1", "CNTR-K8-000150", "The Kubernetes API Server must use TLS 1.2.", "Check it.")
+		require.Equal(t, "not_reviewed", status, distribution)
+	}
+
+	// containers running as root do not satisfy the least privilege rules
+	privileged := map[string]string{
+		KubernetesSTIGProfileKey:       "The Kubernetes cluster must prevent non-privileged users from executing privileged functions.",
+		ContainerPlatformSRGProfileKey: "The container platform must prevent non-privileged users from executing privileged functions.",
+		WebServerSRGProfileKey:         "The web server must run as a non-privileged account following the principle of least privilege.",
+	}
+	for user, expected := range map[string]string{"non-root (appuser)": "not_a_finding", "root": "open", "0": "open", "": "not_reviewed"} {
+		p.Platform.ContainerUser = user
+		for stigID, ruleTitle := range privileged {
+			status, details, _ := EvaluateRules(&p, stigID, "V-1", "SRG-APP-000340", ruleTitle, "Check it.")
+			require.Equal(t, expected, status, "%s running as %q: %s", stigID, user, details)
+		}
+	}
+}
+
+func TestEvaluationRule_Matches(t *testing.T) {
+	ruleSet, err := parseRuleSet([]byte(userRules), "user-rules.yaml")
+	require.NoError(t, err)
+	encryption, session := &ruleSet.Rules[0], &ruleSet.Rules[1]
+	p := *evalProfile
+
+	require.True(t, encryption.matches(&p, "APSC-DV-000160", "", ""))
+	require.False(t, encryption.matches(&p, "APSC-DV-000170", "", ""))
+	p.Platform.ServiceMesh = "Linkerd"
+	require.False(t, encryption.matches(&p, "APSC-DV-000160", "", ""), "text fields match equal texts")
+	p.Platform.ServiceMesh = "istio ambient"
+	p.Chars.Language = "Java"
+	require.False(t, encryption.matches(&p, "APSC-DV-000160", "", ""), "text fields match any of a list")
+
+	p.Chars.IsStateless = false
+	require.True(t, session.matches(&p, "APSC-DV-002230", "Session tokens must expire", "Verify the tokens expire."))
+	require.False(t, session.matches(&p, "APSC-DV-002230", "Session timeouts", "Verify the tokens expire."), "any title keyword")
+	require.False(t, session.matches(&p, "APSC-DV-002230", "Tokens must expire", "Verify the tokens expire."), "all title keywords")
+	require.False(t, session.matches(&p, "APSC-DV-002230", "Session tokens must expire", string(make([]byte, 200))+"Verify"), "check start")
+	p.Chars.IsStateless = true
+	require.False(t, session.matches(&p, "APSC-DV-002230", "Session tokens must expire", "Verify the tokens expire."), "boolean fields")
+}
+
+func TestParseRuleSet_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    string
+		expected string
+	}{
+		{
+			name:     "kind",
+			rules:    "kind: UDS STIG Profile\nrules: []",
+			expected: `rules test.yaml: expected kind "UDS STIG Rules", got "UDS STIG Profile"`,
+		},
+		{
+			name:     "status",
+			rules:    "kind: UDS STIG Rules\nrules:\n  - id: pass\n    status: pass",
+			expected: `rule 1 (pass): invalid status "pass"`,
+		},
+		{
+			name:     "unknown profile field",
+			rules:    "kind: UDS STIG Rules\nrules:\n  - id: soap\n    when: { profile: { uses_soap_messages: false } }\n    status: open",
+			expected: `rule 1 (soap): unknown profile field "uses_soap_messages"`,
+		},
+		{
+			name:     "profile value",
+			rules:    "kind: UDS STIG Rules\nrules:\n  - id: soap\n    when: { profile: { uses_soap: maybe } }\n    status: open",
+			expected: `profile field "uses_soap": expected true or false, got maybe`,
+		},
+		{
+			name:     "negated profile value",
+			rules:    "kind: UDS STIG Rules\nrules:\n  - id: user\n    when: { profile: { container_user: { not: root } } }\n    status: open",
+			expected: `profile field "container_user": expected { not: [...] } with a list of texts, got map[not:root]`,
+		},
+		{
+			name:     "template syntax",
+			rules:    "kind: UDS STIG Rules\nrules:\n  - id: app\n    status: open\n    finding_details: \"{{.AppName\"",
+			expected: `rule 1 (app): template: finding_details`,
+		},
+		{
+			name:     "template field",
+			rules:    "kind: UDS STIG Rules\nrules:\n  - id: app\n    status: open\n    finding_details: \"{{.Platform.Gateway}}\"",
+			expected: `can't evaluate field Gateway`,
+		},
+		{
+			name:     "yaml",
+			rules:    "kind: [",
+			expected: "parsing rules test.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseRuleSet([]byte(tt.rules), "test.yaml")
+			require.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestLoadRuleSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(userRules), 0644))
+
+	ruleSet, err := LoadRuleSet(path)
+	require.NoError(t, err)
+	require.Equal(t, ASDSTIGProfileKey, ruleSet.STIG)
+	require.Equal(t, path, ruleSet.Source)
+	require.Len(t, ruleSet.Rules, 2)
+
+	_, err = LoadRuleSet(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
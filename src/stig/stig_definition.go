@@ -3,33 +3,140 @@
 
 package stig
 
-import "fmt"
-
-var stigDefinitions = map[string]STIGDefinition{
-	ASDSTIGProfileKey: {
-		ID:             ASDSTIGProfileKey,
-		Revision:       "v6r4",
-		ChecklistSlug:  "asd",
-		TargetRole:     "Application Server",
-		TechnologyArea: "Application Review",
-		STIGName:       "Application Security and Development Security Technical Implementation Guide",
-		DisplayName:    "Application Security and Development",
-		STIGID:         "Application_Security_Development_STIG",
-		ZipURL:         "https://dl.dod.cyber.mil/wp-content/uploads/stigs/zip/U_ASD_V6R4_STIG.zip",
-		XCCDFName:      "U_ASD_STIG_V6R4_Manual-xccdf.xml",
-	},
-	RHEL9STIGProfileKey: {
-		ID:             RHEL9STIGProfileKey,
-		Revision:       "v2r7",
-		ChecklistSlug:  "rhel9",
-		TargetRole:     "Operating System",
-		TechnologyArea: "Operating System Review",
-		STIGName:       "Red Hat Enterprise Linux 9 Security Technical Implementation Guide",
-		DisplayName:    "Red Hat Enterprise Linux 9",
-		STIGID:         "RHEL_9_STIG",
-		ZipURL:         "https://dl.dod.cyber.mil/wp-content/uploads/stigs/zip/U_RHEL_9_V2R7_STIG.zip",
-		XCCDFName:      "U_RHEL_9_STIG_V2R7_Manual-xccdf.xml",
-	},
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefinitionKind identifies STIG definition files of the STIG library.
+const DefinitionKind = "UDS STIG Definition"
+
+//go:embed library/*.yaml
+var builtinDefinitionFiles embed.FS
+
+// stigDefinitions holds the STIG library by STIG ID: the built-in definitions and those loaded with LoadLibrary
+var stigDefinitions = mustLoadBuiltinDefinitions()
+
+func mustLoadBuiltinDefinitions() map[string]STIGDefinition {
+	entries, err := builtinDefinitionFiles.ReadDir("library")
+	if err != nil {
+		panic(err)
+	}
+	definitions := map[string]STIGDefinition{}
+	for _, entry := range entries {
+		name := path.Join("library", entry.Name())
+		data, err := builtinDefinitionFiles.ReadFile(name)
+		if err != nil {
+			panic(err)
+		}
+		definition, err := parseDefinition(data, name)
+		if err != nil {
+			panic(err)
+		}
+		definitions[definition.ID] = definition
+	}
+	return definitions
+}
+
+func parseDefinition(data []byte, source string) (STIGDefinition, error) {
+	var definition STIGDefinition
+	if err := yaml.Unmarshal(data, &definition); err != nil {
+		return STIGDefinition{}, fmt.Errorf("parsing STIG definition %s: %w", source, err)
+	}
+	if definition.Kind != DefinitionKind {
+		return STIGDefinition{}, fmt.Errorf("STIG definition %s: expected kind %q, got %q", source, DefinitionKind, definition.Kind)
+	}
+	for _, required := range []struct{ field, value string }{
+		{"id", definition.ID}, {"revision", definition.Revision}, {"slug", definition.ChecklistSlug},
+	} {
+		if required.value == "" {
+			return STIGDefinition{}, fmt.Errorf("STIG definition %s: %s is required", source, required.field)
+		}
+	}
+	if checksum, err := hex.DecodeString(definition.SHA256); err != nil || (definition.SHA256 != "" && len(checksum) != sha256.Size) {
+		return STIGDefinition{}, fmt.Errorf("STIG definition %s: sha256 %q is not a SHA256 checksum", source, definition.SHA256)
+	}
+	return definition, nil
+}
+
+// LoadLibrary adds the STIG definitions of the .yaml files in the library directory to the built-in ones. A
+// definition replaces the built-in definition with the same ID, so new DISA revisions and STIGs can be used
+// without a new release.
+func LoadLibrary(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading STIG library: %w", err)
+	}
+	loaded := map[string]STIGDefinition{}
+	for _, entry := range entries {
+		if entry.IsDir() || !slices.Contains([]string{".yaml", ".yml"}, strings.ToLower(filepath.Ext(entry.Name()))) {
+			continue
+		}
+		name := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("reading STIG definition: %w", err)
+		}
+		definition, err := parseDefinition(data, name)
+		if err != nil {
+			return err
+		}
+		definition.Path = name
+		if duplicate, ok := loaded[definition.ID]; ok {
+			return fmt.Errorf("STIG %q is defined by both %s and %s", definition.ID, duplicate.Path, name)
+		}
+		loaded[definition.ID] = definition
+	}
+	for id, definition := range loaded {
+		stigDefinitions[id] = definition
+	}
+	return nil
+}
+
+// STIGDefinitions returns the definitions of the STIG library sorted by ID.
+func STIGDefinitions() []STIGDefinition {
+	definitions := make([]STIGDefinition, 0, len(stigDefinitions))
+	for _, definition := range stigDefinitions {
+		definitions = append(definitions, definition)
+	}
+	slices.SortFunc(definitions, func(a, b STIGDefinition) int { return strings.Compare(a.ID, b.ID) })
+	return definitions
+}
+
+// RenderDefinitions renders STIG definitions as a markdown table.
+func RenderDefinitions(definitions []STIGDefinition) (string, error) {
+	tableString := &strings.Builder{}
+	table := newMarkdownTable(tableString)
+	table.Header([]string{"ID", "STIG", "Revision", "Target Role", "Download", "Source"})
+	for _, definition := range definitions {
+		download := "No"
+		if definition.ZipURL != "" && definition.XCCDFName != "" {
+			download = "Yes"
+		}
+		if err := table.Append([]string{
+			definition.ID,
+			coalesce(definition.DisplayName, definition.STIGName, definition.ID),
+			strings.ToUpper(definition.Revision),
+			definition.TargetRole,
+			download,
+			coalesce(definition.Path, "built-in"),
+		}); err != nil {
+			return "", err
+		}
+	}
+	if err := table.Render(); err != nil {
+		return "", err
+	}
+	return tableString.String(), nil
 }
 
 func LookupSTIGDefinition(id string) (STIGDefinition, error) {
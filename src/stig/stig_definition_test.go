@@ -0,0 +1,172 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const asdV6R5Definition = `kind: UDS STIG Definition
+id: asd_v6r5
+revision: v6r5
+slug: asd
+stig_id: Application_Security_Development_STIG
+stig_name: Application Security and Development Security Technical Implementation Guide
+display_name: Application Security and Development
+target_role: Application Server
+technology_area: Application Review
+rules: asd_v6r4
+source:
+  zip_url: https://example.com/U_ASD_V6R5_STIG.zip
+  xccdf_name: U_ASD_STIG_V6R5_Manual-xccdf.xml
+`
+
+// useLibrary loads a STIG library with the definition files and restores the built-in library after the test
+func useLibrary(t *testing.T, files map[string]string) error {
+	t.Helper()
+	original := maps.Clone(stigDefinitions)
+	t.Cleanup(func() { stigDefinitions = original })
+
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	return LoadLibrary(dir)
+}
+
+func TestBuiltinDefinitions(t *testing.T) {
+	definition, err := LookupSTIGDefinition(ASDSTIGProfileKey)
+	require.NoError(t, err)
+	require.Equal(t, STIGDefinition{
+		Kind:           DefinitionKind,
+		ID:             ASDSTIGProfileKey,
+		Revision:       "v6r4",
+		ChecklistSlug:  "asd",
+		TargetRole:     "Application Server",
+		TechnologyArea: "Application Review",
+		STIGName:       "Application Security and Development Security Technical Implementation Guide",
+		DisplayName:    "Application Security and Development",
+		STIGID:         "Application_Security_Development_STIG",
+		STIGSource: STIGSource{
+			ZipURL:    "https://dl.dod.cyber.mil/wp-content/uploads/stigs/zip/U_ASD_V6R4_STIG.zip",
+			XCCDFName: "U_ASD_STIG_V6R4_Manual-xccdf.xml",
+		},
+	}, definition)
+
+	var ids []string
+	for _, definition := range STIGDefinitions() {
+		ids = append(ids, definition.ID)
+	}
+	require.Equal(t, []string{"asd_v6r4", "container_platform_v2r1", "kubernetes_v2r3", "rhel9_v2r7", "web_server_v4r1"}, ids)
+}
+
+// unpinnedBuiltinDefinitions are the built-in STIGs whose DISA archive checksum is not pinned yet. Pin the sha256
+// printed by uds-pk stig fetch in the source of the definition and remove it from the list; new built-in STIGs
+// have to be pinned.
+var unpinnedBuiltinDefinitions = []string{"asd_v6r4", "container_platform_v2r1", "kubernetes_v2r3", "rhel9_v2r7", "web_server_v4r1"}
+
+func TestBuiltinDefinitions_PinnedChecksums(t *testing.T) {
+	for _, definition := range STIGDefinitions() {
+		if slices.Contains(unpinnedBuiltinDefinitions, definition.ID) {
+			require.Empty(t, definition.SHA256, "%s is pinned, remove it from unpinnedBuiltinDefinitions", definition.ID)
+			continue
+		}
+		require.Regexp(t, `^[0-9a-f]{64}$`, definition.SHA256, "built-in STIG %s has no pinned lowercase hex sha256", definition.ID)
+	}
+}
+
+func TestLoadLibrary(t *testing.T) {
+	replacedRHEL9 := `kind: UDS STIG Definition
+id: rhel9_v2r7
+revision: v2r7
+slug: rhel9
+display_name: Red Hat Enterprise Linux 9 (mirrored)
+source:
+  zip_url: https://mirror.example.com/U_RHEL_9_V2R7_STIG.zip
+  xccdf_name: U_RHEL_9_STIG_V2R7_Manual-xccdf.xml
+`
+	require.NoError(t, useLibrary(t, map[string]string{
+		"asd_v6r5.yaml":  asdV6R5Definition,
+		"rhel9_v2r7.yml": replacedRHEL9,
+		"README.md":      "not a definition",
+	}))
+
+	added, err := LookupSTIGDefinition("asd_v6r5")
+	require.NoError(t, err)
+	require.Equal(t, "v6r5", added.Revision)
+	require.Equal(t, "U_ASD_STIG_V6R5_Manual-xccdf.xml", added.XCCDFName)
+	require.Equal(t, "asd_v6r5.yaml", filepath.Base(added.Path))
+
+	replaced, err := LookupSTIGDefinition(RHEL9STIGProfileKey)
+	require.NoError(t, err)
+	require.Equal(t, "https://mirror.example.com/U_RHEL_9_V2R7_STIG.zip", replaced.ZipURL)
+
+	_, err = LookupSTIGDefinition(ASDSTIGProfileKey)
+	require.NoError(t, err)
+	require.Len(t, STIGDefinitions(), 6)
+
+	// the new revision is evaluated with the rules of the previous one
+	p := *evalProfile
+	status, _, _ := EvaluateRules(&p, "asd_v6r5", "V-1", "APSC-DV-000160", "Encryption", "Check TLS.")
+	require.Equal(t, "not_a_finding", status)
+}
+
+func TestLoadLibrary_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name:  "wrong kind",
+			files: map[string]string{"asd.yaml": "kind: UDS STIG Profile\nid: asd_v6r5\n"},
+			err:   `expected kind "UDS STIG Definition", got "UDS STIG Profile"`,
+		},
+		{
+			name:  "missing revision",
+			files: map[string]string{"asd.yaml": "kind: UDS STIG Definition\nid: asd_v6r5\nslug: asd\n"},
+			err:   "revision is required",
+		},
+		{
+			name:  "invalid checksum",
+			files: map[string]string{"asd.yaml": asdV6R5Definition + "  sha256: abc\n"},
+			err:   `sha256 "abc" is not a SHA256 checksum`,
+		},
+		{
+			name:  "invalid yaml",
+			files: map[string]string{"asd.yaml": "kind: [\n"},
+			err:   "parsing STIG definition",
+		},
+		{
+			name:  "duplicate id",
+			files: map[string]string{"a.yaml": asdV6R5Definition, "b.yaml": asdV6R5Definition},
+			err:   `STIG "asd_v6r5" is defined by both`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorContains(t, useLibrary(t, tt.files), tt.err)
+		})
+	}
+
+	require.ErrorContains(t, LoadLibrary(filepath.Join(t.TempDir(), "missing")), "reading STIG library")
+}
+
+func TestRenderDefinitions(t *testing.T) {
+	asd, err := LookupSTIGDefinition(ASDSTIGProfileKey)
+	require.NoError(t, err)
+	local := STIGDefinition{ID: "asd_v6r5", Revision: "v6r5", STIGName: "ASD", Path: "/library/asd_v6r5.yaml"}
+
+	output, err := RenderDefinitions([]STIGDefinition{asd, local})
+	require.NoError(t, err)
+	require.Regexp(t, `\| asd_v6r4 +\| Application Security and Development +\| V6R4 +\| Application Server +\| Yes +\| built-in +\|`, output)
+	require.Regexp(t, `\| asd_v6r5 +\| ASD +\| V6R5 +\| +\| No +\| /library/asd_v6r5.yaml +\|`, output)
+}
@@ -10,7 +10,21 @@ func ChecklistTitle(appName string, definition STIGDefinition) string {
 }
 
 func DefaultChecklistFilename(appName string, definition STIGDefinition) string {
-	return ChecklistTitle(appName, definition) + ".cklb"
+	return ChecklistFilename(ChecklistTitle(appName, definition), FormatCKLB)
+}
+
+// ChecklistFilename is the file name of a checklist with the given title in the given format, e.g. app-asd-v6r4.ckl.
+func ChecklistFilename(title string, format string) string {
+	return title + "." + format
+}
+
+// CombinedChecklistTitle is the title of a checklist of several STIGs, e.g. app-asd-v6r4-rhel9-v2r7.
+func CombinedChecklistTitle(appName string, definitions []STIGDefinition) string {
+	title := appName
+	for _, definition := range definitions {
+		title += "-" + definition.ChecklistSlug + "-" + definition.Revision
+	}
+	return title
 }
 
 // Profile represents the stig-profile.yaml configuration.
@@ -26,6 +40,11 @@ type Profile struct {
 	Platform     PlatformConfig      `yaml:"-"`
 	Overrides    map[string]Override `yaml:"-"`
 	SelectedSTIG *STIGProfile        `yaml:"-"`
+	// RuleSets evaluate rules before the default rules of the STIG
+	RuleSets []*RuleSet `yaml:"-"`
+
+	// lines holds the line of each key of the profile file by its path, e.g. stigs[0].overrides.APSC-DV-000010
+	lines map[string]int
 }
 
 type ProfileMetadata struct {
@@ -249,15 +268,32 @@ type CheckContentRef struct {
 	Name string `json:"name"`
 }
 
+// STIGDefinition describes a STIG revision checklists can be generated for, as defined in the STIG library.
 type STIGDefinition struct {
-	ID             string
-	Revision       string
-	ChecklistSlug  string
-	TargetRole     string
-	TechnologyArea string
-	STIGName       string
-	DisplayName    string
-	STIGID         string
-	ZipURL         string
-	XCCDFName      string
+	Kind           string `yaml:"kind"`
+	ID             string `yaml:"id"`
+	Revision       string `yaml:"revision"`
+	ChecklistSlug  string `yaml:"slug"`
+	TargetRole     string `yaml:"target_role"`
+	TechnologyArea string `yaml:"technology_area"`
+	STIGName       string `yaml:"stig_name"`
+	DisplayName    string `yaml:"display_name"`
+	STIGID         string `yaml:"stig_id"`
+	STIGSource     `yaml:"source"`
+	// Rules is the ID of the STIG whose default rules evaluate this one, e.g. the previous revision of a STIG;
+	// the STIG's own ID when empty
+	Rules string `yaml:"rules,omitempty"`
+
+	// Path is the library file the definition was loaded from, empty for built-in definitions
+	Path string `yaml:"-"`
+}
+
+// STIGSource is where the XCCDF of a STIG is downloaded from.
+type STIGSource struct {
+	// ZipURL is the DISA zip archive of the STIG, e.g. https://dl.dod.cyber.mil/wp-content/uploads/stigs/zip/U_ASD_V6R4_STIG.zip
+	ZipURL string `yaml:"zip_url"`
+	// XCCDFName is the file name of the XCCDF in the zip archive
+	XCCDFName string `yaml:"xccdf_name"`
+	// SHA256 pins the checksum of the zip archive; downloads with another checksum are rejected
+	SHA256 string `yaml:"sha256,omitempty"`
 }
@@ -0,0 +1,256 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileProblem is a problem found validating a profile.
+type ProfileProblem struct {
+	// Line is the line of the profile the problem is at, 0 when it is not at a specific line
+	Line    int
+	Message string
+}
+
+func (p ProfileProblem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// ProfileError is returned for a profile with problems.
+type ProfileError struct {
+	Path     string
+	Problems []ProfileProblem
+}
+
+func (e *ProfileError) Error() string {
+	problems := make([]string, 0, len(e.Problems))
+	for _, problem := range e.Problems {
+		problems = append(problems, problem.String())
+	}
+	return fmt.Sprintf("invalid profile %s:\n  %s", e.Path, strings.Join(problems, "\n  "))
+}
+
+// parseProfile decodes a profile and checks it strictly: unknown keys, e.g. a misspelled characteristic, and
+// invalid override statuses are problems. The error is only returned for invalid YAML.
+func parseProfile(data []byte) (*Profile, []ProfileProblem, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+	var p Profile
+	if err := document.Decode(&p); err != nil {
+		return nil, nil, err
+	}
+
+	p.AppName = p.Metadata.Name
+	p.FQDN = p.Metadata.FQDN
+	p.Description = p.Metadata.Description
+	if selected := p.selectDefaultSTIG(); selected != nil {
+		p.use(selected)
+	}
+
+	p.lines = map[string]int{}
+	problems := p.checkKeys(&document, reflect.TypeOf(p), "")
+	if p.Kind != "" && p.Kind != ProfileKind {
+		problems = append(problems, ProfileProblem{Line: p.lines["kind"], Message: fmt.Sprintf("kind must be %q", ProfileKind)})
+	}
+	if p.Metadata.Name == "" {
+		problems = append(problems, ProfileProblem{Line: p.lines["metadata"], Message: "metadata.name is required"})
+	}
+	for i, s := range p.STIGs {
+		for _, ruleVersion := range sortedKeys(s.Overrides) {
+			status := s.Overrides[ruleVersion].Status
+			if status != "" && !slices.Contains(Statuses, status) {
+				problems = append(problems, ProfileProblem{
+					Line:    p.lines[fmt.Sprintf("stigs[%d].overrides.%s.status", i, ruleVersion)],
+					Message: fmt.Sprintf("override of %s has status %q, expected one of: %s", ruleVersion, status, strings.Join(Statuses, ", ")),
+				})
+			}
+		}
+	}
+	slices.SortStableFunc(problems, func(a, b ProfileProblem) int { return a.Line - b.Line })
+	return &p, problems, nil
+}
+
+// checkKeys walks the YAML node along the type it is decoded into, recording the line of every key by its path,
+// e.g. stigs[0].characteristics.uses_soap, and reporting the keys the type does not have
+func (p *Profile) checkKeys(node *yaml.Node, t reflect.Type, path string) []ProfileProblem {
+	for node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var problems []ProfileProblem
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPath := joinPath(path, key.Value)
+			p.lines[childPath] = key.Line
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, ProfileProblem{Line: key.Line, Message: unknownKeyMessage(key.Value, path, fields)})
+				continue
+			}
+			problems = append(problems, p.checkKeys(value, fieldType, childPath)...)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPath := joinPath(path, key.Value)
+			p.lines[childPath] = key.Line
+			problems = append(problems, p.checkKeys(value, t.Elem(), childPath)...)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			problems = append(problems, p.checkKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return problems
+}
+
+// yamlFields returns the types of the fields of the struct type by their YAML key
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func unknownKeyMessage(key, path string, fields map[string]reflect.Type) string {
+	message := fmt.Sprintf("unknown key %q", key)
+	if path != "" {
+		message += " in " + path
+	}
+	// a known key the unknown one abbreviates, e.g. uses_pki for uses_pki_cac, or the closest known key
+	suggestion, best := "", len(key)/2+1
+	for _, name := range sortedKeys(fields) {
+		distance := editDistance(key, name)
+		if strings.HasPrefix(name, key) {
+			distance = 0
+		}
+		if distance < best {
+			suggestion, best = name, distance
+		}
+	}
+	if suggestion != "" {
+		message += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+	return message
+}
+
+// editDistance is the Levenshtein distance of two keys
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// ValidateProfile checks a profile like LoadProfile, and that its STIGs are in the STIG library, and returns all
+// problems found with the profile. The profile is returned unless it is not valid YAML, so its overrides can be
+// validated with ValidateOverrides.
+func ValidateProfile(path string) (*Profile, []ProfileProblem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	p, problems, err := parseProfile(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for i, s := range p.STIGs {
+		if _, err := LookupSTIGDefinition(s.ID); err != nil {
+			problems = append(problems, ProfileProblem{
+				Line:    p.lines[fmt.Sprintf("stigs[%d].id", i)],
+				Message: fmt.Sprintf("STIG %q is not in the STIG library, see uds-pk stig list", s.ID),
+			})
+		}
+	}
+	slices.SortStableFunc(problems, func(a, b ProfileProblem) int { return a.Line - b.Line })
+	return p, problems, nil
+}
+
+// ValidateOverrides reports the overrides of the selected STIG of the profile that do not match a rule version of
+// the STIG parsed from its XCCDF.
+func ValidateOverrides(profile *Profile, s *STIG) []ProfileProblem {
+	if profile.SelectedSTIG == nil {
+		return nil
+	}
+	index := -1
+	for i := range profile.STIGs {
+		if &profile.STIGs[i] == profile.SelectedSTIG {
+			index = i
+		}
+	}
+
+	ruleVersions := map[string]bool{}
+	for _, r := range s.Rules {
+		ruleVersions[r.RuleVersion] = true
+	}
+	var problems []ProfileProblem
+	for _, ruleVersion := range sortedKeys(profile.SelectedSTIG.Overrides) {
+		if !ruleVersions[ruleVersion] {
+			problems = append(problems, ProfileProblem{
+				Line:    profile.lines[fmt.Sprintf("stigs[%d].overrides.%s", index, ruleVersion)],
+				Message: fmt.Sprintf("override %s of %s does not match a rule of %s", ruleVersion, profile.SelectedSTIG.ID, coalesce(s.DisplayName, s.STIGID)),
+			})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const invalidProfile = `kind: UDS STIG Profile
+metadata:
+  name: test-app
+  fqnd: test.example.com
+stigs:
+  - id: asd_v6r4
+    characteristics:
+      uses_pki: true
+      uses_soap: false
+    platform:
+      service_mesh: Istio
+    overrides:
+      APSC-DV-000160:
+        status: pass
+      APSC-DV-999999:
+        status: not_applicable
+        finding_detail: Not used.
+  - id: rhel9_v2r8
+`
+
+func writeProfile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stig-profile.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestValidateProfile(t *testing.T) {
+	profile, problems, err := ValidateProfile(writeProfile(t, invalidProfile))
+	require.NoError(t, err)
+	require.Equal(t, []ProfileProblem{
+		{Line: 4, Message: `unknown key "fqnd" in metadata, did you mean "fqdn"?`},
+		{Line: 8, Message: `unknown key "uses_pki" in stigs[0].characteristics, did you mean "uses_pki_cac"?`},
+		{Line: 14, Message: `override of APSC-DV-000160 has status "pass", expected one of: not_a_finding, not_applicable, not_reviewed, open`},
+		{Line: 17, Message: `unknown key "finding_detail" in stigs[0].overrides.APSC-DV-999999, did you mean "finding_details"?`},
+		{Line: 18, Message: `STIG "rhel9_v2r8" is not in the STIG library, see uds-pk stig list`},
+	}, problems)
+
+	// the profile is decoded regardless, so its overrides can be checked
+	require.Equal(t, "test-app", profile.AppName)
+	require.Equal(t, ASDSTIGProfileKey, profile.SelectedSTIG.ID)
+	require.Equal(t, "Istio", profile.Platform.ServiceMesh)
+
+	s := &STIG{DisplayName: "Application Security and Development", Rules: []Rule{{RuleVersion: "APSC-DV-000160"}}}
+	require.Equal(t, []ProfileProblem{
+		{Line: 15, Message: "override APSC-DV-999999 of asd_v6r4 does not match a rule of Application Security and Development"},
+	}, ValidateOverrides(profile, s))
+
+	_, _, err = ValidateProfile(writeProfile(t, "stigs: [\n"))
+	require.ErrorContains(t, err, "parsing")
+}
+
+func TestLoadProfile_Strict(t *testing.T) {
+	_, err := LoadProfile(writeProfile(t, invalidProfile))
+	var profileErr *ProfileError
+	require.ErrorAs(t, err, &profileErr)
+	require.Len(t, profileErr.Problems, 4)
+	require.ErrorContains(t, err, "invalid profile ")
+	require.ErrorContains(t, err, "\n  line 8: unknown key \"uses_pki\" in stigs[0].characteristics")
+
+	// STIGs missing from the library are skipped, not rejected
+	profile, err := LoadProfile(writeProfile(t, "kind: UDS STIG Profile\nmetadata:\n  name: test-app\nstigs:\n  - id: rhel9_v2r8\n  - id: asd_v6r4\n"))
+	require.NoError(t, err)
+	require.Equal(t, ASDSTIGProfileKey, profile.SelectedSTIG.ID)
+
+	_, err = LoadProfile(writeProfile(t, "kind: UDS STIG Rules\nmetadata:\n  name: test-app\n"))
+	require.ErrorContains(t, err, `line 1: kind must be "UDS STIG Profile"`)
+}
@@ -148,7 +148,7 @@ func ParseXCCDF(path string, profile *Profile) (*STIG, error) {
 		}
 
 		// Evaluate the rule
-		status, findingDetails, comments := evaluateRule(definition, profile, g.ID, r.Version, r.Title, r.Check.Content, discussion)
+		status, findingDetails, comments := EvaluateRules(profile, definition.ID, g.ID, r.Version, r.Title, r.Check.Content)
 
 		// Apply per-rule overrides from profile
 		if ov, ok := profile.Overrides[r.Version]; ok {
@@ -262,6 +262,18 @@ func BuildChecklist(profile *Profile, stig *STIG) *Checklist {
 	}
 }
 
+// CombineChecklists combines checklists of the same target into one checklist with the STIGs of all of them.
+// The target data is that of the first checklist.
+func CombineChecklists(title string, checklists []*Checklist) *Checklist {
+	combined := *checklists[0]
+	combined.Title = title
+	combined.STIGs = nil
+	for _, checklist := range checklists {
+		combined.STIGs = append(combined.STIGs, checklist.STIGs...)
+	}
+	return &combined
+}
+
 func definitionForProfile(profile *Profile) (STIGDefinition, error) {
 	if profile == nil || profile.SelectedSTIG == nil {
 		return STIGDefinition{}, fmt.Errorf("no supported STIG found in profile")
@@ -269,15 +281,6 @@ func definitionForProfile(profile *Profile) (STIGDefinition, error) {
 	return LookupSTIGDefinition(profile.SelectedSTIG.ID)
 }
 
-func evaluateRule(definition STIGDefinition, profile *Profile, groupID, ruleVersion, ruleTitle, checkContent, discussion string) (string, string, string) {
-	switch definition.ID {
-	case RHEL9STIGProfileKey:
-		return EvaluateRHEL9(profile, groupID, ruleVersion, ruleTitle, checkContent, discussion)
-	default:
-		return Evaluate(profile, groupID, ruleVersion, ruleTitle, checkContent, discussion)
-	}
-}
-
 func stigMetadata(definition STIGDefinition, bench *xccdfBenchmark) (string, string, string) {
 	switch definition.ID {
 	case RHEL9STIGProfileKey:
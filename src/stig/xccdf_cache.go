@@ -0,0 +1,132 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// XCCDFCache is a persistent cache of the DISA zip archives of STIGs, keyed by STIG ID. Archives are verified
+// against the SHA256 checksum pinned in the STIG definition, or against the checksum recorded when they were
+// downloaded for definitions without one.
+type XCCDFCache struct {
+	Dir string
+	// Offline only uses cached archives and never downloads one
+	Offline bool
+}
+
+// CachedArchive is a zip archive of a STIG in the cache.
+type CachedArchive struct {
+	Path   string
+	SHA256 string
+	// Downloaded is true when the archive was downloaded, false when it was already cached
+	Downloaded bool
+}
+
+// Archive returns the path of the cached zip archive of the STIG, downloading it when it is not cached yet.
+func (c *XCCDFCache) Archive(ctx context.Context, definition STIGDefinition) (string, error) {
+	archive, err := c.Fetch(ctx, definition, false)
+	if err != nil {
+		return "", err
+	}
+	return archive.Path, nil
+}
+
+// Fetch downloads the zip archive of the STIG into the cache unless a verified archive is cached already, or
+// always with refresh.
+func (c *XCCDFCache) Fetch(ctx context.Context, definition STIGDefinition, refresh bool) (CachedArchive, error) {
+	if definition.ZipURL == "" || definition.XCCDFName == "" {
+		return CachedArchive{}, fmt.Errorf("automatic XCCDF retrieval is not supported for STIG %q", definition.ID)
+	}
+	zipPath := c.archivePath(definition)
+
+	checksum, err := c.verify(definition)
+	switch {
+	case err == nil && !refresh:
+		return CachedArchive{Path: zipPath, SHA256: checksum}, nil
+	case c.Offline && errors.Is(err, fs.ErrNotExist):
+		return CachedArchive{}, fmt.Errorf("STIG %s is not cached in %s, fetch it with uds-pk stig fetch before working offline", definition.ID, c.Dir)
+	case c.Offline && err != nil:
+		return CachedArchive{}, err
+	case c.Offline:
+		return CachedArchive{}, fmt.Errorf("cannot refresh STIG %s offline", definition.ID)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(zipPath), 0755); err != nil {
+		return CachedArchive{}, fmt.Errorf("creating STIG cache: %w", err)
+	}
+	downloadPath := zipPath + ".download"
+	defer func() {
+		_ = os.Remove(downloadPath)
+	}()
+	checksum, err = downloadFile(ctx, definition.ZipURL, downloadPath)
+	if err != nil {
+		return CachedArchive{}, err
+	}
+	if definition.SHA256 != "" && !strings.EqualFold(checksum, definition.SHA256) {
+		return CachedArchive{}, fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", definition.ZipURL, strings.ToLower(definition.SHA256), checksum)
+	}
+	if err := os.Rename(downloadPath, zipPath); err != nil {
+		return CachedArchive{}, fmt.Errorf("caching %s: %w", definition.ZipURL, err)
+	}
+	if err := os.WriteFile(zipPath+".sha256", []byte(checksum+"\n"), 0644); err != nil {
+		return CachedArchive{}, fmt.Errorf("caching %s: %w", definition.ZipURL, err)
+	}
+	return CachedArchive{Path: zipPath, SHA256: checksum, Downloaded: true}, nil
+}
+
+// archivePath returns the path of the zip archive of the STIG in the cache
+func (c *XCCDFCache) archivePath(definition STIGDefinition) string {
+	return filepath.Join(c.Dir, definition.ID, filepath.Base(definition.ZipURL))
+}
+
+// verify checks the cached archive of the STIG against its pinned or recorded checksum and returns the checksum.
+// The error wraps fs.ErrNotExist when the archive is not cached.
+func (c *XCCDFCache) verify(definition STIGDefinition) (string, error) {
+	zipPath := c.archivePath(definition)
+	expected := strings.ToLower(definition.SHA256)
+	if expected == "" {
+		recorded, err := os.ReadFile(zipPath + ".sha256")
+		if err != nil {
+			return "", fmt.Errorf("reading the checksum of the cached STIG %s: %w", definition.ID, err)
+		}
+		expected = strings.TrimSpace(string(recorded))
+	}
+
+	checksum, err := fileSHA256(zipPath)
+	if err != nil {
+		return "", fmt.Errorf("reading the cached STIG %s: %w", definition.ID, err)
+	}
+	if checksum != expected {
+		return "", fmt.Errorf("checksum mismatch for the cached STIG %s at %s: expected sha256 %s, got %s", definition.ID, zipPath, expected, checksum)
+	}
+	return checksum, nil
+}
+
+func fileSHA256(path string) (_ string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		closeErr := file.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package stig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// archiveServer serves a STIG zip archive and counts the downloads
+func archiveServer(t *testing.T) (STIGDefinition, []byte, *atomic.Int32) {
+	t.Helper()
+	zipBytes := buildTestZip(t, map[string]string{
+		"U_ASD_V6R4_Manual_STIG/U_ASD_STIG_V6R4_Manual-xccdf.xml": "<Benchmark></Benchmark>",
+	})
+	var downloads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads.Add(1)
+		_, _ = w.Write(zipBytes)
+	}))
+	t.Cleanup(server.Close)
+
+	originalClient := xccdfHTTPClient
+	xccdfHTTPClient = server.Client()
+	t.Cleanup(func() { xccdfHTTPClient = originalClient })
+
+	definition := stigDefinitions[ASDSTIGProfileKey]
+	definition.ZipURL = server.URL + "/U_ASD_V6R4_STIG.zip"
+	return definition, zipBytes, &downloads
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestXCCDFCache_Fetch(t *testing.T) {
+	definition, zipBytes, downloads := archiveServer(t)
+	cache := &XCCDFCache{Dir: t.TempDir()}
+
+	archive, err := cache.Fetch(context.Background(), definition, false)
+	require.NoError(t, err)
+	require.True(t, archive.Downloaded)
+	require.Equal(t, filepath.Join(cache.Dir, ASDSTIGProfileKey, "U_ASD_V6R4_STIG.zip"), archive.Path)
+	require.Equal(t, sha256Hex(zipBytes), archive.SHA256)
+	require.FileExists(t, archive.Path)
+
+	// the cached archive is reused
+	archive, err = cache.Fetch(context.Background(), definition, false)
+	require.NoError(t, err)
+	require.False(t, archive.Downloaded)
+	require.Equal(t, int32(1), downloads.Load())
+
+	archive, err = cache.Fetch(context.Background(), definition, true)
+	require.NoError(t, err)
+	require.True(t, archive.Downloaded)
+	require.Equal(t, int32(2), downloads.Load())
+
+	// a corrupted archive is downloaded again
+	require.NoError(t, os.WriteFile(archive.Path, []byte("truncated"), 0644))
+	archive, err = cache.Fetch(context.Background(), definition, false)
+	require.NoError(t, err)
+	require.True(t, archive.Downloaded)
+	require.Equal(t, int32(3), downloads.Load())
+}
+
+func TestXCCDFCache_PinnedChecksum(t *testing.T) {
+	definition, zipBytes, _ := archiveServer(t)
+	cache := &XCCDFCache{Dir: t.TempDir()}
+
+	definition.SHA256 = strings.ToUpper(sha256Hex(zipBytes))
+	_, err := cache.Fetch(context.Background(), definition, false)
+	require.NoError(t, err)
+
+	definition.SHA256 = strings.Repeat("0", 64)
+	_, err = cache.Fetch(context.Background(), definition, true)
+	require.ErrorContains(t, err, "checksum mismatch for "+definition.ZipURL+": expected sha256 "+definition.SHA256+", got "+sha256Hex(zipBytes))
+	entries, err := os.ReadDir(filepath.Join(cache.Dir, ASDSTIGProfileKey))
+	require.NoError(t, err)
+	for _, entry := range entries {
+		require.NotContains(t, entry.Name(), ".download")
+	}
+
+	// the cached archive does not match the pinned checksum either
+	cache.Offline = true
+	_, err = cache.Fetch(context.Background(), definition, false)
+	require.ErrorContains(t, err, "checksum mismatch for the cached STIG asd_v6r4")
+}
+
+func TestXCCDFCache_Offline(t *testing.T) {
+	definition, _, downloads := archiveServer(t)
+	cache := &XCCDFCache{Dir: t.TempDir(), Offline: true}
+
+	_, err := cache.Archive(context.Background(), definition)
+	require.ErrorContains(t, err, "STIG asd_v6r4 is not cached in "+cache.Dir)
+	require.Equal(t, int32(0), downloads.Load())
+
+	cache.Offline = false
+	_, err = cache.Archive(context.Background(), definition)
+	require.NoError(t, err)
+
+	cache.Offline = true
+	zipPath, err := cache.Archive(context.Background(), definition)
+	require.NoError(t, err)
+	require.FileExists(t, zipPath)
+	require.Equal(t, int32(1), downloads.Load())
+
+	_, err = cache.Fetch(context.Background(), definition, true)
+	require.ErrorContains(t, err, "cannot refresh STIG asd_v6r4 offline")
+
+	_, err = cache.Archive(context.Background(), STIGDefinition{ID: "local_v1r1"})
+	require.ErrorContains(t, err, `automatic XCCDF retrieval is not supported for STIG "local_v1r1"`)
+}
+
+func TestResolveXCCDFPath_UsesCache(t *testing.T) {
+	definition, _, downloads := archiveServer(t)
+	original := stigDefinitions[ASDSTIGProfileKey]
+	stigDefinitions[ASDSTIGProfileKey] = definition
+	t.Cleanup(func() { stigDefinitions[ASDSTIGProfileKey] = original })
+	cache := &XCCDFCache{Dir: t.TempDir()}
+	profile := &Profile{SelectedSTIG: &STIGProfile{ID: ASDSTIGProfileKey}}
+
+	for range 2 {
+		resolvedPath, cleanup, err := ResolveXCCDFPath(context.Background(), profile, "", cache)
+		require.NoError(t, err)
+		data, err := os.ReadFile(resolvedPath)
+		require.NoError(t, err)
+		require.Equal(t, "<Benchmark></Benchmark>", string(data))
+		cleanup()
+		require.NoFileExists(t, resolvedPath)
+	}
+	require.Equal(t, int32(1), downloads.Load())
+}
@@ -6,6 +6,8 @@ package stig
 import (
 	"archive/zip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,7 +18,10 @@ import (
 
 var xccdfHTTPClient = http.DefaultClient
 
-func ResolveXCCDFPath(ctx context.Context, profile *Profile, explicitPath string) (string, func(), error) {
+// ResolveXCCDFPath returns the XCCDF file of the selected STIG of the profile: the explicit path when given, or the
+// XCCDF extracted from the DISA zip archive of the STIG. The archive is taken from the cache, or downloaded into a
+// temporary directory when cache is nil. The returned function removes the extracted XCCDF.
+func ResolveXCCDFPath(ctx context.Context, profile *Profile, explicitPath string, cache *XCCDFCache) (string, func(), error) {
 	if explicitPath != "" {
 		return explicitPath, func() {}, nil
 	}
@@ -29,7 +34,27 @@ func ResolveXCCDFPath(ctx context.Context, profile *Profile, explicitPath string
 		return "", func() {}, fmt.Errorf("automatic XCCDF retrieval is not supported for STIG %q", definition.ID)
 	}
 
-	return downloadAndExtractXCCDF(ctx, definition.ZipURL, definition.XCCDFName)
+	if cache == nil {
+		return downloadAndExtractXCCDF(ctx, definition.ZipURL, definition.XCCDFName)
+	}
+
+	zipPath, err := cache.Archive(ctx, definition)
+	if err != nil {
+		return "", func() {}, err
+	}
+	tempDir, err := os.MkdirTemp("", "uds-pk-stig-*")
+	if err != nil {
+		return "", func() {}, fmt.Errorf("creating temp dir: %w", err)
+	}
+	cleanup := func() {
+		_ = os.RemoveAll(tempDir)
+	}
+	xccdfPath, err := extractXCCDF(zipPath, tempDir, definition.XCCDFName)
+	if err != nil {
+		cleanup()
+		return "", func() {}, err
+	}
+	return xccdfPath, cleanup, nil
 }
 
 func downloadAndExtractXCCDF(ctx context.Context, url, targetName string) (string, func(), error) {
@@ -42,7 +67,7 @@ func downloadAndExtractXCCDF(ctx context.Context, url, targetName string) (strin
 	}
 
 	zipPath := filepath.Join(tempDir, "source.zip")
-	if err := downloadFile(ctx, url, zipPath); err != nil {
+	if _, err := downloadFile(ctx, url, zipPath); err != nil {
 		cleanup()
 		return "", func() {}, err
 	}
@@ -56,15 +81,16 @@ func downloadAndExtractXCCDF(ctx context.Context, url, targetName string) (strin
 	return xccdfPath, cleanup, nil
 }
 
-func downloadFile(ctx context.Context, url, destPath string) (err error) {
+// downloadFile downloads the URL to the destination path and returns the SHA256 checksum of the download
+func downloadFile(ctx context.Context, url, destPath string) (_ string, err error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("building request for %s: %w", url, err)
+		return "", fmt.Errorf("building request for %s: %w", url, err)
 	}
 
 	resp, err := xccdfHTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("downloading %s: %w", url, err)
+		return "", fmt.Errorf("downloading %s: %w", url, err)
 	}
 	defer func() {
 		closeErr := resp.Body.Close()
@@ -74,12 +100,12 @@ func downloadFile(ctx context.Context, url, destPath string) (err error) {
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("downloading %s: unexpected status %s", url, resp.Status)
+		return "", fmt.Errorf("downloading %s: unexpected status %s", url, resp.Status)
 	}
 
 	file, err := os.Create(destPath)
 	if err != nil {
-		return fmt.Errorf("creating %s: %w", destPath, err)
+		return "", fmt.Errorf("creating %s: %w", destPath, err)
 	}
 	defer func() {
 		closeErr := file.Close()
@@ -88,11 +114,12 @@ func downloadFile(ctx context.Context, url, destPath string) (err error) {
 		}
 	}()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
-		return fmt.Errorf("writing %s: %w", destPath, err)
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(file, hash), resp.Body); err != nil {
+		return "", fmt.Errorf("writing %s: %w", destPath, err)
 	}
 
-	return nil
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
 func extractXCCDF(zipPath, destDir, targetName string) (_ string, err error) {
@@ -16,7 +16,7 @@ import (
 )
 
 func TestResolveXCCDFPath_UsesExplicitPath(t *testing.T) {
-	path, cleanup, err := ResolveXCCDFPath(context.Background(), &Profile{}, "/tmp/test-xccdf.xml")
+	path, cleanup, err := ResolveXCCDFPath(context.Background(), &Profile{}, "/tmp/test-xccdf.xml", nil)
 	require.NoError(t, err)
 	t.Cleanup(cleanup)
 	require.Equal(t, "/tmp/test-xccdf.xml", path)
@@ -47,7 +47,7 @@ func TestResolveXCCDFPath_DownloadsASDFromZip(t *testing.T) {
 		SelectedSTIG: &STIGProfile{ID: ASDSTIGProfileKey},
 	}
 
-	resolvedPath, resolvedCleanup, err := ResolveXCCDFPath(context.Background(), profile, "")
+	resolvedPath, resolvedCleanup, err := ResolveXCCDFPath(context.Background(), profile, "", nil)
 	require.NoError(t, err)
 	defer resolvedCleanup()
 	require.FileExists(t, resolvedPath)
@@ -81,7 +81,7 @@ func TestResolveXCCDFPath_DownloadsRHEL9FromZip(t *testing.T) {
 		SelectedSTIG: &STIGProfile{ID: RHEL9STIGProfileKey},
 	}
 
-	resolvedPath, resolvedCleanup, err := ResolveXCCDFPath(context.Background(), profile, "")
+	resolvedPath, resolvedCleanup, err := ResolveXCCDFPath(context.Background(), profile, "", nil)
 	require.NoError(t, err)
 	defer resolvedCleanup()
 	require.FileExists(t, resolvedPath)
@@ -91,7 +91,7 @@ func TestResolveXCCDFPath_DownloadsRHEL9FromZip(t *testing.T) {
 }
 
 func TestResolveXCCDFPath_NoSupportedSTIG(t *testing.T) {
-	_, cleanup, err := ResolveXCCDFPath(context.Background(), &Profile{}, "")
+	_, cleanup, err := ResolveXCCDFPath(context.Background(), &Profile{}, "", nil)
 	t.Cleanup(cleanup)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "no supported STIG found")
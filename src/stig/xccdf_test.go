@@ -261,6 +261,25 @@ func TestBuildChecklist(t *testing.T) {
 	require.Len(t, checklist.STIGs[0].Rules, 1)
 }
 
+func TestCombineChecklists(t *testing.T) {
+	asd, err := LookupSTIGDefinition(ASDSTIGProfileKey)
+	require.NoError(t, err)
+	rhel9, err := LookupSTIGDefinition(RHEL9STIGProfileKey)
+	require.NoError(t, err)
+
+	first := BuildChecklist(testProfile, &STIG{STIGID: "Application_Security_Development_STIG"})
+	second := &Checklist{Title: "other", TargetData: &TargetData{Role: "Operating System"}, STIGs: []STIG{{STIGID: "RHEL_9_STIG"}}}
+	title := CombinedChecklistTitle("test-app", []STIGDefinition{asd, rhel9})
+	combined := CombineChecklists(title, []*Checklist{first, second})
+
+	require.Equal(t, "test-app-asd-v6r4-rhel9-v2r7", combined.Title)
+	require.Equal(t, first.ID, combined.ID)
+	require.Equal(t, "Application Server", combined.TargetData.Role)
+	require.Len(t, combined.STIGs, 2)
+	require.Equal(t, "RHEL_9_STIG", combined.STIGs[1].STIGID)
+	require.Len(t, first.STIGs, 1)
+}
+
 func TestParseXCCDF_RHEL9UsesBenchmarkMetadata(t *testing.T) {
 	dir := t.TempDir()
 	xccdfPath := filepath.Join(dir, "rhel9-xccdf.xml")
@@ -4,6 +4,9 @@
 package test
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -12,7 +15,7 @@ import (
 )
 
 var expectedTableHeaders = []string {
-	"ID", "Severity", "URL",
+	"ID", "Severity", "Score", "Package", "Installed", "Fixed In", "URL",
 }
 
 func TestCompareScansCommand(t *testing.T) {
@@ -82,3 +85,132 @@ func TestCompareScansCommandAllowDifferentImages(t *testing.T) {
 		assert.Contains(t, stdout, strings.ToUpper(header))
 	}
 }
+
+func TestCompareScansCommandFailOnNewCritical(t *testing.T) {
+	stdout, stderr, err := e2e.UDSPKDir("src/test", "compare-scans", "scans/alpine_3.16.json", "scans/alpine_3.17.json", "--fail-on", "new-critical")
+	require.Error(t, err)
+
+	// the comparison is still printed before the policy check fails
+	assert.Contains(t, stdout, "New vulnerabilities: 5")
+	assert.Contains(t, stderr, "vulnerability policy check failed")
+	assert.Contains(t, stderr, "new-critical: found 1 new vulnerabilities")
+}
+
+func TestCompareScansCommandMaxNewWithinLimit(t *testing.T) {
+	stdout, stderr, err := e2e.UDSPKDir("src/test", "compare-scans", "scans/alpine_3.16.json", "scans/alpine_3.17.json", "--max-new", "medium=4", "--max-new", "critical=1")
+	require.NoError(t, err, stdout, stderr)
+}
+
+func TestCompareScansCommandPolicyFile(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	err := os.WriteFile(policyPath, []byte("maxNew:\n  any: 4\n"), 0644)
+	require.NoError(t, err)
+
+	_, stderr, err := e2e.UDSPKDir("src/test", "compare-scans", "scans/alpine_3.16.json", "scans/alpine_3.17.json", "--policy", policyPath)
+	require.Error(t, err)
+	assert.Contains(t, stderr, "max-new any=4: found 5 new vulnerabilities")
+}
+
+func TestCompareScansCommandVEXIgnoreFile(t *testing.T) {
+	ignorePath := filepath.Join(t.TempDir(), "ignore.yaml")
+	content := `
+ignore:
+  - id: CVE-2022-48174
+    package: busybox-binsh
+    justification: Shell is not reachable in this image.
+  - id: CVE-2000-0001
+    justification: Stale entry.
+    expires: 2020-01-01
+`
+	err := os.WriteFile(ignorePath, []byte(content), 0644)
+	require.NoError(t, err)
+
+	stdout, stderr, err := e2e.UDSPKDir("src/test", "compare-scans", "scans/alpine_3.16.json", "scans/alpine_3.17.json", "--vex", ignorePath, "--fail-on", "new-critical")
+	require.NoError(t, err, stderr)
+
+	assert.Contains(t, stdout, "New vulnerabilities: 4")
+	assert.Contains(t, stdout, "Suppressed vulnerabilities")
+	assert.Contains(t, stdout, "Shell is not reachable in this image.")
+	assert.Contains(t, stderr, "ignore for CVE-2000-0001")
+	assert.Contains(t, stderr, "expired on 2020-01-01")
+}
+
+func TestCompareScansCommandJSONFormat(t *testing.T) {
+	stdout, stderr, err := e2e.UDSPKDir("src/test", "compare-scans", "scans/alpine_3.16.json", "scans/alpine_3.17.json", "--format", "json")
+	require.NoError(t, err, stderr)
+
+	var report struct {
+		Comparisons []struct {
+			New []struct {
+				ID               string `json:"id"`
+				Package          string `json:"package"`
+				InstalledVersion string `json:"installedVersion"`
+			} `json:"new"`
+			Fixed []json.RawMessage `json:"fixed"`
+		} `json:"comparisons"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	require.Len(t, report.Comparisons, 1)
+	assert.Len(t, report.Comparisons[0].New, 5)
+	assert.Len(t, report.Comparisons[0].Fixed, 14)
+	assert.Equal(t, "CVE-2022-48174", report.Comparisons[0].New[0].ID)
+	assert.Equal(t, "busybox-binsh", report.Comparisons[0].New[0].Package)
+	assert.Equal(t, "1.35.0-r31", report.Comparisons[0].New[0].InstalledVersion)
+}
+
+func TestCompareScansCommandSARIFFormat(t *testing.T) {
+	stdout, stderr, err := e2e.UDSPKDir("src/test", "compare-scans", "scans/alpine_3.16.json", "scans/alpine_3.17.json", "--format", "sarif")
+	require.NoError(t, err, stderr)
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	require.Len(t, log.Runs[0].Results, 5)
+	assert.Equal(t, "CVE-2022-48174", log.Runs[0].Results[0].RuleID)
+	assert.Equal(t, "error", log.Runs[0].Results[0].Level)
+}
+
+func TestCompareScansCommandJUnitFormat(t *testing.T) {
+	stdout, stderr, err := e2e.UDSPKDir("src/test", "compare-scans", "scans/alpine_3.16.json", "scans/alpine_3.17.json", "--format", "junit")
+	require.NoError(t, err, stderr)
+
+	assert.Contains(t, stdout, `<testsuites name="uds-pk" tests="5" failures="5">`)
+	assert.Contains(t, stdout, `<testcase name="CVE-2022-48174 in busybox-binsh" classname="alpine">`)
+}
+
+func TestCompareScansCommandUnsupportedFormat(t *testing.T) {
+	_, stderr, err := e2e.UDSPKDir("src/test", "compare-scans", "scans/alpine_3.16.json", "scans/alpine_3.17.json", "--format", "html")
+	require.Error(t, err)
+	assert.Contains(t, stderr, `unsupported output format "html"`)
+}
+
+func TestCompareScansCommandMalformedVulnerabilities(t *testing.T) {
+	scanPath := filepath.Join(t.TempDir(), "malformed.json")
+	content := `{
+  "metadata": {"component": {"name": "alpine", "version": "3.17"}},
+  "vulnerabilities": [
+    {"id": "CVE-2099-0001"},
+    {"id": "CVE-2099-0002", "affects": [{"ref": "pkg:apk/alpine/zlib@1.2.13-r0"}]},
+    {"id": "CVE-2099-0003", "affects": [{"ref": "pkg:apk/alpine/musl@1.2.3-r5"}], "ratings": [{"severity": "medium"}, {"severity": "high"}], "source": {"url": "nvd"}}
+  ]
+}`
+	err := os.WriteFile(scanPath, []byte(content), 0644)
+	require.NoError(t, err)
+
+	stdout, stderr, err := e2e.UDSPKDir("src/test", "compare-scans", "scans/alpine_3.16.json", scanPath)
+	require.NoError(t, err, stderr)
+
+	assert.Contains(t, stderr, "skipping vulnerability CVE-2099-0001: it does not reference an affected package")
+	assert.Contains(t, stdout, "New vulnerabilities: 2")
+	assert.Regexp(t, `\| CVE-2099-0002 \| unknown +\|`, stdout)
+	assert.Regexp(t, `\| CVE-2099-0003 \| high +\|`, stdout)
+}
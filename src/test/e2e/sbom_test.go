@@ -0,0 +1,81 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeLicensedSBOM(t *testing.T, dir string) string {
+	t.Helper()
+	sbomPath := filepath.Join(dir, "app_1.0.0.json")
+	content := `{
+  "artifacts": [
+    {"name": "busybox", "version": "1.36.1-r2", "type": "apk", "purl": "pkg:apk/alpine/busybox@1.36.1-r2", "licenses": ["GPL-2.0"]},
+    {"name": "zlib", "version": "1.3-r0", "type": "apk", "purl": "pkg:apk/alpine/zlib@1.3-r0", "licenses": [{"value": "Zlib", "spdxExpression": "Zlib"}]},
+    {"name": "musl", "version": "1.2.4-r2", "type": "apk", "purl": "pkg:apk/alpine/musl@1.2.4-r2"}
+  ],
+  "source": {"metadata": {"userInput": "example.com/app:1.0.0"}}
+}`
+	require.NoError(t, os.WriteFile(sbomPath, []byte(content), 0644))
+	return sbomPath
+}
+
+func TestSBOMLicensesCommand(t *testing.T) {
+	dir := t.TempDir()
+	writeLicensedSBOM(t, dir)
+
+	stdout, stderr, err := e2e.UDSPK("sbom", "licenses", dir, "--deny", "GPL-*", "--deny", "AGPL-*")
+	require.Error(t, err, stdout)
+	assert.Contains(t, stderr, "license policy check failed: 1 components violate the license policy")
+	assert.Contains(t, stdout, "### `example.com/app:1.0.0`")
+	assert.Contains(t, stdout, "License violations: 1")
+	assert.Contains(t, stdout, "GPL-2.0-only is denied")
+	assert.Contains(t, stdout, "Components without a license: 1")
+
+	stdout, stderr, err = e2e.UDSPK("sbom", "licenses", dir, "--allow", "GPL-2.0-only", "--allow", "Zlib", "--format", "json")
+	require.NoError(t, err, stderr)
+	var report struct {
+		Results []struct {
+			Violations []json.RawMessage `json:"violations"`
+			Unknown    []struct {
+				Name string `json:"name"`
+			} `json:"unknown"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	require.Len(t, report.Results, 1)
+	assert.Empty(t, report.Results[0].Violations)
+	require.Len(t, report.Results[0].Unknown, 1)
+	assert.Equal(t, "musl", report.Results[0].Unknown[0].Name)
+
+	_, stderr, err = e2e.UDSPK("sbom", "licenses", dir, "--allow", "Zlib", "--fail-on-unknown")
+	require.Error(t, err)
+	assert.Contains(t, stderr, "2 components violate the license policy")
+}
+
+func TestSBOMLicensesCommandWithoutPolicy(t *testing.T) {
+	_, stderr, err := e2e.UDSPK("sbom", "licenses", writeLicensedSBOM(t, t.TempDir()))
+	require.Error(t, err)
+	assert.Contains(t, stderr, "license policy needs allow or deny entries")
+}
+
+func TestSBOMLicensesCommandReleased(t *testing.T) {
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.16.0-registry1"}},
+		map[string]string{"elasticsearch_8.16.0.json": "example.com/opensource/bitnami/elasticsearch:8.16.0"})
+	dir := t.TempDir()
+	zarfYaml := writeZarfYaml(t, dir)
+
+	stdout, stderr, err := e2e.UDSPK("sbom", "licenses", "--released", "-p", zarfYaml, "--registry", registry, "--plain-http", "--repo-owner=", "--deny", "GPL-*")
+	require.Error(t, err, stdout)
+	assert.Contains(t, stderr, "1 components violate the license policy")
+	assert.Contains(t, stdout, "### `example.com/opensource/bitnami/elasticsearch:8.16.0` (registry1)")
+	assert.Contains(t, stdout, "GPL-2.0-only is denied")
+}
@@ -4,23 +4,29 @@
 package test
 
 import (
+	"archive/tar"
 	"bytes"
-	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/CycloneDX/cyclonedx-go"
 	"github.com/defenseunicorns/uds-pk/src/cmd"
+	"github.com/defenseunicorns/uds-pk/src/compare"
 	"github.com/defenseunicorns/uds-pk/src/utils"
-	"github.com/google/go-github/v89/github"
 	"github.com/spf13/cobra"
 )
 
@@ -44,6 +50,9 @@ func simulateGrype(args []string, stdout io.Writer, stderr io.Writer) {
 	command := args[1]
 
 	switch command {
+	case "version":
+		_, _ = fmt.Fprint(stdout, `{"application": "grype", "version": "0.99.0"}`)
+		return
 	case "db":
 		if len(args) < 3 {
 			panic("grype db requires a subcommand")
@@ -53,6 +62,10 @@ func simulateGrype(args []string, stdout io.Writer, stderr io.Writer) {
 		case "status":
 			{ // grype db status
 				// print healthy status
+				if slices.Contains(args, "--output") {
+					_, _ = fmt.Fprint(stdout, `{"schemaVersion": "v6.0.2", "built": "2025-12-01T00:00:00Z", "valid": true}`)
+					return
+				}
 				_, _ = fmt.Fprint(stdout, "ok\n")
 				return
 			}
@@ -68,13 +81,17 @@ func simulateGrype(args []string, stdout io.Writer, stderr io.Writer) {
 			// in the default mode - we're scanning. The file to scan is a positional argument
 			// after the command.
 			var outFile string
-			var output string
+			var outputs []string
 
 			grypeFlagSet := flag.NewFlagSet("grype", flag.ContinueOnError)
 			grypeFlagSet.StringVar(&outFile, "file", "default-file.json", "")
 			grypeFlagSet.Bool("add-cpes-if-none", false, "")
-			grypeFlagSet.StringVar(&output, "output", "", "")
+			grypeFlagSet.Func("output", "", func(output string) error {
+				outputs = append(outputs, output)
+				return nil
+			})
 			grypeFlagSet.Bool("v", false, "")
+			grypeFlagSet.String("platform", "", "")
 			err := grypeFlagSet.Parse(args[1:])
 			if err != nil {
 				panic("failed to parse grype args: " + err.Error())
@@ -93,12 +110,22 @@ func simulateGrype(args []string, stdout io.Writer, stderr io.Writer) {
 					},
 				}
 			}
+			// like grype, record the scanned image reference in the metadata
+			name, version := scannedImage(jsonFile)
+			for _, output := range outputs {
+				if reportFile, ok := strings.CutPrefix(output, "json="); ok {
+					report := fmt.Sprintf(`{"source": {"type": "image", "target": {"userInput": %q, "manifestDigest": "sha256:fedcba9876543210"}}}`, jsonFile)
+					if err := os.WriteFile(reportFile, []byte(report), 0o644); err != nil {
+						_, _ = fmt.Fprintf(stderr, "failed to write the JSON report: %v\n", err)
+					}
+				}
+			}
 			// minimal CycloneDX structure required by compare code
 			payload := map[string]any{
 				"metadata": map[string]any{
 					"component": map[string]any{
-						"name":    "elasticsearch-exporter",
-						"version": "1.9.0",
+						"name":    name,
+						"version": version,
 					},
 				},
 				"vulnerabilities": vulns,
@@ -125,10 +152,87 @@ func simulateGrype(args []string, stdout io.Writer, stderr io.Writer) {
 	}
 }
 
+func simulateSyft(args []string) error {
+	if len(args) < 2 || args[0] != "syft" || args[1] != "scan" {
+		panic(fmt.Sprintf("simulateSyft only supports syft scan: %v", args))
+	}
+	var output string
+	syftFlagSet := flag.NewFlagSet("syft", flag.ContinueOnError)
+	syftFlagSet.StringVar(&output, "output", "", "")
+	syftFlagSet.String("from", "", "")
+	syftFlagSet.String("platform", "", "")
+	if err := syftFlagSet.Parse(args[2:]); err != nil {
+		panic("failed to parse syft args: " + err.Error())
+	}
+	outFile, found := strings.CutPrefix(output, "syft-json=")
+	if !found {
+		panic("syft output must be syft-json: " + output)
+	}
+	// like Zarf's SBOMs, record the image reference as the user input
+	content, err := json.Marshal(map[string]any{
+		"artifacts": []map[string]any{
+			{"name": "busybox", "version": "1.36.1-r2", "type": "apk", "purl": "pkg:apk/alpine/busybox@1.36.1-r2"},
+		},
+		"source":     map[string]any{"metadata": map[string]any{"userInput": syftFlagSet.Arg(0), "manifestDigest": "sha256:0123456789abcdef"}},
+		"descriptor": map[string]any{"name": "syft", "version": "1.20.0"},
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outFile, content, 0644)
+}
+
+// scannedImage returns the image name and tag of a grype scan target, reading the user input of SBOM targets
+func scannedImage(target string) (string, string) {
+	reference := target
+	if sbomPath, ok := strings.CutPrefix(target, "sbom:"); ok {
+		var sbom struct {
+			Source struct {
+				Metadata struct {
+					UserInput string `json:"userInput"`
+				} `json:"metadata"`
+			} `json:"source"`
+		}
+		if data, err := os.ReadFile(sbomPath); err == nil {
+			_ = json.Unmarshal(data, &sbom)
+		}
+		reference = sbom.Source.Metadata.UserInput
+	}
+	reference = strings.TrimPrefix(reference, "registry:")
+	if idx := strings.LastIndex(reference, ":"); idx > strings.LastIndex(reference, "/") {
+		return reference[:idx], reference[idx+1:]
+	}
+	return reference, ""
+}
+
+// simulateGrypeDB simulates the grype db commands with the database in the GRYPE_DB_CACHE_DIR of env
+func simulateGrypeDB(args []string, env []string, stdout io.Writer) error {
+	var cacheDir string
+	for _, variable := range env {
+		if value, found := strings.CutPrefix(variable, "GRYPE_DB_CACHE_DIR="); found {
+			cacheDir = value
+		}
+	}
+	imported := filepath.Join(cacheDir, "imported")
+	switch args[1] {
+	case "import":
+		return os.WriteFile(imported, []byte(args[2]), 0644)
+	case "status":
+		if _, err := os.Stat(imported); err != nil {
+			_, _ = fmt.Fprint(stdout, `{"valid": false, "error": "database does not exist"}`)
+			return errors.New("exit status 1")
+		}
+		_, _ = fmt.Fprintf(stdout, `{"schemaVersion": "v6.0.2", "built": "2026-01-02T03:04:05Z", "path": %q, "valid": true}`, cacheDir)
+		return nil
+	}
+	return fmt.Errorf("unsupported grype db command %v", args)
+}
+
 // FakeCommand simulates a command for testing purposes
 type FakeCommand struct {
 	cmd    string
 	args   []string
+	env    []string
 	stdout io.Writer
 	stderr io.Writer
 }
@@ -142,6 +246,12 @@ func (f *FakeCommand) Run() error {
 	if err == nil {
 		err = io.Discard
 	}
+	if f.cmd == "syft" {
+		return simulateSyft(append([]string{f.cmd}, f.args...))
+	}
+	if len(f.env) > 0 && f.args[0] == "db" {
+		return simulateGrypeDB(f.args, f.env, out)
+	}
 	simulateGrype(append([]string{f.cmd}, f.args...), out, err)
 	return nil
 }
@@ -154,6 +264,10 @@ func (f *FakeCommand) SetStderr(stderr io.Writer) {
 	f.stderr = stderr
 }
 
+func (f *FakeCommand) SetEnv(env []string) {
+	f.env = env
+}
+
 func (f *FakeCommand) CombinedOutput() ([]byte, error) {
 	var buf bytes.Buffer
 	simulateGrype(append([]string{"--", f.cmd}, f.args...), &buf, &buf)
@@ -191,6 +305,7 @@ func TestScanCommand_EndToEnd(t *testing.T) {
 	scanOptions.ZarfYamlLocation = writeZarfYaml(t, tmp)
 	scanOptions.ExecCommand = fakeExecCommand
 
+	started := time.Now().UTC().Truncate(time.Second)
 	res, err := cmd.ScanZarfYamlImages(outputDirectory, &scanOptions, log, true)
 	if err != nil {
 		t.Fatalf("scan failed: %v", err)
@@ -204,7 +319,7 @@ func TestScanCommand_EndToEnd(t *testing.T) {
 	for _, p := range files {
 		path = p
 	}
-	if !strings.HasSuffix(path, "/registry1/elasticsearch-exporter_1.9.0.json") {
+	if !scanFilePattern("registry1", "elasticsearch-exporter_1.9.0").MatchString(path) {
 		t.Fatalf("unexpected output path: %s", path)
 	}
 	// validate JSON exists and contains metadata.component
@@ -215,26 +330,69 @@ func TestScanCommand_EndToEnd(t *testing.T) {
 	if !strings.Contains(string(data), "\"metadata\"") {
 		t.Fatalf("output not JSON-like: %s", string(data))
 	}
+
+	// the provenance records the digest grype resolved for the image and when it was scanned
+	var scan cyclonedx.BOM
+	if err := json.Unmarshal(data, &scan); err != nil {
+		t.Fatalf("cannot parse output: %v", err)
+	}
+	provenance := compare.ReadProvenance(scan)
+	if provenance == nil || provenance.ImageDigest != "sha256:fedcba9876543210" {
+		t.Fatalf("expected the resolved image digest in the provenance, got %+v", provenance)
+	}
+	if provenance.Timestamp == nil || provenance.Timestamp.Before(started) || provenance.Timestamp.After(time.Now()) {
+		t.Fatalf("expected the scan time in the provenance, got %v (started %v)", provenance.Timestamp, started)
+	}
 }
 
-func TestScanReleased_EndToEnd(t *testing.T) {
+func TestScanCommand_GenerateSBOMs(t *testing.T) {
 	log := cmd.CreateLogger(true)
 
-	// Mock GitHub API with helper
-	withMockGitHub(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.URL.Path, "/versions") {
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write([]byte(`[{"id":1, "metadata": {"container": {"tags": ["8.16.0-registry1"]}}}]`))
-			return
+	tmp := t.TempDir()
+	syftRuns := 0
+	scanOptions := cmd.CommonScanOptions{}
+	scanOptions.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	scanOptions.GenerateSBOMs = true
+	scanOptions.SBOMCacheDir = filepath.Join(tmp, "cache")
+	scanOptions.ExecCommand = func(command string, args ...string) utils.CommandRunner {
+		if command == "syft" {
+			syftRuns++
 		}
-		if strings.Contains(r.URL.Path, "/orgs/") && strings.Contains(r.URL.Path, "/packages/container/") {
-			w.WriteHeader(http.StatusOK)
-			return
+		return fakeExecCommand(command, args...)
+	}
+
+	for _, run := range []string{"first", "cached"} {
+		res, err := cmd.ScanZarfYamlImages(filepath.Join(tmp, run), &scanOptions, log, true)
+		if err != nil {
+			t.Fatalf("%s scan failed: %v", run, err)
 		}
-		w.WriteHeader(http.StatusNotFound)
-	}))
+		scanFile, found := res["registry1"]["registry:example.com/opensource/bitnami/elasticsearch-exporter:1.9.0"]
+		if !found || !scanFilePattern("registry1", "elasticsearch-exporter_1.9.0").MatchString(scanFile) {
+			t.Fatalf("%s scan: unexpected results: %v", run, res)
+		}
+		data, err := os.ReadFile(scanFile)
+		if err != nil {
+			t.Fatalf("cannot read output: %v", err)
+		}
+		// the fake grype reports vulnerabilities only for images, so none are expected for the generated SBOM
+		if !strings.Contains(string(data), `"name": "example.com/opensource/bitnami/elasticsearch-exporter"`) || strings.Contains(string(data), "CVE-TEST-1") {
+			t.Fatalf("%s scan: expected a scan of the generated SBOM, got: %s", run, string(data))
+		}
+	}
+	if syftRuns != 1 {
+		t.Fatalf("expected the cached SBOM to be reused, syft ran %d times", syftRuns)
+	}
+	sboms, _ := filepath.Glob(filepath.Join(tmp, "cache", "*.json"))
+	if len(sboms) != 1 || !strings.HasPrefix(filepath.Base(sboms[0]), "elasticsearch-exporter_1.9.0_") {
+		t.Fatalf("expected one cached SBOM, got %v", sboms)
+	}
+}
 
-	withMockFetchSbomsUserInput(t, "elasticsearch_8.16.0.json", "registry:example.com/opensource/bitnami/elasticsearch:8.16.0")
+func TestScanReleased_EndToEnd(t *testing.T) {
+	log := cmd.CreateLogger(true)
+
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.15.0-registry1", "8.16.0-registry1", "8.16.0-upstream"}},
+		map[string]string{"elasticsearch_8.16.0.json": "registry:example.com/opensource/bitnami/elasticsearch:8.16.0"})
 
 	tmp := t.TempDir()
 
@@ -242,6 +400,8 @@ func TestScanReleased_EndToEnd(t *testing.T) {
 
 	scanReleasedOptions.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
 	scanReleasedOptions.Scan.ExecCommand = fakeExecCommand
+	scanReleasedOptions.Fetch.Registry = registry
+	scanReleasedOptions.Fetch.PlainHTTP = true
 	outDir := filepath.Join(tmp, "out")
 
 	ctx := t.Context()
@@ -258,7 +418,7 @@ func TestScanReleased_EndToEnd(t *testing.T) {
 	for _, v := range files {
 		p = v
 	}
-	if !strings.HasSuffix(p, "/registry1/elasticsearch_8.16.0.json") {
+	if !scanFilePattern("registry1", "elasticsearch_8.16.0").MatchString(p) {
 		t.Fatalf("unexpected released output path: %s", p)
 	}
 	if _, err := os.Stat(p); err != nil {
@@ -266,32 +426,64 @@ func TestScanReleased_EndToEnd(t *testing.T) {
 	}
 }
 
+func TestScanReleased_SameImageName(t *testing.T) {
+	log := cmd.CreateLogger(true)
+
+	// the images only differ in their repository, so they share the last path segment
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.16.0-registry1"}},
+		map[string]string{
+			"foo_app_1.json": "registry:example.com/foo/app:1",
+			"bar_app_1.json": "registry:example.com/bar/app:1",
+		})
+
+	tmp := t.TempDir()
+	scanReleasedOptions := cmd.ScanReleasedOptions{}
+	scanReleasedOptions.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	scanReleasedOptions.Scan.ExecCommand = fakeExecCommand
+	scanReleasedOptions.Fetch.Registry = registry
+	scanReleasedOptions.Fetch.PlainHTTP = true
+
+	ctx := t.Context()
+	ctx = cmd.InitLoggerContext(true, ctx)
+	res, err := cmd.ScanReleased(&ctx, filepath.Join(tmp, "out"), &scanReleasedOptions, log, true)
+	if err != nil {
+		t.Fatalf("scan-released failed: %v", err)
+	}
+	images := map[string]string{}
+	for _, p := range res["registry1"] {
+		if !scanFilePattern("registry1", "app_1").MatchString(p) {
+			t.Fatalf("unexpected released output path: %s", p)
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("released output file missing: %v", err)
+		}
+		for _, image := range []string{"example.com/foo/app", "example.com/bar/app"} {
+			if strings.Contains(string(data), image) {
+				images[image] = p
+			}
+		}
+	}
+	if len(images) != 2 || images["example.com/foo/app"] == images["example.com/bar/app"] {
+		t.Fatalf("expected a separate scan file of each image, got %v", images)
+	}
+}
+
 func TestScanAndCompare_EndToEnd(t *testing.T) {
 	options := cmd.ScanAndCompareOptions{}
 	// Apply image name override so elasticsearch-exporter matches elasticsearch released scan
 	options.ImageNameOverrides = []string{"elasticsearch=elasticsearch-exporter"}
 
-	// Mock GitHub API with helper
-	withMockGitHub(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.URL.Path, "/versions") {
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write([]byte(`[{"id":1, "metadata": {"container": {"tags": ["8.16.0-registry1"]}}}]`))
-			return
-		}
-		if strings.Contains(r.URL.Path, "/orgs/") && strings.Contains(r.URL.Path, "/packages/container/") {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		w.WriteHeader(http.StatusNotFound)
-	}))
-
-	withMockFetchSbomsUserInput(t, "elasticsearch_8.16.0.json", "registry:example.com/opensource/bitnami/elasticsearch:8.16.0")
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.15.0-registry1", "8.16.0-registry1", "8.16.0-upstream"}},
+		map[string]string{"elasticsearch_8.16.0.json": "registry:example.com/opensource/bitnami/elasticsearch:8.16.0"})
 
 	tmp := t.TempDir()
 	outFile := filepath.Join(tmp, "compare.md")
 	options.Scan.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
 	options.Scan.Scan.OutputDirectory = filepath.Join(tmp, "out")
 	options.Scan.Scan.ExecCommand = fakeExecCommand
+	options.Scan.Fetch.Registry = registry
+	options.Scan.Fetch.PlainHTTP = true
 	options.ScanAndCompareOutputFile = outFile
 
 	ctx := t.Context()
@@ -315,34 +507,73 @@ func TestScanAndCompare_EndToEnd(t *testing.T) {
 	if !strings.Contains(out, "Fixed vulnerabilities: 0") {
 		t.Fatalf("expected zero fixed vulnerabilities, got output: %s", out)
 	}
+	if !strings.Contains(out, "## Vulnerability report: elasticsearch") {
+		t.Fatalf("expected a consolidated report for the package, got output: %s", out)
+	}
+	if !strings.Contains(out, "**Verdict:** :warning: 1 (1 ") {
+		t.Fatalf("expected a verdict for the new vulnerability, got output: %s", out)
+	}
+	if strings.Index(out, "### Summary") > strings.Index(out, "New vulnerabilities: 1") {
+		t.Fatalf("expected the summary before the image details, got output: %s", out)
+	}
+}
+
+func TestScanAndCompare_JUnitFormat(t *testing.T) {
+	options := cmd.ScanAndCompareOptions{}
+	// Apply image name override so elasticsearch-exporter matches elasticsearch released scan
+	options.ImageNameOverrides = []string{"elasticsearch=elasticsearch-exporter"}
+	options.Compare.Format = "junit"
+
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.15.0-registry1", "8.16.0-registry1", "8.16.0-upstream"}},
+		map[string]string{"elasticsearch_8.16.0.json": "registry:example.com/opensource/bitnami/elasticsearch:8.16.0"})
+
+	tmp := t.TempDir()
+	outFile := filepath.Join(tmp, "compare.xml")
+	options.Scan.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	options.Scan.Scan.OutputDirectory = filepath.Join(tmp, "out")
+	options.Scan.Scan.ExecCommand = fakeExecCommand
+	options.Scan.Fetch.Registry = registry
+	options.Scan.Fetch.PlainHTTP = true
+	options.ScanAndCompareOutputFile = outFile
+
+	ctx := t.Context()
+	command := &cobra.Command{}
+	ctx = cmd.InitLoggerContext(true, ctx)
+	command.SetContext(ctx)
+	err := options.Run(command, []string{})
+	if err != nil {
+		t.Fatalf("scan-and-compare failed: %v", err)
+	}
+
+	b, rerr := os.ReadFile(outFile)
+	if rerr != nil {
+		t.Fatalf("failed to read output file: %v", rerr)
+	}
+	out := string(b)
+
+	if !strings.Contains(out, `<testsuites name="uds-pk" tests="1" failures="1">`) {
+		t.Fatalf("expected one failed test case for the new vulnerability, got output: %s", out)
+	}
+	if !strings.Contains(out, "(registry1)") {
+		t.Fatalf("expected the flavor in the test suite name, got output: %s", out)
+	}
 }
 
 func TestScanAndCompare_MissingReleasedImage_PrintsNotice(t *testing.T) {
 	options := cmd.ScanAndCompareOptions{}
 	// No image name overrides: elasticsearch-exporter current image will not match released 'elasticsearch'
 
-	// Mock GitHub API with helper: provide a single released tag for the flavor
-	withMockGitHub(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.URL.Path, "/versions") {
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write([]byte(`[{"id":1, "metadata": {"container": {"tags": ["8.16.0-registry1"]}}}]`))
-			return
-		}
-		if strings.Contains(r.URL.Path, "/orgs/") && strings.Contains(r.URL.Path, "/packages/container/") {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		w.WriteHeader(http.StatusNotFound)
-	}))
-
-	// Mock SBOM fetch for the released artifact, pointing to an elasticsearch image
-	withMockFetchSbomsUserInput(t, "elasticsearch_8.16.0.json", "registry:example.com/opensource/bitnami/elasticsearch:8.16.0")
+	// Released package pointing to an elasticsearch image
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.15.0-registry1", "8.16.0-registry1", "8.16.0-upstream"}},
+		map[string]string{"elasticsearch_8.16.0.json": "registry:example.com/opensource/bitnami/elasticsearch:8.16.0"})
 
 	tmp := t.TempDir()
 	outFile := filepath.Join(tmp, "compare_missing.md")
 	options.Scan.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
 	options.Scan.Scan.OutputDirectory = filepath.Join(tmp, "out")
 	options.Scan.Scan.ExecCommand = fakeExecCommand
+	options.Scan.Fetch.Registry = registry
+	options.Scan.Fetch.PlainHTTP = true
 	options.ScanAndCompareOutputFile = outFile
 
 	ctx := t.Context()
@@ -372,35 +603,309 @@ func TestScanAndCompare_MissingReleasedImage_PrintsNotice(t *testing.T) {
 	}
 }
 
-func TestScanReleased_PrivatePackageMissing(t *testing.T) {
+func TestScanAndCompare_RegistryMirrors(t *testing.T) {
+	options := cmd.ScanAndCompareOptions{}
+
+	// the released package pulled the image from Docker Hub, the current one from a mirror
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"1.8.0-registry1"}},
+		map[string]string{"elasticsearch-exporter_1.8.0.json": "registry:docker.io/bitnami/elasticsearch-exporter:1.8.0"})
+
+	tmp := t.TempDir()
+	mirrorsFile := filepath.Join(tmp, "mirrors.yaml")
+	mirrors := "mirrors:\n  - canonical: docker.io\n    mirrors:\n      - example.com/opensource\n"
+	if err := os.WriteFile(mirrorsFile, []byte(mirrors), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outFile := filepath.Join(tmp, "compare.md")
+	options.Scan.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	options.Scan.Scan.OutputDirectory = filepath.Join(tmp, "out")
+	options.Scan.Scan.ExecCommand = fakeExecCommand
+	options.Scan.Fetch.Registry = registry
+	options.Scan.Fetch.PlainHTTP = true
+	options.Compare.RegistryMirrorsFile = mirrorsFile
+	options.ScanAndCompareOutputFile = outFile
+
+	ctx := t.Context()
+	command := &cobra.Command{}
+	ctx = cmd.InitLoggerContext(true, ctx)
+	command.SetContext(ctx)
+	if err := options.Run(command, []string{}); err != nil {
+		t.Fatalf("scan compare with registry mirrors failed: %v", err)
+	}
+
+	b, rerr := os.ReadFile(outFile)
+	if rerr != nil {
+		t.Fatalf("failed to read output file: %v", rerr)
+	}
+	out := string(b)
+	if strings.Contains(out, "No released scan found for image") {
+		t.Fatalf("expected the mirrored image to match the released scan, got output: %s", out)
+	}
+	if !strings.Contains(out, "`bitnami/elasticsearch-exporter:1.8.0` -> `example.com/opensource/bitnami/elasticsearch-exporter:1.9.0`") {
+		t.Fatalf("expected a comparison with the released version, got output: %s", out)
+	}
+}
+
+func TestScanAndCompare_SameNameDifferentRepository(t *testing.T) {
+	options := cmd.ScanAndCompareOptions{}
+
+	// same image name and file name as the current image, but from an unrelated repository
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"1.8.0-registry1"}},
+		map[string]string{"elasticsearch-exporter_1.8.0.json": "registry:example.com/other/elasticsearch-exporter:1.8.0"})
+
+	tmp := t.TempDir()
+	outFile := filepath.Join(tmp, "compare.md")
+	options.Scan.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	options.Scan.Scan.OutputDirectory = filepath.Join(tmp, "out")
+	options.Scan.Scan.ExecCommand = fakeExecCommand
+	options.Scan.Fetch.Registry = registry
+	options.Scan.Fetch.PlainHTTP = true
+	options.ScanAndCompareOutputFile = outFile
+
+	ctx := t.Context()
+	command := &cobra.Command{}
+	ctx = cmd.InitLoggerContext(true, ctx)
+	command.SetContext(ctx)
+	if err := options.Run(command, []string{}); err != nil {
+		t.Fatalf("scan compare failed: %v", err)
+	}
+
+	b, rerr := os.ReadFile(outFile)
+	if rerr != nil {
+		t.Fatalf("failed to read output file: %v", rerr)
+	}
+	if out := string(b); !strings.Contains(out, "No released scan found for image") {
+		t.Fatalf("expected images from different repositories not to match, got output: %s", out)
+	}
+}
+
+func TestScanReleased_ReleasedVersion(t *testing.T) {
 	log := cmd.CreateLogger(true)
 
-	// Mock GitHub API: public package exists, private package returns 404
-	withMockGitHub(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasSuffix(r.URL.Path, "/versions") {
-			w.Header().Set("Content-Type", "application/json")
-			_, _ = w.Write([]byte(`[{"id":1, "metadata": {"container": {"tags": ["8.16.0-registry1"]}}}]`))
-			return
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.15.0-registry1", "8.16.0-registry1", "8.17.0-rc.1-registry1"}},
+		map[string]string{"elasticsearch_8.15.0.json": "registry:example.com/opensource/bitnami/elasticsearch:8.15.0"})
+
+	tmp := t.TempDir()
+	scanReleasedOptions := cmd.ScanReleasedOptions{}
+	scanReleasedOptions.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	scanReleasedOptions.Scan.ExecCommand = fakeExecCommand
+	scanReleasedOptions.Fetch.Registry = registry
+	scanReleasedOptions.Fetch.PlainHTTP = true
+	scanReleasedOptions.Fetch.ReleasedVersion = "8.15.0"
+
+	ctx := t.Context()
+	ctx = cmd.InitLoggerContext(true, ctx)
+	res, err := cmd.ScanReleased(&ctx, filepath.Join(tmp, "out"), &scanReleasedOptions, log, true)
+	if err != nil {
+		t.Fatalf("scan-released with a pinned version failed: %v", err)
+	}
+	if len(res["registry1"]) != 1 {
+		t.Fatalf("expected 1 released scan result, got %v", res)
+	}
+
+	scanReleasedOptions.Fetch.ReleasedVersion = "8.14.0"
+	if _, err := cmd.ScanReleased(&ctx, filepath.Join(tmp, "missing"), &scanReleasedOptions, log, true); err == nil ||
+		!strings.Contains(err.Error(), "released version 8.14.0 not found") {
+		t.Fatalf("expected an error for a released version that does not exist, got: %v", err)
+	}
+}
+
+func TestScanAndCompare_AllArchitectures(t *testing.T) {
+	options := cmd.ScanAndCompareOptions{}
+	options.ImageNameOverrides = []string{"elasticsearch=elasticsearch-exporter"}
+	options.AllArchitectures = true
+
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.16.0-registry1", "8.16.0-upstream"}},
+		map[string]string{"elasticsearch_8.16.0.json": "registry:example.com/opensource/bitnami/elasticsearch:8.16.0"})
+
+	tmp := t.TempDir()
+	outFile := filepath.Join(tmp, "compare.md")
+	options.Scan.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	options.Scan.Scan.OutputDirectory = filepath.Join(tmp, "out")
+	options.Scan.Scan.ExecCommand = fakeExecCommand
+	options.Scan.Scan.Arch = "amd64"
+	options.Scan.Fetch.Registry = registry
+	options.Scan.Fetch.PlainHTTP = true
+	options.ScanAndCompareOutputFile = outFile
+
+	ctx := t.Context()
+	command := &cobra.Command{}
+	ctx = cmd.InitLoggerContext(true, ctx)
+	command.SetContext(ctx)
+	if err := options.Run(command, []string{}); err != nil {
+		t.Fatalf("scan-and-compare failed: %v", err)
+	}
+
+	b, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	out := string(b)
+	amd64 := strings.Index(out, "Architecture: amd64")
+	arm64 := strings.Index(out, "Architecture: arm64")
+	if amd64 < 0 || arm64 < amd64 {
+		t.Fatalf("expected a comparison for amd64 followed by one for arm64, got output: %s", out)
+	}
+	for _, arch := range []string{"amd64", "arm64"} {
+		if _, err := os.Stat(filepath.Join(tmp, "out", arch, "released")); err != nil {
+			t.Errorf("expected the released scans of %s in their own directory: %v", arch, err)
 		}
-		if strings.Contains(r.URL.Path, "/packages/container/") {
-			if strings.Contains(r.URL.Path, "private") {
-				w.WriteHeader(http.StatusNotFound)
-				_, _ = w.Write([]byte(`{"message":"Package not found."}`))
-				return
-			}
-			w.WriteHeader(http.StatusOK)
-			return
+	}
+}
+
+func TestScanAndCompare_SBOMDiff(t *testing.T) {
+	options := cmd.ScanAndCompareOptions{}
+	options.ImageNameOverrides = []string{"elasticsearch=elasticsearch-exporter"}
+	options.SBOMDiff = true
+
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.16.0-registry1"}},
+		map[string]string{"elasticsearch_8.16.0.json": "example.com/opensource/bitnami/elasticsearch:8.16.0"})
+
+	tmp := t.TempDir()
+	outFile := filepath.Join(tmp, "compare.md")
+	options.Scan.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	options.Scan.Scan.OutputDirectory = filepath.Join(tmp, "out")
+	options.Scan.Scan.ExecCommand = fakeExecCommand
+	options.Scan.Fetch.Registry = registry
+	options.Scan.Fetch.PlainHTTP = true
+	options.ScanAndCompareOutputFile = outFile
+
+	ctx := t.Context()
+	command := &cobra.Command{}
+	ctx = cmd.InitLoggerContext(true, ctx)
+	command.SetContext(ctx)
+	if err := options.Run(command, []string{}); err != nil {
+		t.Fatalf("scan-and-compare failed: %v", err)
+	}
+
+	b, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	out := string(b)
+	for _, expected := range []string{"#### Component changes", "Added components: 0\n", "Removed components: 1\n", "Version changes: 1\n", "| busybox | 1.36.0-r0",
+		"#### Provenance", "Generated by uds-pk dev", "| sha256:0123456789abcdef | generated (syft 1.20.0) | 0.99.0 | 2025-12-01T00:00:00Z (schema v6.0.2) |", "| released package"} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected %q in the output, got: %s", expected, out)
 		}
-		w.WriteHeader(http.StatusNotFound)
-	}))
+	}
+}
+
+func TestScanAndCompare_OfflineGrypeDB(t *testing.T) {
+	options := cmd.ScanAndCompareOptions{}
+	options.ImageNameOverrides = []string{"elasticsearch=elasticsearch-exporter"}
+
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.16.0-registry1"}},
+		map[string]string{"elasticsearch_8.16.0.json": "example.com/opensource/bitnami/elasticsearch:8.16.0"})
+
+	tmp := t.TempDir()
+	// the imported database is cached in the user cache directory
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(tmp, "cache"))
+	archive := filepath.Join(tmp, "vulnerability-db.tar.zst")
+	if err := os.WriteFile(archive, []byte("database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var grypeCommands []*FakeCommand
+	outFile := filepath.Join(tmp, "compare.md")
+	options.Scan.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	options.Scan.Scan.OutputDirectory = filepath.Join(tmp, "out")
+	options.Scan.Scan.GrypeDBArchive = archive
+	options.Scan.Scan.ExecCommand = func(command string, args ...string) utils.CommandRunner {
+		fake := &FakeCommand{cmd: command, args: args}
+		grypeCommands = append(grypeCommands, fake)
+		return fake
+	}
+	options.Scan.Fetch.Registry = registry
+	options.Scan.Fetch.PlainHTTP = true
+	options.ScanAndCompareOutputFile = outFile
+
+	ctx := t.Context()
+	command := &cobra.Command{}
+	ctx = cmd.InitLoggerContext(true, ctx)
+	command.SetContext(ctx)
+	if err := options.Run(command, []string{}); err != nil {
+		t.Fatalf("scan-and-compare failed: %v", err)
+	}
+
+	imports := 0
+	for _, grype := range grypeCommands {
+		if !slices.Contains(grype.env, "GRYPE_DB_AUTO_UPDATE=false") {
+			t.Errorf("expected grype %v to run offline, got environment %v", grype.args, grype.env)
+		}
+		if slices.Contains(grype.args, "update") {
+			t.Errorf("expected no database update, got grype %v", grype.args)
+		}
+		if grype.args[0] == "db" && grype.args[1] == "import" {
+			imports++
+		}
+	}
+	if imports != 1 {
+		t.Errorf("expected the database to be imported once, got %d imports", imports)
+	}
+	b, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("failed to read output file: %v", err)
+	}
+	if !strings.Contains(string(b), "Vulnerability database built: 2026-01-02T03:04:05Z") {
+		t.Fatalf("expected the database build date in the output, got: %s", string(b))
+	}
+}
 
-	withMockFetchSbomsUserInput(t, "elasticsearch_8.16.0.json", "registry:example.com/opensource/bitnami/elasticsearch:8.16.0")
+func TestScanReleased_MissingArchitecture(t *testing.T) {
+	log := cmd.CreateLogger(true)
+
+	registry := withMockRegistry(t, map[string][]string{"elasticsearch": {"8.16.0-registry1"}},
+		map[string]string{"elasticsearch_8.16.0.json": "registry:example.com/opensource/bitnami/elasticsearch:8.16.0"})
+
+	tmp := t.TempDir()
+	scanReleasedOptions := cmd.ScanReleasedOptions{}
+	scanReleasedOptions.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	scanReleasedOptions.Scan.ExecCommand = fakeExecCommand
+	scanReleasedOptions.Scan.Arch = "s390x"
+	scanReleasedOptions.Fetch.Registry = registry
+	scanReleasedOptions.Fetch.PlainHTTP = true
+
+	ctx := t.Context()
+	ctx = cmd.InitLoggerContext(true, ctx)
+	res, err := cmd.ScanReleased(&ctx, filepath.Join(tmp, "out"), &scanReleasedOptions, log, true)
+	if err != nil {
+		t.Fatalf("expected a missing architecture to be skipped, got: %v", err)
+	}
+	if len(res) != 0 {
+		t.Fatalf("expected no released scan results for an architecture that was not released, got %v", res)
+	}
+}
+
+func TestScanReleased_PrivatePackageMissing(t *testing.T) {
+	log := cmd.CreateLogger(true)
+
+	// Registry: public package exists, the private package is denied like ghcr.io does for an anonymous token
+	registry := withMockRegistry(t, map[string][]string{"uds-packages/elasticsearch": {"8.16.0-registry1"}},
+		map[string]string{"elasticsearch_8.16.0.json": "registry:example.com/opensource/bitnami/elasticsearch:8.16.0"})
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: registry})
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "anonymous"})
+		case strings.HasPrefix(r.URL.Path, "/v2/uds-packages/private/"):
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="ghcr.io",scope="repository:uds-packages/private/elasticsearch:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			proxy.ServeHTTP(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GITLAB_RELEASE_TOKEN", "")
 
 	tmp := t.TempDir()
 
 	scanReleasedOptions := cmd.ScanReleasedOptions{}
 	scanReleasedOptions.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
 	scanReleasedOptions.Scan.ExecCommand = fakeExecCommand
+	scanReleasedOptions.Fetch.Registry = strings.TrimPrefix(srv.URL, "http://")
+	scanReleasedOptions.Fetch.PlainHTTP = true
 	scanReleasedOptions.Fetch.RepoOwner = "uds-packages"
 	scanReleasedOptions.Fetch.PrivatePackagesPrefix = "private"
 	outDir := filepath.Join(tmp, "out")
@@ -417,6 +922,29 @@ func TestScanReleased_PrivatePackageMissing(t *testing.T) {
 	}
 }
 
+func TestScanReleased_RegistryDeniesAccess(t *testing.T) {
+	log := cmd.CreateLogger(true)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+
+	tmp := t.TempDir()
+	scanReleasedOptions := cmd.ScanReleasedOptions{}
+	scanReleasedOptions.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
+	scanReleasedOptions.Scan.ExecCommand = fakeExecCommand
+	scanReleasedOptions.Fetch.Registry = strings.TrimPrefix(srv.URL, "http://")
+	scanReleasedOptions.Fetch.PlainHTTP = true
+
+	ctx := t.Context()
+	ctx = cmd.InitLoggerContext(true, ctx)
+	_, err := cmd.ScanReleased(&ctx, filepath.Join(tmp, "out"), &scanReleasedOptions, log, true)
+	if !errors.Is(err, utils.ErrUnauthorized) {
+		t.Fatalf("expected scan-released to fail when the registry denies access, got: %v", err)
+	}
+}
+
 func TestScanReleased_NoRelease_ReturnsEmptyResults(t *testing.T) {
 	// Regression test: when a package has never been released, GHCR returns 404
 	// for the package existence check, leaving packageUrls empty. Previously,
@@ -425,21 +953,15 @@ func TestScanReleased_NoRelease_ReturnsEmptyResults(t *testing.T) {
 	// and ScanReleased returns empty results without error.
 	log := cmd.CreateLogger(true)
 
-	// GHCR: package does not exist yet (new package, no prior release)
-	withMockGitHub(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusNotFound)
-	}))
-
-	// FetchSboms must never be called — the guard should prevent it
-	withMockFetchSboms(t, func(repoOwner, packageUrl, tag string, outputDir string, logger *slog.Logger) ([]string, error) {
-		t.Errorf("FetchSboms called unexpectedly: tag=%q packageUrl=%q", tag, packageUrl)
-		return nil, nil
-	})
+	// Registry: package does not exist yet (new package, no prior release)
+	registry := withMockRegistry(t, map[string][]string{}, nil)
 
 	tmp := t.TempDir()
 	scanReleasedOptions := cmd.ScanReleasedOptions{}
 	scanReleasedOptions.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
 	scanReleasedOptions.Scan.ExecCommand = fakeExecCommand
+	scanReleasedOptions.Fetch.Registry = registry
+	scanReleasedOptions.Fetch.PlainHTTP = true
 	outDir := filepath.Join(tmp, "out")
 
 	ctx := t.Context()
@@ -460,22 +982,16 @@ func TestScanAndCompare_NoRelease_CompletesWithoutError(t *testing.T) {
 	// an invalid URL that returned 404 and caused the command to crash.
 	options := cmd.ScanAndCompareOptions{}
 
-	// GHCR: package does not exist yet (new package, no prior release)
-	withMockGitHub(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusNotFound)
-	}))
-
-	// FetchSboms must never be called — the guard should prevent it
-	withMockFetchSboms(t, func(repoOwner, packageUrl, tag string, outputDir string, logger *slog.Logger) ([]string, error) {
-		t.Errorf("FetchSboms called unexpectedly: tag=%q packageUrl=%q", tag, packageUrl)
-		return nil, nil
-	})
+	// Registry: package does not exist yet (new package, no prior release)
+	registry := withMockRegistry(t, map[string][]string{}, nil)
 
 	tmp := t.TempDir()
 	outFile := filepath.Join(tmp, "compare.md")
 	options.Scan.Scan.ZarfYamlLocation = writeZarfYaml(t, tmp)
 	options.Scan.Scan.OutputDirectory = filepath.Join(tmp, "out")
 	options.Scan.Scan.ExecCommand = fakeExecCommand
+	options.Scan.Fetch.Registry = registry
+	options.Scan.Fetch.PlainHTTP = true
 	options.ScanAndCompareOutputFile = outFile
 
 	ctx := t.Context()
@@ -494,50 +1010,120 @@ func TestScanAndCompare_NoRelease_CompletesWithoutError(t *testing.T) {
 	if len(b) != 0 {
 		t.Errorf("expected empty output for new package with no prior release, got: %s", string(b))
 	}
-}
 
-// withMockGitHub starts a test HTTP server with the given handler and
-// overrides NewGithubClient to point to it. Cleanup is automatic via t.Cleanup.
-func withMockGitHub(t *testing.T, handler http.Handler) {
-	t.Helper()
-	srv := httptest.NewServer(handler)
-	t.Cleanup(func() { srv.Close() })
+	// the documents of the other formats are written without comparisons, to the output file and to stdout
+	for format, expected := range map[string]string{"json": `"comparisons": []`, "sarif": `"runs"`, "junit": "<testsuites"} {
+		options.Compare.Format = format
+		options.ScanAndCompareOutputFile = filepath.Join(tmp, "compare."+format)
+		if err := options.Run(command, []string{}); err != nil {
+			t.Fatalf("scan-and-compare failed for format %s: %v", format, err)
+		}
+		if b, err := os.ReadFile(options.ScanAndCompareOutputFile); err != nil || !strings.Contains(string(b), expected) {
+			t.Errorf("expected %s in the %s output file, got: %s (%v)", expected, format, string(b), err)
+		}
 
-	origNewGH := cmd.NewGithubClient
-	cmd.NewGithubClient = func(_ *context.Context) *github.Client {
-		c, _ := github.NewClient(
-			github.WithHTTPClient(srv.Client()),
-			github.WithEnterpriseURLs(srv.URL+"/", srv.URL+"/"),
-		)
-		return c
+		options.ScanAndCompareOutputFile = ""
+		stdout := captureStdout(t, func() {
+			if err := options.Run(command, []string{}); err != nil {
+				t.Fatalf("scan-and-compare failed for format %s: %v", format, err)
+			}
+		})
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("expected %s in the %s output, got: %s", expected, format, stdout)
+		}
 	}
-	t.Cleanup(func() { cmd.NewGithubClient = origNewGH })
 }
 
-// withMockFetchSboms overrides the package-level FetchSboms for the duration
-// of a test and restores it automatically via t.Cleanup.
-func withMockFetchSboms(t *testing.T, fn func(repoOwner, packageUrl, tag string, outputDir string, logger *slog.Logger) ([]string, error)) {
+// captureStdout returns what the function prints to stdout
+func captureStdout(t *testing.T, f func()) string {
 	t.Helper()
-	orig := cmd.FetchSboms
-	cmd.FetchSboms = fn
-	t.Cleanup(func() { cmd.FetchSboms = orig })
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+	f()
+	_ = writer.Close()
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// scanFilePattern matches the path of the scan of an image in the directory of a flavor, named after the image with a
+// hash of its full reference
+func scanFilePattern(flavor, name string) *regexp.Regexp {
+	return regexp.MustCompile("/" + regexp.QuoteMeta(flavor+"/"+name) + "_[0-9a-f]{12}\\.json$")
 }
 
-// withMockFetchSbomsUserInput writes a minimal SBOM JSON to outputDir/fileName
-// with the provided source.metadata.userInput and returns that path.
-func withMockFetchSbomsUserInput(t *testing.T, fileName, userInput string) {
+// withMockRegistry starts an OCI registry serving the given tags per repository and returns its host,
+// to be used with plain HTTP. Every tag resolves to a package whose sboms.tar layer holds one SBOM per
+// file name with the given source.metadata.userInput. Requests for SBOMs of repositories without tags
+// fail the test. Cleanup is automatic via t.Cleanup.
+func withMockRegistry(t *testing.T, tags map[string][]string, sboms map[string]string) string {
 	t.Helper()
-	withMockFetchSboms(t, func(_ string, _ string, _ string, outDir string, _ *slog.Logger) ([]string, error) {
-		p := filepath.Join(outDir, fileName)
-		sbom := map[string]any{"source": map[string]any{"metadata": map[string]any{"userInput": userInput}}}
-		f, err := os.Create(p)
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	for fileName, userInput := range sboms {
+		content, err := json.Marshal(map[string]any{
+			"artifacts": []map[string]any{
+				{"name": "busybox", "version": "1.36.0-r0", "type": "apk", "purl": "pkg:apk/alpine/busybox@1.36.0-r0", "licenses": []string{"GPL-2.0-only"}},
+				{"name": "zlib", "version": "1.3-r0", "type": "apk", "purl": "pkg:apk/alpine/zlib@1.3-r0"},
+			},
+			"source": map[string]any{"metadata": map[string]any{"userInput": userInput}},
+		})
 		if err != nil {
-			return nil, err
+			t.Fatal(err)
 		}
-		defer f.Close() //nolint:errcheck
-		if err := json.NewEncoder(f).Encode(sbom); err != nil {
-			return nil, err
+		if err := writer.WriteHeader(&tar.Header{Name: fileName, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
 		}
-		return []string{p}, nil
-	})
+		if _, err := writer.Write(content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repository, resource, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/tags/")
+		if resource == "list" {
+			repositoryTags, ok := tags[repository]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": repository, "tags": repositoryTags})
+			return
+		}
+		for _, kind := range []string{"/manifests/", "/blobs/"} {
+			repository, reference, found := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), kind)
+			if !found {
+				continue
+			}
+			if _, ok := tags[repository]; !ok {
+				t.Errorf("SBOMs fetched unexpectedly: %s", r.URL.Path)
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			switch {
+			case kind == "/blobs/":
+				_, _ = w.Write(archive.Bytes())
+			case strings.HasPrefix(reference, "sha256:"):
+				_, _ = w.Write([]byte(`{"layers":[{"digest":"sha256:sboms","annotations":{"org.opencontainers.image.title":"sboms.tar"}}]}`))
+			case slices.Contains(tags[repository], reference):
+				_, _ = w.Write([]byte(`{"manifests":[{"digest":"sha256:manifest","platform":{"architecture":"amd64","os":"linux"}},{"digest":"sha256:manifest","platform":{"architecture":"arm64","os":"linux"}}]}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
 }
@@ -4,9 +4,17 @@
 package test
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/defenseunicorns/uds-pk/src/stig"
@@ -204,6 +212,249 @@ func TestStigGenerateChecklistDefaultOutput(t *testing.T) {
 	require.NoError(t, err, "default output file should exist")
 }
 
+func TestStigGenerateChecklistCKLFormat(t *testing.T) {
+	stdout, stderr, err := e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--format", "ckl",
+	)
+	require.NoError(t, err, stdout, stderr)
+
+	definition, err := stig.LookupSTIGDefinition(stig.ASDSTIGProfileKey)
+	require.NoError(t, err)
+	defaultOutput := stig.ChecklistFilename(stig.ChecklistTitle("e2e-test-app", definition), stig.FormatCKL)
+	defer e2e.CleanFiles(defaultOutput)
+	assert.Contains(t, stdout, "Generated "+defaultOutput)
+
+	data, err := os.ReadFile(defaultOutput)
+	require.NoError(t, err)
+	checklist, err := stig.ParseCKL(data)
+	require.NoError(t, err)
+	require.Len(t, checklist.STIGs, 1)
+	assert.Equal(t, "Application_Security_Development_STIG", checklist.STIGs[0].STIGID)
+	assert.Len(t, checklist.STIGs[0].Rules, 5)
+	assert.Equal(t, "e2e-test-app", checklist.TargetData.HostName)
+
+	_, stderr, err = e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--format", "xlsx",
+	)
+	require.Error(t, err)
+	assert.Contains(t, stderr, `unsupported checklist format "xlsx"`)
+}
+
+func TestStigGenerateChecklistMerge(t *testing.T) {
+	outputDir := t.TempDir()
+	previousPath := filepath.Join(outputDir, "previous.ckl")
+
+	stdout, stderr, err := e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--format", "ckl",
+		"--output", previousPath,
+	)
+	require.NoError(t, err, stdout, stderr)
+
+	// an assessor reviews the checklist in STIG Viewer
+	previous, err := stig.LoadChecklist(previousPath)
+	require.NoError(t, err)
+	for i, rule := range previous.STIGs[0].Rules {
+		switch rule.RuleVersion {
+		case "APSC-DV-002900":
+			previous.STIGs[0].Rules[i].Status = "not_a_finding"
+			previous.STIGs[0].Rules[i].Comments = "Audit records are retained for a year."
+		case "APSC-DV-000160":
+			previous.STIGs[0].Rules[i].Status = "open"
+		}
+	}
+	data, err := stig.MarshalCKL(previous)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(previousPath, data, 0644))
+
+	outputPath := filepath.Join(outputDir, "merged.cklb")
+	stdout, stderr, err = e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--merge", previousPath,
+		"--output", outputPath,
+	)
+	require.NoError(t, err, stdout, stderr)
+	// the rules left as evaluated are evaluated again rather than carried over
+	assert.Contains(t, stdout, "Merged 2 reviewed rules from "+previousPath)
+	assert.Contains(t, stdout, "conflict: APSC-DV-000160 (V-222400): reviewed as open, evaluated as not_a_finding")
+	assert.NotContains(t, stdout, "not_reviewed:")
+
+	merged, err := stig.LoadChecklist(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, previous.STIGs[0].UUID, merged.STIGs[0].UUID)
+	for _, rule := range merged.STIGs[0].Rules {
+		switch rule.RuleVersion {
+		case "APSC-DV-002900":
+			assert.Equal(t, "not_a_finding", rule.Status)
+			assert.Equal(t, "Audit records are retained for a year.", rule.Comments)
+		case "APSC-DV-000160":
+			assert.Equal(t, "open", rule.Status)
+		}
+	}
+
+	_, stderr, err = e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--merge", filepath.Join(outputDir, "missing.cklb"),
+	)
+	require.Error(t, err)
+	assert.Contains(t, stderr, "failed to load checklist to merge")
+}
+
+func TestStigGenerateChecklistUserRules(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "rules.cklb")
+
+	stdout, stderr, err := e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--rules", "src/test/stig/test-rules.yaml",
+		"--output", outputPath,
+	)
+	require.NoError(t, err, stdout, stderr)
+
+	checklist, err := stig.LoadChecklist(outputPath)
+	require.NoError(t, err)
+	for _, rule := range checklist.STIGs[0].Rules {
+		switch rule.RuleVersion {
+		case "APSC-DV-002900":
+			assert.Equal(t, "not_a_finding", rule.Status)
+			assert.Equal(t, "e2e-test-app audit records are retained by Kubernetes for a year.", rule.FindingDetails)
+			assert.Equal(t, "Verified by the e2e-test-app team.", rule.Comments)
+		case "APSC-DV-000160":
+			// rules without a user rule keep their default evaluation
+			assert.Equal(t, "not_a_finding", rule.Status)
+		}
+	}
+
+	_, stderr, err = e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--rules", "src/test/stig/test-profile.yaml",
+	)
+	require.Error(t, err)
+	assert.Contains(t, stderr, `failed to load rules: rules src/test/stig/test-profile.yaml: expected kind "UDS STIG Rules"`)
+}
+
+func TestStigGenerateChecklistExplain(t *testing.T) {
+	outputDir := t.TempDir()
+	reportPath := filepath.Join(outputDir, "trace.json")
+
+	stdout, stderr, err := e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--output", filepath.Join(outputDir, "explain.cklb"),
+		"--explain",
+		"--explain-report", reportPath,
+	)
+	require.NoError(t, err, stdout, stderr)
+	assert.Contains(t, stdout, "APSC-DV-002010 (V-222600): not_applicable\n  rule soap-not-used of rules/asd_v6r4.yaml: not_applicable\n    title contains \"soap\"\n    profile uses_soap is false\n  profile: uses_soap=false\n")
+	assert.Contains(t, stdout, "Wrote evaluation trace to "+reportPath)
+
+	data, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	var explanation stig.Explanation
+	require.NoError(t, json.Unmarshal(data, &explanation))
+	require.Len(t, explanation.STIGs, 1)
+	assert.Len(t, explanation.STIGs[0].Rules, 5)
+	for _, rule := range explanation.STIGs[0].Rules {
+		assert.NotEmpty(t, rule.Evaluation.Rule, rule.RuleVersion)
+	}
+}
+
+func TestStigGenerateChecklistSelectedSTIG(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "rhel9.cklb")
+
+	stdout, stderr, err := e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--stig", stig.RHEL9STIGProfileKey,
+		"--xccdf", "src/test/stig/test-rhel9-xccdf.xml",
+		"--output", outputPath,
+	)
+	require.NoError(t, err, stdout, stderr)
+	assert.Contains(t, stdout, "Total rules: 2")
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	var checklist stig.Checklist
+	require.NoError(t, json.Unmarshal(data, &checklist))
+	require.Len(t, checklist.STIGs, 1)
+	assert.Equal(t, "RHEL_9_STIG", checklist.STIGs[0].STIGID)
+	assert.Equal(t, "Operating System Review", checklist.TargetData.TechnologyArea)
+
+	_, stderr, err = e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--stig", "missing_v1r1",
+		"--xccdf", "src/test/stig/test-rhel9-xccdf.xml",
+	)
+	require.Error(t, err)
+	assert.Contains(t, stderr, "not listed in the profile")
+}
+
+func TestStigGenerateChecklistAllSTIGs(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "all.cklb")
+
+	stdout, stderr, err := e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--all",
+		"--xccdf", "asd_v6r4=src/test/stig/test-xccdf.xml",
+		"--xccdf", "rhel9_v2r7=src/test/stig/test-rhel9-xccdf.xml",
+		"--output", outputPath,
+	)
+	require.NoError(t, err, stdout, stderr)
+	assert.Contains(t, stdout, "Generated "+outputPath)
+	assert.Contains(t, stdout, "Red Hat Enterprise Linux 9 (rhel9_v2r7)\nTotal rules: 2")
+
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	var checklist stig.Checklist
+	require.NoError(t, json.Unmarshal(data, &checklist))
+	assert.Equal(t, "e2e-test-app-asd-v6r4-rhel9-v2r7", checklist.Title)
+	require.Len(t, checklist.STIGs, 2)
+	assert.Equal(t, "Application_Security_Development_STIG", checklist.STIGs[0].STIGID)
+	assert.Equal(t, "RHEL_9_STIG", checklist.STIGs[1].STIGID)
+}
+
+func TestStigGenerateChecklistAllSTIGsSplit(t *testing.T) {
+	outputDir := t.TempDir()
+
+	stdout, stderr, err := e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--all", "--split",
+		"--xccdf", "asd_v6r4=src/test/stig/test-xccdf.xml",
+		"--xccdf", "rhel9_v2r7=src/test/stig/test-rhel9-xccdf.xml",
+		"--output", outputDir,
+	)
+	require.NoError(t, err, stdout, stderr)
+
+	for _, id := range []string{stig.ASDSTIGProfileKey, stig.RHEL9STIGProfileKey} {
+		definition, err := stig.LookupSTIGDefinition(id)
+		require.NoError(t, err)
+		outputPath := filepath.Join(outputDir, stig.DefaultChecklistFilename("e2e-test-app", definition))
+		assert.Contains(t, stdout, "Generated "+outputPath)
+
+		data, err := os.ReadFile(outputPath)
+		require.NoError(t, err)
+		var checklist stig.Checklist
+		require.NoError(t, json.Unmarshal(data, &checklist))
+		require.Len(t, checklist.STIGs, 1)
+	}
+
+	// a plain --xccdf path is ambiguous with several STIGs
+	_, stderr, err = e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--all",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+	)
+	require.Error(t, err)
+	assert.Contains(t, stderr, "must name the STIG")
+}
+
 func TestStigGenerateChecklistMissingXCCDF(t *testing.T) {
 	profilePath := filepath.Join(t.TempDir(), "profile-without-supported-stig.yaml")
 	err := os.WriteFile(profilePath, []byte(`
@@ -272,3 +523,218 @@ func TestStigGenerateChecklistWithRealSTIG(t *testing.T) {
 	stig := stigs[0].(map[string]interface{})
 	assert.Equal(t, float64(286), stig["size"])
 }
+
+func TestStigDiff(t *testing.T) {
+	outputDir := t.TempDir()
+
+	// a new revision of the STIG rewrites a check and replaces a rule
+	xccdf, err := os.ReadFile("src/test/stig/test-xccdf.xml")
+	require.NoError(t, err)
+	revised := strings.NewReplacer(
+		"Release: 1 Benchmark Date: 01 Jan 2025", "Release: 2 Benchmark Date: 01 Jul 2025",
+		"Verify audit record storage is allocated.", "Verify audit record storage is allocated for a year.",
+		"APSC-DV-001680", "APSC-DV-001690",
+	).Replace(string(xccdf))
+	revisedPath := filepath.Join(outputDir, "revised-xccdf.xml")
+	require.NoError(t, os.WriteFile(revisedPath, []byte(revised), 0644))
+
+	stdout, stderr, err := e2e.UDSPK("stig", "diff", "src/test/stig/test-xccdf.xml", revisedPath,
+		"--profile", "src/test/stig/test-profile.yaml",
+	)
+	require.NoError(t, err, stdout, stderr)
+	assert.Contains(t, stdout, "### Application Security and Development (Application_Security_Development_STIG)")
+	assert.Contains(t, stdout, "Release: 1 Benchmark Date: 01 Jan 2025 -> Release: 2 Benchmark Date: 01 Jul 2025")
+	assert.Contains(t, stdout, "Added rules: 1\nRemoved rules: 1\nStatus changes: 0\nText changes: 1")
+	assert.Contains(t, stdout, "APSC-DV-001690")
+
+	// an assessor changed a disposition in the previous checklist
+	previousPath := filepath.Join(outputDir, "previous.cklb")
+	stdout, stderr, err = e2e.UDSPK("stig", "generate-checklist",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--output", previousPath,
+	)
+	require.NoError(t, err, stdout, stderr)
+	previous, err := stig.LoadChecklist(previousPath)
+	require.NoError(t, err)
+	previous.STIGs[0].Rules[0].Status = "open"
+	data, err := json.Marshal(previous)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(previousPath, data, 0644))
+
+	stdout, stderr, err = e2e.UDSPK("stig", "diff", previousPath, revisedPath,
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--format", "json",
+	)
+	require.NoError(t, err, stdout, stderr)
+	var diff stig.ChecklistDiff
+	require.NoError(t, json.Unmarshal([]byte(stdout), &diff))
+	require.Len(t, diff.STIGs, 1)
+	assert.Equal(t, []stig.StatusChange{{
+		RuleVersion: "APSC-DV-000160",
+		GroupID:     "V-222400",
+		Title:       previous.STIGs[0].Rules[0].RuleTitle,
+		OldStatus:   "open",
+		NewStatus:   "not_a_finding",
+	}}, diff.STIGs[0].StatusChanges)
+	assert.Len(t, diff.STIGs[0].Added, 1)
+	assert.Len(t, diff.STIGs[0].Removed, 1)
+
+	_, stderr, err = e2e.UDSPK("stig", "diff", previousPath, revisedPath, "--format", "html")
+	require.Error(t, err)
+	assert.Contains(t, stderr, `unsupported output format "html"`)
+}
+
+func TestStigList(t *testing.T) {
+	stdout, stderr, err := e2e.UDSPK("stig", "list", "--library", "")
+	require.NoError(t, err, stdout, stderr)
+	for _, id := range []string{stig.ASDSTIGProfileKey, stig.RHEL9STIGProfileKey, stig.KubernetesSTIGProfileKey} {
+		assert.Contains(t, stdout, "| "+id+" ")
+	}
+	assert.NotContains(t, stdout, "asd_v6r5")
+
+	// a new revision of a STIG defined in the library
+	libraryDir := t.TempDir()
+	definition := `kind: UDS STIG Definition
+id: asd_v6r5
+revision: v6r5
+slug: asd
+stig_id: Application_Security_Development_STIG
+display_name: Application Security and Development
+target_role: Application Server
+technology_area: Application Review
+rules: asd_v6r4
+`
+	require.NoError(t, os.WriteFile(filepath.Join(libraryDir, "asd_v6r5.yaml"), []byte(definition), 0644))
+
+	stdout, stderr, err = e2e.UDSPK("stig", "list", "--library", libraryDir)
+	require.NoError(t, err, stdout, stderr)
+	assert.Regexp(t, `\| asd_v6r5 +\| Application Security and Development +\| V6R5 +\| Application Server +\| No +\| `+filepath.Join(libraryDir, "asd_v6r5.yaml"), stdout)
+
+	profile, err := os.ReadFile("src/test/stig/test-profile.yaml")
+	require.NoError(t, err)
+	profilePath := filepath.Join(t.TempDir(), "stig-profile.yaml")
+	require.NoError(t, os.WriteFile(profilePath, []byte(strings.Replace(string(profile), "id: asd_v6r4", "id: asd_v6r5", 1)), 0644))
+	outputPath := filepath.Join(t.TempDir(), "output.cklb")
+	stdout, stderr, err = e2e.UDSPK("stig", "generate-checklist",
+		"--library", libraryDir,
+		"--profile", profilePath,
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--output", outputPath,
+	)
+	require.NoError(t, err, stdout, stderr)
+	assert.Contains(t, stdout, "not_a_finding:")
+	checklist, err := stig.LoadChecklist(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, "e2e-test-app-asd-v6r5", checklist.Title)
+
+	_, stderr, err = e2e.UDSPK("stig", "list", "--library", filepath.Join(libraryDir, "missing"))
+	require.Error(t, err)
+	assert.Contains(t, stderr, "failed to load STIG library")
+}
+
+func TestStigFetchOffline(t *testing.T) {
+	// a STIG library definition whose archive is served locally
+	xccdf, err := os.ReadFile("src/test/stig/test-xccdf.xml")
+	require.NoError(t, err)
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, err := zw.Create("U_ASD_V6R5_Manual_STIG/U_ASD_STIG_V6R5_Manual-xccdf.xml")
+	require.NoError(t, err)
+	_, err = w.Write(xccdf)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+	checksum := sha256.Sum256(archive.Bytes())
+
+	libraryDir := t.TempDir()
+	writeDefinition := func(sha string) {
+		definition := fmt.Sprintf(`kind: UDS STIG Definition
+id: asd_v6r5
+revision: v6r5
+slug: asd
+stig_id: Application_Security_Development_STIG
+target_role: Application Server
+rules: asd_v6r4
+source:
+  zip_url: %s/U_ASD_V6R5_STIG.zip
+  xccdf_name: U_ASD_STIG_V6R5_Manual-xccdf.xml
+  sha256: %s
+`, server.URL, sha)
+		require.NoError(t, os.WriteFile(filepath.Join(libraryDir, "asd_v6r5.yaml"), []byte(definition), 0644))
+	}
+	writeDefinition(hex.EncodeToString(checksum[:]))
+
+	profile, err := os.ReadFile("src/test/stig/test-profile.yaml")
+	require.NoError(t, err)
+	profilePath := filepath.Join(t.TempDir(), "stig-profile.yaml")
+	require.NoError(t, os.WriteFile(profilePath, []byte(strings.Replace(string(profile), "id: asd_v6r4", "id: asd_v6r5", 1)), 0644))
+	cacheDir := t.TempDir()
+	outputPath := filepath.Join(t.TempDir(), "output.cklb")
+	generate := []string{"stig", "generate-checklist", "--library", libraryDir, "--profile", profilePath,
+		"--output", outputPath, "--cache-dir", cacheDir, "--offline"}
+
+	_, stderr, err := e2e.UDSPK(generate...)
+	require.Error(t, err)
+	assert.Contains(t, stderr, "STIG asd_v6r5 is not cached in "+cacheDir)
+
+	stdout, stderr, err := e2e.UDSPK("stig", "fetch", "asd_v6r5", "--library", libraryDir, "--cache-dir", cacheDir)
+	require.NoError(t, err, stdout, stderr)
+	assert.Contains(t, stdout, "Downloaded asd_v6r5 to "+filepath.Join(cacheDir, "asd_v6r5", "U_ASD_V6R5_STIG.zip"))
+
+	server.Close()
+	stdout, stderr, err = e2e.UDSPK(generate...)
+	require.NoError(t, err, stdout, stderr)
+	assert.Contains(t, stdout, "Total rules: 5")
+
+	// a re-published archive does not match the pinned checksum
+	writeDefinition(strings.Repeat("0", 64))
+	_, stderr, err = e2e.UDSPK(generate...)
+	require.Error(t, err)
+	assert.Contains(t, stderr, "checksum mismatch for the cached STIG asd_v6r5")
+}
+
+func TestStigValidate(t *testing.T) {
+	stdout, stderr, err := e2e.UDSPK("stig", "validate",
+		"--profile", "src/test/stig/test-profile.yaml",
+		"--xccdf", "asd_v6r4=src/test/stig/test-xccdf.xml",
+		"--xccdf", "rhel9_v2r7=src/test/stig/test-rhel9-xccdf.xml",
+	)
+	require.NoError(t, err, stdout, stderr)
+	assert.Contains(t, stdout, "src/test/stig/test-profile.yaml is valid")
+
+	profile, err := os.ReadFile("src/test/stig/test-profile.yaml")
+	require.NoError(t, err)
+	invalid := strings.NewReplacer(
+		"uses_pki_cac: false", "uses_pki: true",
+		"    id: asd_v6r4\n", "    id: asd_v6r4\n    overrides:\n      APSC-DV-000160:\n        status: pass\n      APSC-DV-999999:\n        status: open\n",
+	).Replace(string(profile))
+	profilePath := filepath.Join(t.TempDir(), "stig-profile.yaml")
+	require.NoError(t, os.WriteFile(profilePath, []byte(invalid), 0644))
+
+	stdout, stderr, err = e2e.UDSPK("stig", "validate", "--profile", profilePath,
+		"--xccdf", "asd_v6r4=src/test/stig/test-xccdf.xml",
+		"--xccdf", "rhel9_v2r7=src/test/stig/test-rhel9-xccdf.xml",
+	)
+	require.Error(t, err)
+	assert.Contains(t, stdout, `override of APSC-DV-000160 has status "pass"`)
+	assert.Contains(t, stdout, "override APSC-DV-999999 of asd_v6r4 does not match a rule of Application Security and Development")
+	assert.Contains(t, stdout, `unknown key "uses_pki" in stigs[0].characteristics, did you mean "uses_pki_cac"?`)
+	assert.Contains(t, stderr, "found 3 problems in "+profilePath)
+
+	// generating a checklist rejects the profile as well
+	_, stderr, err = e2e.UDSPK("stig", "generate-checklist", "--profile", profilePath,
+		"--xccdf", "src/test/stig/test-xccdf.xml",
+		"--output", filepath.Join(t.TempDir(), "output.cklb"),
+	)
+	require.Error(t, err)
+	assert.Contains(t, stderr, `unknown key "uses_pki"`)
+
+	// offline without a cache would download into a temporary directory
+	_, stderr, err = e2e.UDSPK("stig", "validate", "--profile", "src/test/stig/test-profile.yaml", "--cache-dir", "", "--offline")
+	require.Error(t, err)
+	assert.Contains(t, stderr, "--offline requires --cache-dir")
+}
@@ -0,0 +1,381 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package utils
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+
+	sbomsLayerTitle = "sboms.tar"
+
+	// DefaultRegistry is the registry released packages are fetched from when none is configured
+	DefaultRegistry = "ghcr.io"
+)
+
+// ErrNotFound is returned when a repository, tag or blob does not exist in the registry.
+var ErrNotFound = errors.New("not found in registry")
+
+// ErrUnauthorized is returned when the registry denies access, e.g. to a private repository without credentials.
+var ErrUnauthorized = errors.New("access denied by registry")
+
+// RegistryClient reads tags and SBOMs of Zarf packages from an OCI distribution registry,
+// e.g. ghcr.io, Harbor or the GitLab container registry.
+type RegistryClient struct {
+	// Registry is the host of the registry, optionally with a port
+	Registry  string
+	PlainHTTP bool
+	Username  string
+	Password  string
+	HTTP      *http.Client
+
+	token string
+}
+
+type registryManifest struct {
+	MediaType string       `json:"mediaType"`
+	Manifests []IndexEntry `json:"manifests"`
+	Layers    []Layer      `json:"layers"`
+}
+
+type tagList struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// NewRegistryClient creates a client for the registry. The password is read from tokenVarName,
+// or from GITHUB_TOKEN or GITLAB_RELEASE_TOKEN when no variable is given.
+func NewRegistryClient(registry string, plainHTTP bool, username string, tokenVarName string) *RegistryClient {
+	password := ""
+	if tokenVarName != "" {
+		password = os.Getenv(tokenVarName)
+	} else if password = os.Getenv("GITHUB_TOKEN"); password == "" {
+		password = os.Getenv("GITLAB_RELEASE_TOKEN")
+	}
+	if registry == "" {
+		registry = DefaultRegistry
+	}
+	return &RegistryClient{
+		Registry:  strings.TrimSuffix(registry, "/"),
+		PlainHTTP: plainHTTP,
+		Username:  username,
+		Password:  password,
+		HTTP:      &http.Client{},
+	}
+}
+
+// HasCredentials reports whether the client authenticates with a password or token
+func (c *RegistryClient) HasCredentials() bool {
+	return c.Password != ""
+}
+
+// ListTags returns all tags of the repository, following the pagination of the registry.
+// ErrNotFound is returned when the repository does not exist, ErrUnauthorized when it is not accessible.
+func (c *RegistryClient) ListTags(repository string, logger *slog.Logger) ([]string, error) {
+	var tags []string
+	next := c.url(repository, "tags/list")
+	for next != "" {
+		response, err := c.get(next, "application/json", logger)
+		if err != nil {
+			return nil, err
+		}
+		var list tagList
+		err = json.NewDecoder(response.Body).Decode(&list)
+		_ = response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tag list of %s: %w", repository, err)
+		}
+		tags = append(tags, list.Tags...)
+		next, err = c.nextPage(next, response.Header.Get("Link"))
+		if err != nil {
+			return nil, err
+		}
+	}
+	logger.Debug("Listed tags", slog.String("repository", repository), slog.Int("count", len(tags)))
+	return tags, nil
+}
+
+// FetchSboms pulls the sboms.tar layer of the package tagged tag and extracts the SBOM json files
+// to outputDir. For a multi-platform index, the manifest of the architecture is used, or the first one
+// when no architecture is given.
+func (c *RegistryClient) FetchSboms(repository string, tag string, arch string, outputDir string, logger *slog.Logger) ([]string, error) {
+	manifest, err := c.fetchManifest(repository, tag, logger)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Manifests) > 0 {
+		digest, err := platformManifest(manifest.Manifests, arch)
+		if err != nil {
+			return nil, fmt.Errorf("package %s:%s %w", repository, tag, err)
+		}
+		logger.Debug("Resolved index to manifest", slog.String("tag", tag), slog.String("arch", arch), slog.String("digest", digest))
+		if manifest, err = c.fetchManifest(repository, digest, logger); err != nil {
+			return nil, err
+		}
+	}
+
+	sbomsDigest := ""
+	for _, layer := range manifest.Layers {
+		if layer.Annotations["org.opencontainers.image.title"] == sbomsLayerTitle {
+			logger.Debug("found sboms.tar layer", slog.String("digest", layer.Digest))
+			sbomsDigest = layer.Digest
+			break
+		}
+	}
+	if sbomsDigest == "" {
+		return nil, fmt.Errorf("package %s:%s has no %s layer", repository, tag, sbomsLayerTitle)
+	}
+
+	response, err := c.get(c.url(repository, "blobs/"+sbomsDigest), "application/octet-stream", logger)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close() //nolint:errcheck
+	return extractSboms(response.Body, outputDir, logger)
+}
+
+// Architectures returns the architectures of the platform manifests in the index of the package tagged tag.
+func (c *RegistryClient) Architectures(repository string, tag string, logger *slog.Logger) ([]string, error) {
+	manifest, err := c.fetchManifest(repository, tag, logger)
+	if err != nil {
+		return nil, err
+	}
+	var architectures []string
+	for _, entry := range manifest.Manifests {
+		if entry.Platform.Architecture != "" && !slices.Contains(architectures, entry.Platform.Architecture) {
+			architectures = append(architectures, entry.Platform.Architecture)
+		}
+	}
+	return architectures, nil
+}
+
+// platformManifest returns the digest of the index entry for the architecture
+func platformManifest(entries []IndexEntry, arch string) (string, error) {
+	if arch == "" {
+		return entries[0].Digest, nil
+	}
+	var available []string
+	for _, entry := range entries {
+		if entry.Platform.Architecture == arch {
+			return entry.Digest, nil
+		}
+		available = append(available, entry.Platform.Architecture)
+	}
+	return "", fmt.Errorf("%w: no manifest for architecture %s (available: %s)", ErrNotFound, arch, strings.Join(available, ", "))
+}
+
+func (c *RegistryClient) fetchManifest(repository string, reference string, logger *slog.Logger) (registryManifest, error) {
+	accept := strings.Join([]string{mediaTypeOCIIndex, mediaTypeOCIManifest, mediaTypeDockerManifestList, mediaTypeDockerManifest}, ", ")
+	manifestUrl := c.url(repository, "manifests/"+reference)
+	response, err := c.get(manifestUrl, accept, logger)
+	if err != nil {
+		return registryManifest{}, err
+	}
+	defer response.Body.Close() //nolint:errcheck
+	var manifest registryManifest
+	if err := json.NewDecoder(response.Body).Decode(&manifest); err != nil {
+		return registryManifest{}, fmt.Errorf("failed to unmarshal manifest json from %s: %w", manifestUrl, err)
+	}
+	return manifest, nil
+}
+
+// extractSboms writes the json files of the sboms.tar archive to outputDir and returns their paths
+func extractSboms(archive io.Reader, outputDir string, logger *slog.Logger) ([]string, error) {
+	var extractedFiles []string
+	tarReader := tar.NewReader(archive)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg || !strings.HasSuffix(header.Name, "json") {
+			continue
+		}
+		logger.Debug("extracting sbom", slog.String("name", header.Name))
+		// only the file name is kept so that entries cannot be written outside of the output directory
+		outPath := filepath.Join(outputDir, filepath.Base(header.Name))
+		if err := writeFile(outPath, tarReader); err != nil {
+			return nil, fmt.Errorf("failed to copy sbom out of tar: %w", err)
+		}
+		extractedFiles = append(extractedFiles, outPath)
+	}
+	return extractedFiles, nil
+}
+
+func writeFile(path string, content io.Reader) error {
+	outFile, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(outFile, content); err != nil {
+		_ = outFile.Close()
+		return err
+	}
+	return outFile.Close()
+}
+
+func (c *RegistryClient) url(repository string, path string) string {
+	scheme := "https"
+	if c.PlainHTTP {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, c.Registry, strings.Trim(repository, "/"), path)
+}
+
+var linkNext = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// nextPage resolves the next page of a paginated response from its Link header, if any
+func (c *RegistryClient) nextPage(current string, link string) (string, error) {
+	match := linkNext.FindStringSubmatch(link)
+	if match == nil {
+		return "", nil
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	next, err := base.Parse(match[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid pagination link %q: %w", link, err)
+	}
+	return next.String(), nil
+}
+
+// get requests the url and authenticates with the registry when it asks for it
+func (c *RegistryClient) get(requestUrl string, accept string, logger *slog.Logger) (*http.Response, error) {
+	response, err := c.do(requestUrl, accept)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusUnauthorized {
+		challenge := response.Header.Get("WWW-Authenticate")
+		_ = response.Body.Close()
+		if err := c.authenticate(challenge, logger); err != nil {
+			return nil, err
+		}
+		if response, err = c.do(requestUrl, accept); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case response.StatusCode >= 200 && response.StatusCode < 300:
+		return response, nil
+	case response.StatusCode == http.StatusNotFound:
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("%w: %s returned %s", ErrNotFound, requestUrl, response.Status)
+	case response.StatusCode == http.StatusUnauthorized, response.StatusCode == http.StatusForbidden:
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("%w: %s returned %s, check the registry credentials", ErrUnauthorized, requestUrl, response.Status)
+	default:
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %s from %s", response.Status, requestUrl)
+	}
+}
+
+func (c *RegistryClient) do(requestUrl string, accept string) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Accept", accept)
+	switch {
+	case c.token != "":
+		request.Header.Set("Authorization", "Bearer "+c.token)
+	case c.Password != "":
+		request.SetBasicAuth(c.username(), c.Password)
+	}
+	return c.HTTP.Do(request)
+}
+
+// authenticate obtains a bearer token from the token service named in the challenge of the registry.
+// A basic challenge is answered with the credentials directly on the next request.
+func (c *RegistryClient) authenticate(challenge string, logger *slog.Logger) error {
+	scheme, params := parseChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") || params["realm"] == "" {
+		return nil
+	}
+	tokenUrl, err := url.Parse(params["realm"])
+	if err != nil {
+		return fmt.Errorf("invalid token realm %q: %w", params["realm"], err)
+	}
+	query := tokenUrl.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	tokenUrl.RawQuery = query.Encode()
+	logger.Debug("Requesting registry token", slog.String("realm", params["realm"]), slog.String("scope", params["scope"]))
+
+	request, err := http.NewRequest(http.MethodGet, tokenUrl.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.Password != "" {
+		request.SetBasicAuth(c.username(), c.Password)
+	}
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close() //nolint:errcheck
+	if response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: failed to get a token from %s: %s, check the registry credentials", ErrUnauthorized, params["realm"], response.Status)
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("failed to get a token from %s: %s", params["realm"], response.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to parse token from %s: %w", params["realm"], err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	return nil
+}
+
+// username is sent with the password; registries that authenticate with a token alone, like ghcr.io, ignore it
+func (c *RegistryClient) username() string {
+	if c.Username == "" {
+		return "uds-pk"
+	}
+	return c.Username
+}
+
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// parseChallenge splits a WWW-Authenticate header into its scheme and parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for _, match := range challengeParam.FindAllStringSubmatch(rest, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	return scheme, params
+}
@@ -0,0 +1,198 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sbomsArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	for name, content := range files {
+		if err := writer.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newTestRegistry serves a single package repository behind a bearer token challenge
+func newTestRegistry(t *testing.T, repository string, archive []byte) (*httptest.Server, *RegistryClient) {
+	t.Helper()
+	arm64Archive := sbomsArchive(t, map[string]string{"app_1.0.0_arm64.json": `{"source":{}}`})
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, password, ok := r.BasicAuth(); !ok || user != "robot" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:"+repository+":pull" {
+				t.Errorf("unexpected token scope %q", r.URL.Query().Get("scope"))
+			}
+			_, _ = w.Write([]byte(`{"token":"registry-token"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer registry-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:%s:pull"`, srv.URL, repository))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		base := "/v2/" + repository + "/"
+		if !strings.HasPrefix(r.URL.Path, base) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch strings.TrimPrefix(r.URL.Path, base) {
+		case "tags/list":
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?n=2&last=1.0.0-upstream>; rel="next"`, repository))
+				_, _ = w.Write([]byte(`{"tags":["0.9.0-upstream","1.0.0-upstream"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"tags":["1.0.0-registry1"]}`))
+		case "manifests/1.0.0-upstream":
+			_, _ = w.Write([]byte(`{"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[{"digest":"sha256:manifest","platform":{"architecture":"amd64","os":"linux"}},{"digest":"sha256:manifest-arm64","platform":{"architecture":"arm64","os":"linux"}}]}`))
+		case "manifests/sha256:manifest":
+			_, _ = w.Write([]byte(`{"layers":[{"digest":"sha256:other","annotations":{"org.opencontainers.image.title":"zarf.yaml"}},{"digest":"sha256:sboms","annotations":{"org.opencontainers.image.title":"sboms.tar"}}]}`))
+		case "manifests/sha256:manifest-arm64":
+			_, _ = w.Write([]byte(`{"layers":[{"digest":"sha256:sboms-arm64","annotations":{"org.opencontainers.image.title":"sboms.tar"}}]}`))
+		case "blobs/sha256:sboms":
+			_, _ = w.Write(archive)
+		case "blobs/sha256:sboms-arm64":
+			_, _ = w.Write(arm64Archive)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := &RegistryClient{
+		Registry:  strings.TrimPrefix(srv.URL, "http://"),
+		PlainHTTP: true,
+		Username:  "robot",
+		Password:  "secret",
+		HTTP:      srv.Client(),
+	}
+	return srv, client
+}
+
+func TestRegistryClient_ListTags(t *testing.T) {
+	_, client := newTestRegistry(t, "uds-packages/app", nil)
+
+	tags, err := client.ListTags("uds-packages/app", slog.Default())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if strings.Join(tags, ",") != "0.9.0-upstream,1.0.0-upstream,1.0.0-registry1" {
+		t.Errorf("Expected the tags of all pages, got %v", tags)
+	}
+
+	if _, err := client.ListTags("uds-packages/missing", slog.Default()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing repository, got %v", err)
+	}
+}
+
+func TestRegistryClient_ListTags_Unauthorized(t *testing.T) {
+	_, client := newTestRegistry(t, "uds-packages/app", nil)
+	client.Password = "wrong"
+
+	if _, err := client.ListTags("uds-packages/app", slog.Default()); err == nil || !strings.Contains(err.Error(), "failed to get a token") {
+		t.Errorf("Expected the token request to fail, got %v", err)
+	}
+}
+
+func TestRegistryClient_ListTags_Denied(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+	client := &RegistryClient{Registry: strings.TrimPrefix(srv.URL, "http://"), PlainHTTP: true, HTTP: srv.Client()}
+
+	_, err := client.ListTags("uds-packages/private/app", slog.Default())
+	if !errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrUnauthorized for a repository the registry denies access to, got %v", err)
+	}
+}
+
+func TestRegistryClient_FetchSboms(t *testing.T) {
+	archive := sbomsArchive(t, map[string]string{
+		"app_1.0.0.json":        `{"source":{}}`,
+		"../escape.json":        `{}`,
+		"app_1.0.0.html":        `<html></html>`,
+		"nested/sidecar_1.json": `{}`,
+	})
+	_, client := newTestRegistry(t, "uds-packages/app", archive)
+	outputDir := t.TempDir()
+
+	sboms, err := client.FetchSboms("uds-packages/app", "1.0.0-upstream", "amd64", outputDir, slog.Default())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(sboms) != 3 {
+		t.Fatalf("Expected the 3 json files to be extracted, got %v", sboms)
+	}
+	for _, sbom := range sboms {
+		if filepath.Dir(sbom) != outputDir {
+			t.Errorf("Expected %s to be extracted into the output directory", sbom)
+		}
+		if _, err := os.Stat(sbom); err != nil {
+			t.Errorf("Expected %s to exist: %v", sbom, err)
+		}
+	}
+
+	if _, err := client.FetchSboms("uds-packages/app", "0.9.0-upstream", "", outputDir, slog.Default()); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected an error for a missing tag, got %v", err)
+	}
+}
+
+func TestRegistryClient_FetchSboms_Architecture(t *testing.T) {
+	_, client := newTestRegistry(t, "uds-packages/app", sbomsArchive(t, map[string]string{"app_1.0.0.json": `{}`}))
+
+	architectures, err := client.Architectures("uds-packages/app", "1.0.0-upstream", slog.Default())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if strings.Join(architectures, ",") != "amd64,arm64" {
+		t.Errorf("Expected amd64 and arm64, got %v", architectures)
+	}
+
+	sboms, err := client.FetchSboms("uds-packages/app", "1.0.0-upstream", "arm64", t.TempDir(), slog.Default())
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(sboms) != 1 || filepath.Base(sboms[0]) != "app_1.0.0_arm64.json" {
+		t.Errorf("Expected the SBOMs of the arm64 manifest, got %v", sboms)
+	}
+
+	_, err = client.FetchSboms("uds-packages/app", "1.0.0-upstream", "s390x", t.TempDir(), slog.Default())
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "available: amd64, arm64") {
+		t.Errorf("Expected ErrNotFound listing the available architectures, got %v", err)
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://ghcr.io/token",service="ghcr.io",scope="repository:uds-packages/app:pull"`)
+	if scheme != "Bearer" || params["realm"] != "https://ghcr.io/token" || params["service"] != "ghcr.io" || params["scope"] != "repository:uds-packages/app:pull" {
+		t.Errorf("Unexpected challenge %s %v", scheme, params)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReleaseTag is a tag of a released package, created by GetFormattedVersion from a version and a flavor.
+type ReleaseTag struct {
+	Tag     string
+	Version string
+	Flavor  string
+
+	core       [3]int
+	prerelease []string
+}
+
+var semverPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+
+// udsRevision is the prerelease part UDS appends to the upstream version of a package, e.g. uds.0 in 1.10.0-uds.0
+var udsRevision = regexp.MustCompile(`^uds\.\d+$`)
+
+// udsRevisionTag splits a tag after the UDS revision of its version into the version and the flavor, e.g.
+// 1.10.0-uds.0 and fips-upstream in 1.10.0-uds.0-fips-upstream
+var udsRevisionTag = regexp.MustCompile(`^(.+?-uds\.\d+(?:\+[0-9A-Za-z.]+)?)-(.+)$`)
+
+// ParseReleaseTag parses a tag in the form <version>-<flavor>. The flavor is everything after the UDS
+// revision of the version, e.g. fips-upstream in 1.10.0-uds.0-fips-upstream, and has to equal the flavor; a
+// version without a UDS revision ends before the flavor suffix. It reports false when the tag is not for the
+// flavor or its version is not a semantic version.
+func ParseReleaseTag(tag string, flavor string) (ReleaseTag, bool) {
+	var version string
+	if match := udsRevisionTag.FindStringSubmatch(tag); match != nil {
+		if match[2] != flavor {
+			return ReleaseTag{}, false
+		}
+		version = match[1]
+	} else if cut, found := strings.CutSuffix(tag, "-"+flavor); found && cut != "" {
+		version = cut
+	} else {
+		return ReleaseTag{}, false
+	}
+	match := semverPattern.FindStringSubmatch(version)
+	if match == nil {
+		return ReleaseTag{}, false
+	}
+	releaseTag := ReleaseTag{Tag: tag, Version: version, Flavor: flavor}
+	for i := range releaseTag.core {
+		number, err := strconv.Atoi(match[i+1])
+		if err != nil {
+			return ReleaseTag{}, false
+		}
+		releaseTag.core[i] = number
+	}
+	if match[4] != "" {
+		releaseTag.prerelease = strings.Split(match[4], ".")
+	}
+	return releaseTag, true
+}
+
+// IsPrerelease reports whether the version is a pre-release. The UDS revision of a package, e.g. -uds.0,
+// is part of every released version and does not make it a pre-release.
+func (t ReleaseTag) IsPrerelease() bool {
+	return len(t.prerelease) > 0 && !udsRevision.MatchString(strings.Join(t.prerelease, "."))
+}
+
+// Compare orders release tags by the semantic version precedence of their versions.
+func (t ReleaseTag) Compare(other ReleaseTag) int {
+	for i := range t.core {
+		if t.core[i] != other.core[i] {
+			return compareInts(t.core[i], other.core[i])
+		}
+	}
+	// a version without pre-release identifiers has a higher precedence than one with them
+	switch {
+	case len(t.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	case len(t.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(t.prerelease) && i < len(other.prerelease); i++ {
+		if result := compareIdentifiers(t.prerelease[i], other.prerelease[i]); result != 0 {
+			return result
+		}
+	}
+	return compareInts(len(t.prerelease), len(other.prerelease))
+}
+
+// compareIdentifiers compares pre-release identifiers: numeric ones numerically and lower than alphanumeric ones
+func compareIdentifiers(a string, b string) int {
+	aNumber, aErr := strconv.Atoi(a)
+	bNumber, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareInts(aNumber, bNumber)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
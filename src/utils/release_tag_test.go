@@ -0,0 +1,57 @@
+// Copyright 2026 Defense Unicorns
+// SPDX-License-Identifier: AGPL-3.0-or-later OR LicenseRef-Defense-Unicorns-Commercial
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReleaseTag(t *testing.T) {
+	tag, ok := ParseReleaseTag("1.10.0-uds.0-upstream", "upstream")
+	require.True(t, ok)
+	require.Equal(t, "1.10.0-uds.0", tag.Version)
+	require.False(t, tag.IsPrerelease())
+
+	tag, ok = ParseReleaseTag("v2.0.0-rc.1-uds.0-registry1", "registry1")
+	require.True(t, ok)
+	require.True(t, tag.IsPrerelease())
+
+	tag, ok = ParseReleaseTag("1.12.0-uds.0+build.1-fips-upstream", "fips-upstream")
+	require.True(t, ok)
+	require.Equal(t, "1.12.0-uds.0+build.1", tag.Version)
+
+	for _, invalid := range []string{"1.10.0-uds.0-registry1", "1.12.0-uds.0-fips-upstream", "1.11.0-rc.1-uds.0-fips-upstream", "latest-upstream", "-upstream", "1.10-uds.0-upstream", "upstream"} {
+		_, ok := ParseReleaseTag(invalid, "upstream")
+		require.False(t, ok, invalid)
+	}
+}
+
+func TestReleaseTagCompare(t *testing.T) {
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-rc.1",
+		"1.0.0-uds.0",
+		"1.0.0-uds.2",
+		"1.0.0-uds.10",
+		"1.0.0",
+		"1.2.0-uds.0",
+		"1.10.0-uds.0",
+		"2.0.0-uds.0",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		lower, ok := ParseReleaseTag(ordered[i]+"-upstream", "upstream")
+		require.True(t, ok, ordered[i])
+		higher, ok := ParseReleaseTag(ordered[i+1]+"-upstream", "upstream")
+		require.True(t, ok, ordered[i+1])
+		require.Equal(t, -1, lower.Compare(higher), "%s < %s", ordered[i], ordered[i+1])
+		require.Equal(t, 1, higher.Compare(lower), "%s > %s", ordered[i+1], ordered[i])
+	}
+
+	a, _ := ParseReleaseTag("1.0.0-uds.0+build.1-upstream", "upstream")
+	b, _ := ParseReleaseTag("1.0.0-uds.0-upstream", "upstream")
+	require.Equal(t, 0, a.Compare(b), "build metadata is ignored")
+}
@@ -5,6 +5,7 @@ package utils
 
 import (
 	"io"
+	"os"
 	"os/exec"
 )
 
@@ -12,6 +13,8 @@ type CommandRunner interface {
 	Run() error
 	SetStdout(stdout io.Writer)
 	SetStderr(stderr io.Writer)
+	// SetEnv adds variables in the form key=value to the environment inherited from the current process
+	SetEnv(env []string)
 	CombinedOutput() ([]byte, error)
 }
 
@@ -33,6 +36,10 @@ func (r *RealCommand) SetStderr(stderr io.Writer) {
 	r.cmd.Stderr = stderr
 }
 
+func (r *RealCommand) SetEnv(env []string) {
+	r.cmd.Env = append(os.Environ(), env...)
+}
+
 func (r *RealCommand) CombinedOutput() ([]byte, error) {
 	return r.cmd.CombinedOutput()
 }